@@ -0,0 +1,14 @@
+// Package cache provides the byte caches behind repositories.CachedSongStore:
+// an in-process LRU and a client for servers speaking the Redis protocol.
+package cache
+
+import "time"
+
+// Cache stores values under string keys for a limited time. A zero ttl keeps
+// a value until it is evicted or deleted.
+type Cache interface {
+	// Get returns the value of key and whether it was found.
+	Get(key string) ([]byte, bool, error)
+	Set(key string, value []byte, ttl time.Duration) error
+	Delete(keys ...string) error
+}
@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often Set scans the whole LRU for expired entries.
+const sweepInterval = time.Second
+
+// LRU is a thread-safe in-process Cache that evicts the least recently used
+// entry once it holds capacity entries. Expired entries are dropped when
+// they are read, reach the end of the list, or by the sweep Set runs every
+// sweepInterval, so an unbounded LRU does not keep them either.
+type LRU struct {
+	mu       sync.Mutex
+	capacity int
+	// order holds *lruEntry values, most recently used first.
+	order   *list.List
+	entries map[string]*list.Element
+	swept   time.Time
+}
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewLRU returns an LRU holding at most capacity entries; a capacity of 0
+// or less leaves it unbounded.
+func NewLRU(capacity int) *LRU {
+	return &LRU{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (c *LRU) Get(key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*lruEntry)
+	if entry.expired(time.Now()) {
+		c.remove(element)
+		return nil, false, nil
+	}
+	c.order.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+func (c *LRU) Set(key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.swept) >= sweepInterval {
+		c.sweep(now)
+	}
+
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = now.Add(ttl)
+	}
+
+	if element, ok := c.entries[key]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.value, entry.expiresAt = value, expiresAt
+		c.order.MoveToFront(element)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	for c.capacity > 0 && c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+func (c *LRU) Delete(keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := c.entries[key]; ok {
+			c.remove(element)
+		}
+	}
+	return nil
+}
+
+// Clear removes every entry.
+func (c *LRU) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+}
+
+// Len returns the number of entries, expired ones included.
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
+
+// sweep removes every expired entry; the caller must hold the lock.
+func (c *LRU) sweep(now time.Time) {
+	for element := c.order.Front(); element != nil; {
+		next := element.Next()
+		if element.Value.(*lruEntry).expired(now) {
+			c.remove(element)
+		}
+		element = next
+	}
+	c.swept = now
+}
+
+// remove unlinks element; the caller must hold the lock.
+func (c *LRU) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*lruEntry).key)
+}
+
+func (e *lruEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Redis is a Cache kept on a server speaking the Redis protocol, so that it
+// is shared by every replica. It keeps up to poolSize idle connections and
+// drops a connection after any I/O error.
+type Redis struct {
+	addr     string
+	password string
+	timeout  time.Duration
+	idle     chan *redisConn
+}
+
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+// NewRedis returns a client for the server at addr. Connections are opened
+// on first use and authenticate with password unless it is empty; timeout
+// bounds dialing and every command.
+func NewRedis(addr, password string, timeout time.Duration, poolSize int) *Redis {
+	return &Redis{
+		addr:     addr,
+		password: password,
+		timeout:  timeout,
+		idle:     make(chan *redisConn, poolSize),
+	}
+}
+
+func (c *Redis) Get(key string) ([]byte, bool, error) {
+	reply, err := c.do([]byte("GET"), []byte(key))
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: unexpected GET reply %T", reply)
+	}
+	return value, true, nil
+}
+
+func (c *Redis) Set(key string, value []byte, ttl time.Duration) error {
+	args := [][]byte{[]byte("SET"), []byte(key), value}
+	if ttl > 0 {
+		// Round up: PX 0 is an error, and a TTL should never come out shorter.
+		ms := (ttl + time.Millisecond - 1) / time.Millisecond
+		args = append(args, []byte("PX"), []byte(strconv.FormatInt(int64(ms), 10)))
+	}
+	_, err := c.do(args...)
+	return err
+}
+
+func (c *Redis) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	args := [][]byte{[]byte("DEL")}
+	for _, key := range keys {
+		args = append(args, []byte(key))
+	}
+	_, err := c.do(args...)
+	return err
+}
+
+// Ping checks that the server is reachable.
+func (c *Redis) Ping() error {
+	_, err := c.do([]byte("PING"))
+	return err
+}
+
+// Close closes the idle connections.
+func (c *Redis) Close() error {
+	for {
+		select {
+		case conn := <-c.idle:
+			conn.conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do runs a command and returns its reply, with error replies as errors.
+func (c *Redis) do(args ...[]byte) (interface{}, error) {
+	conn, err := c.get()
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := conn.do(c.timeout, args...)
+	if err != nil {
+		conn.conn.Close()
+		return nil, fmt.Errorf("redis %s: %w", args[0], err)
+	}
+	c.put(conn)
+
+	if replyErr, ok := reply.(respError); ok {
+		return nil, fmt.Errorf("redis %s: %w", args[0], replyErr)
+	}
+	return reply, nil
+}
+
+func (c *Redis) get() (*redisConn, error) {
+	select {
+	case conn := <-c.idle:
+		return conn, nil
+	default:
+	}
+
+	netConn, err := net.DialTimeout("tcp", c.addr, c.timeout)
+	if err != nil {
+		return nil, fmt.Errorf("redis: %w", err)
+	}
+	conn := &redisConn{conn: netConn, r: bufio.NewReader(netConn), w: bufio.NewWriter(netConn)}
+
+	if c.password != "" {
+		reply, err := conn.do(c.timeout, []byte("AUTH"), []byte(c.password))
+		if err == nil {
+			if replyErr, ok := reply.(respError); ok {
+				err = replyErr
+			}
+		}
+		if err != nil {
+			netConn.Close()
+			return nil, fmt.Errorf("redis AUTH: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+func (c *Redis) put(conn *redisConn) {
+	select {
+	case c.idle <- conn:
+	default:
+		conn.conn.Close()
+	}
+}
+
+func (c *redisConn) do(timeout time.Duration, args ...[]byte) (interface{}, error) {
+	if timeout > 0 {
+		if err := c.conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+			return nil, err
+		}
+	}
+	if err := writeCommand(c.w, args...); err != nil {
+		return nil, err
+	}
+	return readReply(c.r)
+}
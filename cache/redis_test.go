@@ -0,0 +1,203 @@
+package cache
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// startServer serves a Server on a random local port for the duration of
+// the test and returns its address.
+func startServer(t *testing.T, capacity int, password string) string {
+	t.Helper()
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := NewServer(capacity, password, log)
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	return listener.Addr().String()
+}
+
+func newTestRedis(t *testing.T, addr, password string) *Redis {
+	t.Helper()
+
+	client := NewRedis(addr, password, time.Second, 2)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestRedisGetSetDelete(t *testing.T) {
+	client := newTestRedis(t, startServer(t, 0, ""), "")
+
+	if err := client.Set("a", []byte("1"), 0); err != nil {
+		t.Fatalf("Set(a): %v", err)
+	}
+	if err := client.Set("b", []byte("2"), 0); err != nil {
+		t.Fatalf("Set(b): %v", err)
+	}
+
+	tests := []struct {
+		key   string
+		value []byte
+		found bool
+	}{
+		{"a", []byte("1"), true},
+		{"b", []byte("2"), true},
+		{"missing", nil, false},
+	}
+	for _, tt := range tests {
+		value, found, err := client.Get(tt.key)
+		if err != nil {
+			t.Fatalf("Get(%q): %v", tt.key, err)
+		}
+		if found != tt.found || !bytes.Equal(value, tt.value) {
+			t.Errorf("Get(%q) = %q, %v; want %q, %v", tt.key, value, found, tt.value, tt.found)
+		}
+	}
+
+	if err := client.Delete("a", "missing"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, found, _ := client.Get("a"); found {
+		t.Error("Get(a) found the value after Delete")
+	}
+	if _, found, _ := client.Get("b"); !found {
+		t.Error("Get(b) lost a value that was not deleted")
+	}
+}
+
+func TestRedisSetBinaryValue(t *testing.T) {
+	client := newTestRedis(t, startServer(t, 0, ""), "")
+
+	value := []byte("line one\r\nline two\x00$3\r\n")
+	if err := client.Set("binary", value, 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	got, found, err := client.Get("binary")
+	if err != nil || !found || !bytes.Equal(got, value) {
+		t.Errorf("Get = %q, %v, %v; want %q", got, found, err, value)
+	}
+}
+
+func TestRedisSetTTL(t *testing.T) {
+	client := newTestRedis(t, startServer(t, 0, ""), "")
+
+	// Set sends the TTL as PX in milliseconds.
+	if err := client.Set("short", []byte("x"), 50*time.Millisecond); err != nil {
+		t.Fatalf("Set(short): %v", err)
+	}
+	if err := client.Set("long", []byte("y"), time.Hour); err != nil {
+		t.Fatalf("Set(long): %v", err)
+	}
+	if _, found, _ := client.Get("short"); !found {
+		t.Fatal("Get(short) missed before the TTL ran out")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if _, found, _ := client.Get("short"); found {
+		t.Error("Get(short) found the value after the TTL ran out")
+	}
+	if _, found, _ := client.Get("long"); !found {
+		t.Error("Get(long) missed before the TTL ran out")
+	}
+}
+
+func TestRedisSetSubMillisecondTTL(t *testing.T) {
+	client := newTestRedis(t, startServer(t, 0, ""), "")
+
+	// A TTL under a millisecond is rounded up rather than sent as PX 0.
+	if err := client.Set("brief", []byte("x"), 500*time.Microsecond); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+}
+
+func TestRedisAuth(t *testing.T) {
+	addr := startServer(t, 0, "s3cret")
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  string
+	}{
+		{"correct password", "s3cret", ""},
+		{"wrong password", "guess", "WRONGPASS"},
+		{"no password", "", "NOAUTH"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestRedis(t, addr, tt.password)
+
+			err := client.Set("key", []byte("value"), 0)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Set: %v", err)
+				}
+				if _, found, err := client.Get("key"); err != nil || !found {
+					t.Errorf("Get = %v, %v; want found", found, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Set error = %v; want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRedisPingWithoutAuth(t *testing.T) {
+	client := newTestRedis(t, startServer(t, 0, "s3cret"), "")
+
+	if err := client.Ping(); err != nil {
+		t.Errorf("Ping: %v", err)
+	}
+}
+
+func TestServerEvictsLeastRecentlyUsed(t *testing.T) {
+	client := newTestRedis(t, startServer(t, 2, ""), "")
+
+	for _, key := range []string{"a", "b"} {
+		if err := client.Set(key, []byte(key), 0); err != nil {
+			t.Fatalf("Set(%q): %v", key, err)
+		}
+	}
+	// Reading a makes b the least recently used key.
+	if _, found, _ := client.Get("a"); !found {
+		t.Fatal("Get(a) missed")
+	}
+	if err := client.Set("c", []byte("c"), 0); err != nil {
+		t.Fatalf("Set(c): %v", err)
+	}
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, found, _ := client.Get(key); found != want {
+			t.Errorf("Get(%q) found = %v; want %v", key, found, want)
+		}
+	}
+}
+
+func TestLRUSweepsExpiredEntriesOnSet(t *testing.T) {
+	c := NewLRU(0)
+	for _, key := range []string{"a", "b", "c"} {
+		c.Set(key, []byte(key), time.Millisecond)
+	}
+
+	time.Sleep(sweepInterval + 10*time.Millisecond)
+	c.Set("fresh", []byte("x"), 0)
+
+	if n := c.Len(); n != 1 {
+		t.Errorf("Len() = %d after the sweep; want 1", n)
+	}
+}
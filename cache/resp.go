@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// maxBulkLen bounds the bulk strings read from a peer, as Redis does.
+const maxBulkLen = 512 << 20
+
+var errProtocol = errors.New("resp: protocol error")
+
+// respError is an error reply such as "-ERR unknown command".
+type respError string
+
+func (e respError) Error() string { return string(e) }
+
+// writeCommand writes args as a RESP array of bulk strings.
+func writeCommand(w *bufio.Writer, args ...[]byte) error {
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		writeBulk(w, arg)
+	}
+	return w.Flush()
+}
+
+func writeBulk(w *bufio.Writer, value []byte) {
+	if value == nil {
+		w.WriteString("$-1\r\n")
+		return
+	}
+	fmt.Fprintf(w, "$%d\r\n", len(value))
+	w.Write(value)
+	w.WriteString("\r\n")
+}
+
+func writeSimple(w *bufio.Writer, status string) {
+	w.WriteString("+" + status + "\r\n")
+}
+
+func writeError(w *bufio.Writer, message string) {
+	w.WriteString("-" + message + "\r\n")
+}
+
+func writeInteger(w *bufio.Writer, n int) {
+	w.WriteString(":" + strconv.Itoa(n) + "\r\n")
+}
+
+// readReply reads one RESP value: a string for simple strings, []byte or nil
+// for bulk strings, int64 for integers, []interface{} for arrays and
+// respError for error replies.
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errProtocol
+	}
+
+	switch line[0] {
+	case '+':
+		return string(line[1:]), nil
+	case '-':
+		return respError(line[1:]), nil
+	case ':':
+		return strconv.ParseInt(string(line[1:]), 10, 64)
+	case '$':
+		n, err := strconv.Atoi(string(line[1:]))
+		if err != nil || n > maxBulkLen {
+			return nil, errProtocol
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		value := make([]byte, n+2)
+		if _, err := io.ReadFull(r, value); err != nil {
+			return nil, err
+		}
+		if value[n] != '\r' || value[n+1] != '\n' {
+			return nil, errProtocol
+		}
+		return value[:n], nil
+	case '*':
+		n, err := strconv.Atoi(string(line[1:]))
+		if err != nil {
+			return nil, errProtocol
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		values := make([]interface{}, 0, min(n, 1024))
+		for i := 0; i < n; i++ {
+			value, err := readReply(r)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	default:
+		return nil, errProtocol
+	}
+}
+
+// readLine reads a CRLF-terminated line without the terminator.
+func readLine(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return nil, errProtocol
+	}
+	return line[:len(line)-2], nil
+}
@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Server is an in-memory stand-in for Redis that understands the commands
+// Redis uses (PING, AUTH, GET, SET with EX or PX, DEL, FLUSHDB, QUIT). It
+// runs local setups and tests without a real server.
+type Server struct {
+	store    *LRU
+	password string
+	log      *logrus.Logger
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+}
+
+// NewServer returns a server keeping at most capacity keys, evicting the
+// least recently used ones like Redis with maxmemory-policy allkeys-lru. With
+// a password, clients must AUTH before any command but PING and QUIT.
+func NewServer(capacity int, password string, log *logrus.Logger) *Server {
+	return &Server{
+		store:    NewLRU(capacity),
+		password: password,
+		log:      log,
+		conns:    make(map[net.Conn]struct{}),
+	}
+}
+
+// Serve accepts connections on l until Close is called.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	s.listener = l
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		go s.serveConn(conn)
+	}
+}
+
+// Close stops Serve and closes the open connections.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for conn := range s.conns {
+		conn.Close()
+	}
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Close()
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	authenticated := s.password == ""
+	for {
+		request, err := readReply(r)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) && !errors.Is(err, io.EOF) {
+				s.log.WithFields(logrus.Fields{
+					"error": err,
+				}).Debug("Closing cache connection")
+			}
+			return
+		}
+
+		args, ok := commandArgs(request)
+		if !ok {
+			writeError(w, "ERR Protocol error: expected an array of bulk strings")
+			w.Flush()
+			return
+		}
+
+		quit := s.execute(w, args, &authenticated)
+		if err := w.Flush(); err != nil || quit {
+			return
+		}
+	}
+}
+
+// execute runs one command and reports whether the connection should close.
+// authenticated is the AUTH state of the connection.
+func (s *Server) execute(w *bufio.Writer, args [][]byte, authenticated *bool) bool {
+	name := strings.ToUpper(string(args[0]))
+	if !*authenticated && name != "AUTH" && name != "PING" && name != "QUIT" {
+		writeError(w, "NOAUTH Authentication required.")
+		return false
+	}
+
+	switch {
+	case name == "PING" && len(args) == 1:
+		writeSimple(w, "PONG")
+	case name == "PING" && len(args) == 2:
+		writeBulk(w, args[1])
+	case name == "AUTH" && (len(args) == 2 || len(args) == 3):
+		// The password is the last argument; a username, if any, is ignored.
+		if s.password != "" && string(args[len(args)-1]) != s.password {
+			writeError(w, "WRONGPASS invalid username-password pair or user is disabled.")
+			return false
+		}
+		*authenticated = true
+		writeSimple(w, "OK")
+	case name == "GET" && len(args) == 2:
+		value, _, _ := s.store.Get(string(args[1]))
+		writeBulk(w, value)
+	case name == "SET" && (len(args) == 3 || len(args) == 5):
+		var ttl time.Duration
+		if len(args) == 5 {
+			n, err := strconv.ParseInt(string(args[4]), 10, 64)
+			unit := strings.ToUpper(string(args[3]))
+			if err != nil || n <= 0 || (unit != "EX" && unit != "PX") {
+				writeError(w, "ERR syntax error")
+				return false
+			}
+			ttl = time.Duration(n) * time.Millisecond
+			if unit == "EX" {
+				ttl = time.Duration(n) * time.Second
+			}
+		}
+		s.store.Set(string(args[1]), args[2], ttl)
+		writeSimple(w, "OK")
+	case name == "DEL" && len(args) > 1:
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok, _ := s.store.Get(string(key)); ok {
+				s.store.Delete(string(key))
+				deleted++
+			}
+		}
+		writeInteger(w, deleted)
+	case (name == "FLUSHDB" || name == "FLUSHALL") && len(args) == 1:
+		s.store.Clear()
+		writeSimple(w, "OK")
+	case name == "QUIT":
+		writeSimple(w, "OK")
+		return true
+	default:
+		writeError(w, "ERR unknown command or wrong number of arguments for '"+string(args[0])+"'")
+	}
+	return false
+}
+
+// commandArgs converts a request read by readReply to its arguments.
+func commandArgs(request interface{}) ([][]byte, bool) {
+	values, ok := request.([]interface{})
+	if !ok || len(values) == 0 {
+		return nil, false
+	}
+	args := make([][]byte, 0, len(values))
+	for _, value := range values {
+		arg, ok := value.([]byte)
+		if !ok {
+			return nil, false
+		}
+		args = append(args, arg)
+	}
+	return args, true
+}
@@ -3,13 +3,43 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	DatabaseURL string
-	ApiUrl      string
+	Storage        string
+	DatabaseURL    string
+	MigrateOnStart bool
+	ApiUrl         string
+	ApiTimeout     time.Duration
+	ApiRetries     int
+
+	AuthEnabled       bool
+	JWTAlgorithm      string
+	JWTSecret         string
+	JWTPrivateKeyFile string
+	JWTPublicKeyFile  string
+	JWTIssuer         string
+	JWTTTL            time.Duration
+	DefaultRole       string
+
+	TrashRetention     time.Duration
+	TrashPurgeInterval time.Duration
+
+	RequireIfMatch bool
+
+	CacheControlSongs  string
+	CacheControlLyrics string
+
+	Cache         string
+	CacheTTL      time.Duration
+	CacheSize     int
+	RedisAddr     string
+	RedisPassword string
+	RedisTimeout  time.Duration
 }
 
 func LoadConfig() *Config {
@@ -19,7 +49,81 @@ func LoadConfig() *Config {
 	}
 
 	return &Config{
-		DatabaseURL: os.Getenv("DATABASE_URL"),
-		ApiUrl:      os.Getenv("API_URL"),
+		Storage:        getEnv("STORAGE", "postgres"),
+		DatabaseURL:    os.Getenv("DATABASE_URL"),
+		MigrateOnStart: getEnvBool("MIGRATE_ON_START", true),
+		ApiUrl:         os.Getenv("API_URL"),
+		ApiTimeout:     getEnvDuration("API_TIMEOUT", 5*time.Second),
+		ApiRetries:     getEnvInt("API_RETRIES", 2),
+
+		AuthEnabled:       getEnvBool("AUTH_ENABLED", true),
+		JWTAlgorithm:      getEnv("JWT_ALGORITHM", "HS256"),
+		JWTSecret:         os.Getenv("JWT_SECRET"),
+		JWTPrivateKeyFile: os.Getenv("JWT_PRIVATE_KEY_FILE"),
+		JWTPublicKeyFile:  os.Getenv("JWT_PUBLIC_KEY_FILE"),
+		JWTIssuer:         getEnv("JWT_ISSUER", "go-api"),
+		JWTTTL:            getEnvDuration("JWT_TTL", time.Hour),
+		DefaultRole:       getEnv("DEFAULT_ROLE", "reader"),
+
+		TrashRetention:     getEnvDuration("TRASH_RETENTION", 30*24*time.Hour),
+		TrashPurgeInterval: getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour),
+
+		RequireIfMatch: getEnvBool("REQUIRE_IF_MATCH", true),
+
+		CacheControlSongs:  getEnv("CACHE_CONTROL_SONGS", "public, no-cache"),
+		CacheControlLyrics: getEnv("CACHE_CONTROL_LYRICS", "public, max-age=300"),
+
+		Cache:         getEnv("CACHE", "none"),
+		CacheTTL:      getEnvDuration("CACHE_TTL", 10*time.Minute),
+		CacheSize:     getEnvInt("CACHE_SIZE", 1000),
+		RedisAddr:     getEnv("REDIS_ADDR", "localhost:6379"),
+		RedisPassword: os.Getenv("REDIS_PASSWORD"),
+		RedisTimeout:  getEnvDuration("REDIS_TIMEOUT", 500*time.Millisecond),
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", key, err)
+	}
+	return b
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", key, err)
+	}
+	return d
+}
+
+func getEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", key, err)
 	}
+	return n
 }
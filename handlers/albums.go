@@ -0,0 +1,369 @@
+package handlers
+
+import (
+	"case/models"
+	"case/repositories"
+	"case/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type AlbumHandler struct {
+	service *services.AlbumService
+	log     *logrus.Logger
+}
+
+func NewAlbumHandler(service *services.AlbumService, log *logrus.Logger) *AlbumHandler {
+	return &AlbumHandler{service: service, log: log}
+}
+
+// GetAlbums
+// @Summary Get a list of albums
+// @Description Get a list of albums ordered by release date without their tracks
+// @Tags albums
+// @Accept json
+// @Produce json
+// @Param artist_id query int false "Filter by artist"
+// @Param date_format query string false "Release date format in the response" Enums(dmy, iso) default(dmy)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Success 200 {object} models.AlbumListResponse "List of albums"
+// @Failure 400 {object} models.ErrorResponse "Invalid filter or pagination"
+// @Failure 500 {object} models.ErrorResponse "Failed to get albums"
+// @Router /albums [get]
+func (h *AlbumHandler) GetAlbums(c *gin.Context) {
+	var artistID *int
+	if value := c.Query("artist_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid artist_id"})
+			return
+		}
+		artistID = &id
+	}
+
+	layout, err := dateLayout(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid page number"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid limit number"})
+		return
+	}
+
+	albums, total, err := h.service.GetAlbums(artistID, page, limit)
+	if err != nil {
+		h.log.Errorf("Failed to get albums: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get albums"})
+		return
+	}
+
+	response := models.AlbumListResponse{
+		Albums:     []models.AlbumResponse{},
+		Pagination: paginate(c, total, page, limit),
+	}
+	for _, album := range albums {
+		response.Albums = append(response.Albums, models.ToAlbumResponse(album, nil, layout))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetAlbum
+// @Summary Get an album
+// @Description Get an album by ID with its tracks ordered by disc and track number
+// @Tags albums
+// @Accept json
+// @Produce json
+// @Param id path int true "Album ID"
+// @Param date_format query string false "Release date format in the response" Enums(dmy, iso) default(dmy)
+// @Success 200 {object} models.AlbumResponse "Album with tracks"
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 404 {object} models.ErrorResponse "Album not found"
+// @Failure 500 {object} models.ErrorResponse "Failed to get album"
+// @Router /albums/{id} [get]
+func (h *AlbumHandler) GetAlbum(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	layout, err := dateLayout(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	album, tracks, err := h.service.GetAlbum(id)
+	if err != nil {
+		h.respondError(c, err, "Failed to get album")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ToAlbumResponse(album, tracks, layout))
+}
+
+// AddAlbum
+// @Summary Add a new album
+// @Description Add a new album by an existing artist. Tracks are attached separately
+// @Tags albums
+// @Accept json
+// @Produce json
+// @Param album body models.Album true "Album data"
+// @Success 200 {object} models.AlbumResponse "Album added"
+// @Failure 400 {object} models.ErrorResponse "Invalid request body or unknown artist"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to add album"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /albums [post]
+func (h *AlbumHandler) AddAlbum(c *gin.Context) {
+	var album models.Album
+	if err := c.ShouldBindJSON(&album); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid album: " + err.Error()})
+		return
+	}
+
+	if err := h.service.AddAlbum(&album); err != nil {
+		h.respondError(c, err, "Failed to add album")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ToAlbumResponse(album, nil, models.DateLayout))
+}
+
+// UpdateAlbum
+// @Summary Update an album
+// @Description Update the title, artist, release date and cover of an album. The track list is left unchanged
+// @Tags albums
+// @Accept json
+// @Produce json
+// @Param id path int true "Album ID"
+// @Param album body models.Album true "Updated album data"
+// @Success 200 {object} models.AlbumResponse "Album updated"
+// @Failure 400 {object} models.ErrorResponse "Invalid request body, ID or unknown artist"
+// @Failure 404 {object} models.ErrorResponse "Album not found"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to update album"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /albums/{id} [put]
+func (h *AlbumHandler) UpdateAlbum(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	var album models.Album
+	if err := c.ShouldBindJSON(&album); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid album: " + err.Error()})
+		return
+	}
+	album.ID = id
+
+	if err := h.service.UpdateAlbum(&album); err != nil {
+		h.respondError(c, err, "Failed to update album")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ToAlbumResponse(album, nil, models.DateLayout))
+}
+
+// DeleteAlbum
+// @Summary Delete an album
+// @Description Delete an album by ID. Its songs stay in the catalog
+// @Tags albums
+// @Accept json
+// @Produce json
+// @Param id path int true "Album ID"
+// @Success 200 {object} models.MessageResponse "Album deleted"
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 404 {object} models.ErrorResponse "Album not found"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to delete album"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /albums/{id} [delete]
+func (h *AlbumHandler) DeleteAlbum(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	if err := h.service.DeleteAlbum(id); err != nil {
+		h.respondError(c, err, "Failed to delete album")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MessageResponse{Message: "album deleted"})
+}
+
+// AddTrack
+// @Summary Attach a song to an album
+// @Description Insert a song at the given track number of a disc, shifting the following tracks down. Without track_number the song is appended to the disc; disc_number defaults to 1
+// @Tags albums
+// @Accept json
+// @Produce json
+// @Param id path int true "Album ID"
+// @Param track body models.AddTrackRequest true "Song and position"
+// @Success 200 {object} models.AlbumResponse "Album with tracks"
+// @Failure 400 {object} models.ErrorResponse "Invalid request body or unknown song"
+// @Failure 404 {object} models.ErrorResponse "Album not found"
+// @Failure 409 {object} models.ErrorResponse "Song is already on the album"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to add track"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /albums/{id}/tracks [post]
+func (h *AlbumHandler) AddTrack(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	var req models.AddTrackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid track"})
+		return
+	}
+
+	if err := h.service.AddTrack(id, req); err != nil {
+		h.respondError(c, err, "Failed to add track")
+		return
+	}
+
+	h.respondAlbum(c, id)
+}
+
+// RemoveTrack
+// @Summary Detach a song from an album
+// @Description Remove a song from an album and renumber the following tracks of its disc
+// @Tags albums
+// @Accept json
+// @Produce json
+// @Param id path int true "Album ID"
+// @Param song_id path int true "Song ID"
+// @Success 200 {object} models.AlbumResponse "Album with tracks"
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 404 {object} models.ErrorResponse "Album not found or song is not on the album"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to remove track"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /albums/{id}/tracks/{song_id} [delete]
+func (h *AlbumHandler) RemoveTrack(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	songID, err := strconv.Atoi(c.Param("song_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid song_id"})
+		return
+	}
+
+	if err := h.service.RemoveTrack(id, songID); err != nil {
+		h.respondError(c, err, "Failed to remove track")
+		return
+	}
+
+	h.respondAlbum(c, id)
+}
+
+// ReorderTracks
+// @Summary Reorder the tracks of an album
+// @Description Replace the track order of an album. Every song on the album must be listed exactly once; track numbers are assigned per disc in the given order
+// @Tags albums
+// @Accept json
+// @Produce json
+// @Param id path int true "Album ID"
+// @Param tracks body models.ReorderTracksRequest true "New track order"
+// @Success 200 {object} models.AlbumResponse "Album with tracks"
+// @Failure 400 {object} models.ErrorResponse "Invalid request body or track list does not match the album"
+// @Failure 404 {object} models.ErrorResponse "Album not found"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to reorder tracks"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /albums/{id}/tracks [put]
+func (h *AlbumHandler) ReorderTracks(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	var req models.ReorderTracksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid track order"})
+		return
+	}
+
+	if err := h.service.ReorderTracks(id, req.Tracks); err != nil {
+		h.respondError(c, err, "Failed to reorder tracks")
+		return
+	}
+
+	h.respondAlbum(c, id)
+}
+
+// respondAlbum replies with the album and its current track list after a
+// track change.
+func (h *AlbumHandler) respondAlbum(c *gin.Context, id int) {
+	album, tracks, err := h.service.GetAlbum(id)
+	if err != nil {
+		h.respondError(c, err, "Failed to get album")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ToAlbumResponse(album, tracks, models.DateLayout))
+}
+
+func (h *AlbumHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidAlbum):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid album: " + err.Error()})
+	case errors.Is(err, repositories.ErrArtistNotFound):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Unknown artist_id"})
+	case errors.Is(err, repositories.ErrSongNotFound):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Unknown song_id"})
+	case errors.Is(err, repositories.ErrInvalidTrackNumber), errors.Is(err, repositories.ErrTrackListMismatch):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, repositories.ErrAlbumNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Album not found"})
+	case errors.Is(err, repositories.ErrTrackNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Song is not on the album"})
+	case errors.Is(err, repositories.ErrTrackExists):
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Song is already on the album"})
+	default:
+		h.log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error(message)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: message})
+	}
+}
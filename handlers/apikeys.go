@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"case/models"
+	"case/repositories"
+	"case/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type APIKeyHandler struct {
+	service *services.APIKeyService
+	log     *logrus.Logger
+}
+
+func NewAPIKeyHandler(service *services.APIKeyService, log *logrus.Logger) *APIKeyHandler {
+	return &APIKeyHandler{service: service, log: log}
+}
+
+// GetAPIKeys
+// @Summary Get a list of API keys
+// @Description Get all API keys, including revoked and expired ones. Secrets are never returned
+// @Tags apikeys
+// @Produce json
+// @Success 200 {object} models.APIKeyListResponse "List of API keys"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to get API keys"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /apikeys [get]
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	keys, err := h.service.GetAPIKeys()
+	if err != nil {
+		h.respondError(c, err, "Failed to get API keys")
+		return
+	}
+
+	response := models.APIKeyListResponse{APIKeys: []models.APIKeyResponse{}}
+	for _, key := range keys {
+		response.APIKeys = append(response.APIKeys, models.ToAPIKeyResponse(key))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// AddAPIKey
+// @Summary Create an API key
+// @Description Create an API key limited to the given permission scopes. The key is only returned in this response; clients send it in the X-API-Key header
+// @Tags apikeys
+// @Accept json
+// @Produce json
+// @Param apikey body models.CreateAPIKeyRequest true "Name, scopes and optional expiry"
+// @Success 200 {object} models.APIKeySecretResponse "API key created"
+// @Failure 400 {object} models.ErrorResponse "Invalid request body"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to create API key"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /apikeys [post]
+func (h *APIKeyHandler) AddAPIKey(c *gin.Context) {
+	var request models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid API key"})
+		return
+	}
+
+	key, secret, err := h.service.CreateAPIKey(request)
+	if err != nil {
+		h.respondError(c, err, "Failed to create API key")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIKeySecretResponse{APIKeyResponse: models.ToAPIKeyResponse(key), Key: secret})
+}
+
+// RotateAPIKey
+// @Summary Rotate an API key
+// @Description Replace the secret of an API key. The old key stops working immediately; the new one is only returned in this response
+// @Tags apikeys
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} models.APIKeySecretResponse "API key rotated"
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 404 {object} models.ErrorResponse "API key not found"
+// @Failure 409 {object} models.ErrorResponse "API key is revoked"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to rotate API key"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /apikeys/{id}/rotate [post]
+func (h *APIKeyHandler) RotateAPIKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	key, secret, err := h.service.RotateAPIKey(id)
+	if err != nil {
+		h.respondError(c, err, "Failed to rotate API key")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIKeySecretResponse{APIKeyResponse: models.ToAPIKeyResponse(key), Key: secret})
+}
+
+// RevokeAPIKey
+// @Summary Revoke an API key
+// @Description Revoke an API key by ID. Revoked keys stay listed but can no longer authenticate
+// @Tags apikeys
+// @Produce json
+// @Param id path int true "API key ID"
+// @Success 200 {object} models.APIKeyResponse "API key revoked"
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 404 {object} models.ErrorResponse "API key not found"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to revoke API key"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /apikeys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	key, err := h.service.RevokeAPIKey(id)
+	if err != nil {
+		h.respondError(c, err, "Failed to revoke API key")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ToAPIKeyResponse(key))
+}
+
+func (h *APIKeyHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidAPIKey):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, repositories.ErrAPIKeyNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "API key not found"})
+	case errors.Is(err, services.ErrAPIKeyRevoked):
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "API key is revoked"})
+	default:
+		h.log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error(message)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: message})
+	}
+}
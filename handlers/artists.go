@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"case/models"
+	"case/repositories"
+	"case/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type ArtistHandler struct {
+	service *services.ArtistService
+	log     *logrus.Logger
+}
+
+func NewArtistHandler(service *services.ArtistService, log *logrus.Logger) *ArtistHandler {
+	return &ArtistHandler{service: service, log: log}
+}
+
+// GetArtists
+// @Summary Get a list of artists
+// @Description Get a list of artists ordered by name with optional filtering by name or alias
+// @Tags artists
+// @Accept json
+// @Produce json
+// @Param name query string false "Filter by case-insensitive substring of name or alias"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Success 200 {object} models.ArtistListResponse "List of artists"
+// @Failure 400 {object} models.ErrorResponse "Invalid pagination"
+// @Failure 500 {object} models.ErrorResponse "Failed to get artists"
+// @Router /artists [get]
+func (h *ArtistHandler) GetArtists(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid page number"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid limit number"})
+		return
+	}
+
+	artists, total, err := h.service.GetArtists(c.Query("name"), page, limit)
+	if err != nil {
+		h.log.Errorf("Failed to get artists: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get artists"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ArtistListResponse{
+		Artists:    models.ToArtistResponseList(artists),
+		Pagination: paginate(c, total, page, limit),
+	})
+}
+
+// GetArtist
+// @Summary Get an artist
+// @Description Get an artist by ID
+// @Tags artists
+// @Accept json
+// @Produce json
+// @Param id path int true "Artist ID"
+// @Success 200 {object} models.ArtistResponse "Artist"
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 404 {object} models.ErrorResponse "Artist not found"
+// @Failure 500 {object} models.ErrorResponse "Failed to get artist"
+// @Router /artists/{id} [get]
+func (h *ArtistHandler) GetArtist(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	artist, err := h.service.GetArtist(id)
+	if errors.Is(err, repositories.ErrArtistNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Artist not found"})
+		return
+	}
+	if err != nil {
+		h.log.Errorf("Failed to get artist: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get artist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ToArtistResponse(artist))
+}
+
+// AddArtist
+// @Summary Add a new artist
+// @Description Add a new artist. Names are unique regardless of case, spacing and a leading "The"
+// @Tags artists
+// @Accept json
+// @Produce json
+// @Param artist body models.Artist true "Artist data"
+// @Success 200 {object} models.ArtistResponse "Artist added"
+// @Failure 400 {object} models.ErrorResponse "Invalid request body"
+// @Failure 409 {object} models.ErrorResponse "Artist already exists"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to add artist"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /artists [post]
+func (h *ArtistHandler) AddArtist(c *gin.Context) {
+	var artist models.Artist
+	if err := c.ShouldBindJSON(&artist); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid artist"})
+		return
+	}
+
+	if err := h.service.AddArtist(&artist); err != nil {
+		h.respondError(c, err, "Failed to add artist")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ToArtistResponse(artist))
+}
+
+// UpdateArtist
+// @Summary Update an artist
+// @Description Update an existing artist by ID. Renaming an artist renames the group of all of its songs
+// @Tags artists
+// @Accept json
+// @Produce json
+// @Param id path int true "Artist ID"
+// @Param artist body models.Artist true "Updated artist data"
+// @Success 200 {object} models.ArtistResponse "Artist updated"
+// @Failure 400 {object} models.ErrorResponse "Invalid request body or ID"
+// @Failure 404 {object} models.ErrorResponse "Artist not found"
+// @Failure 409 {object} models.ErrorResponse "Artist already exists"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to update artist"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /artists/{id} [put]
+func (h *ArtistHandler) UpdateArtist(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	var artist models.Artist
+	if err := c.ShouldBindJSON(&artist); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid artist"})
+		return
+	}
+	artist.ID = id
+
+	if err := h.service.UpdateArtist(&artist); err != nil {
+		h.respondError(c, err, "Failed to update artist")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ToArtistResponse(artist))
+}
+
+// DeleteArtist
+// @Summary Delete an artist
+// @Description Delete an artist by ID. Artists that still have songs or albums cannot be deleted
+// @Tags artists
+// @Accept json
+// @Produce json
+// @Param id path int true "Artist ID"
+// @Success 200 {object} models.MessageResponse "Artist deleted"
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 404 {object} models.ErrorResponse "Artist not found"
+// @Failure 409 {object} models.ErrorResponse "Artist still has songs or albums"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to delete artist"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /artists/{id} [delete]
+func (h *ArtistHandler) DeleteArtist(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	if err := h.service.DeleteArtist(id); err != nil {
+		h.respondError(c, err, "Failed to delete artist")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MessageResponse{Message: "artist deleted"})
+}
+
+func (h *ArtistHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidArtist):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid artist: " + err.Error()})
+	case errors.Is(err, repositories.ErrArtistNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Artist not found"})
+	case errors.Is(err, repositories.ErrArtistExists):
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Artist already exists"})
+	case errors.Is(err, repositories.ErrArtistHasSongs):
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Artist still has songs or albums"})
+	default:
+		h.log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error(message)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: message})
+	}
+}
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"case/models"
+	"case/services"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// claimsKey is the gin context key RequireAuth stores the token claims under.
+const claimsKey = "auth.claims"
+
+// apiKeyHeader carries API keys of service clients instead of a bearer token.
+const apiKeyHeader = "X-API-Key"
+
+// RequireAuth rejects requests without a valid "Authorization: Bearer" JWT
+// or X-API-Key header with 401. With a nil TokenService authentication is
+// disabled and every request passes.
+func RequireAuth(tokens *services.TokenService, keys *services.APIKeyService, log *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tokens == nil {
+			c.Next()
+			return
+		}
+
+		if presented := strings.TrimSpace(c.GetHeader(apiKeyHeader)); presented != "" && keys != nil {
+			authenticateAPIKey(c, keys, presented, log)
+			return
+		}
+
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || strings.TrimSpace(token) == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="api"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:  "Missing bearer token",
+				Reason: models.ReasonMissingToken,
+			})
+			return
+		}
+
+		claims, err := tokens.Verify(strings.TrimSpace(token))
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err,
+				"path":  c.FullPath(),
+			}).Warn("Rejected bearer token")
+
+			response := models.ErrorResponse{Error: "Invalid token", Reason: models.ReasonInvalidToken}
+			if errors.Is(err, services.ErrTokenExpired) {
+				response = models.ErrorResponse{Error: "Token expired", Reason: models.ReasonTokenExpired}
+			}
+			c.Header("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, response)
+			return
+		}
+
+		c.Set(claimsKey, claims)
+		c.Next()
+	}
+}
+
+func authenticateAPIKey(c *gin.Context, keys *services.APIKeyService, presented string, log *logrus.Logger) {
+	claims, err := keys.Authenticate(presented)
+	switch {
+	case errors.Is(err, services.ErrUnknownAPIKey), errors.Is(err, services.ErrAPIKeyExpired):
+		log.WithFields(logrus.Fields{
+			"error": err,
+			"path":  c.FullPath(),
+		}).Warn("Rejected API key")
+
+		response := models.ErrorResponse{Error: "Invalid API key", Reason: models.ReasonInvalidAPIKey}
+		if errors.Is(err, services.ErrAPIKeyExpired) {
+			response = models.ErrorResponse{Error: "API key expired", Reason: models.ReasonAPIKeyExpired}
+		}
+		c.AbortWithStatusJSON(http.StatusUnauthorized, response)
+		return
+	case err != nil:
+		log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("Failed to check API key")
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to check API key"})
+		return
+	}
+
+	if err := keys.MarkUsed(claims.APIKeyID); err != nil {
+		log.WithFields(logrus.Fields{
+			"error":      err,
+			"api_key_id": claims.APIKeyID,
+		}).Warn("Failed to record API key use")
+	}
+
+	c.Set(claimsKey, claims)
+	c.Next()
+}
+
+// CurrentClaims returns the claims of the token that authenticated the
+// request, if any.
+func CurrentClaims(c *gin.Context) (*services.Claims, bool) {
+	value, ok := c.Get(claimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := value.(*services.Claims)
+	return claims, ok
+}
+
+// OptionalAuth authenticates requests that carry a bearer token or API key
+// like RequireAuth, and lets anonymous requests through without claims.
+func OptionalAuth(tokens *services.TokenService, keys *services.APIKeyService, log *logrus.Logger) gin.HandlerFunc {
+	auth := RequireAuth(tokens, keys, log)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" && c.GetHeader(apiKeyHeader) == "" {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
+// currentSubject returns the subject of the request, or "" when it is
+// anonymous or authentication is disabled.
+func currentSubject(c *gin.Context) string {
+	if claims, ok := CurrentClaims(c); ok {
+		return claims.Subject
+	}
+	return ""
+}
+
+// RequirePermission rejects authenticated requests whose subject lacks
+// permission with 403. It must run after RequireAuth; with a nil
+// AuthzService, as when authentication is disabled, every request passes.
+func RequirePermission(authz *services.AuthzService, permission string, log *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if authz == nil {
+			c.Next()
+			return
+		}
+
+		claims, ok := CurrentClaims(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:  "Missing bearer token",
+				Reason: models.ReasonMissingToken,
+			})
+			return
+		}
+
+		allowed, err := authz.Authorize(claims, permission)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"error":   err,
+				"subject": claims.Subject,
+			}).Error("Failed to check permission")
+			c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to check permission"})
+			return
+		}
+		if !allowed {
+			log.WithFields(logrus.Fields{
+				"subject":    claims.Subject,
+				"permission": permission,
+				"path":       c.FullPath(),
+			}).Warn("Permission denied")
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+				Error:  "Permission " + permission + " required",
+				Reason: models.ReasonMissingPermission,
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// WhoAmI
+// @Summary Get the current subject
+// @Description Get the subject of the bearer token or API key with its roles and permissions. API keys have no roles and their scopes as permissions
+// @Tags auth
+// @Produce json
+// @Success 200 {object} models.SubjectRolesResponse "Subject, roles and permissions"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 500 {object} models.ErrorResponse "Failed to get roles"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /auth/me [get]
+func WhoAmI(authz *services.AuthzService, log *logrus.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := CurrentClaims(c)
+		if !ok || authz == nil {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:  "Authentication is disabled",
+				Reason: models.ReasonMissingToken,
+			})
+			return
+		}
+
+		if claims.APIKeyID != 0 {
+			c.JSON(http.StatusOK, models.SubjectRolesResponse{
+				Subject:     claims.Subject,
+				Roles:       []string{},
+				Permissions: claims.Scopes,
+			})
+			return
+		}
+
+		roles, err := authz.Roles(claims.Subject)
+		if err != nil {
+			log.Errorf("Failed to get roles: %v", err)
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get roles"})
+			return
+		}
+		permissions, err := authz.Permissions(claims.Subject)
+		if err != nil {
+			log.Errorf("Failed to get roles: %v", err)
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get roles"})
+			return
+		}
+
+		c.JSON(http.StatusOK, models.SubjectRolesResponse{
+			Subject:     claims.Subject,
+			Roles:       roles,
+			Permissions: permissions,
+		})
+	}
+}
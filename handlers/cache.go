@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bytes"
+	"case/models"
+	"case/repositories"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Conditional serves GET requests conditionally. It buffers the response,
+// tags a 200 with an ETag hashed from its body and the given Cache-Control,
+// and replaces it with 304 Not Modified when If-None-Match lists that ETag
+// or, without If-None-Match, when If-Modified-Since is not older than the
+// Last-Modified set by the handler. An empty cacheControl sends none.
+func Conditional(cacheControl string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		buffered := &bufferedWriter{ResponseWriter: original}
+		c.Writer = buffered
+		c.Next()
+		c.Writer = original
+
+		if original.Status() != http.StatusOK {
+			original.Write(buffered.body.Bytes())
+			return
+		}
+
+		header := original.Header()
+		sum := sha256.Sum256(buffered.body.Bytes())
+		etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+		header.Set("ETag", etag)
+		if cacheControl != "" {
+			header.Set("Cache-Control", cacheControl)
+		}
+
+		if notModified(c.Request, etag, header.Get("Last-Modified")) {
+			// A 304 carries the validators but no body or content headers.
+			header.Del("Content-Type")
+			header.Del("Content-Length")
+			original.WriteHeader(http.StatusNotModified)
+			original.WriteHeaderNow()
+			return
+		}
+		original.Write(buffered.body.Bytes())
+	}
+}
+
+// notModified evaluates If-None-Match and If-Modified-Since as in RFC 9110:
+// If-Modified-Since only counts when If-None-Match is absent.
+func notModified(r *http.Request, etag, lastModified string) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		return etagMatchesWeak(header, etag)
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+	return !modified.After(since)
+}
+
+// etagMatchesWeak reports whether an If-None-Match header lists etag, using
+// the weak comparison that ignores the W/ prefix.
+func etagMatchesWeak(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// setLastModified sets Last-Modified unless t is zero, as for an empty
+// catalog.
+func setLastModified(c *gin.Context, t time.Time) {
+	if !t.IsZero() {
+		c.Header("Last-Modified", t.UTC().Format(http.TimeFormat))
+	}
+}
+
+// bufferedWriter holds back the body so that Conditional can hash it before
+// anything is sent. The status is still recorded by the wrapped writer.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// CacheStats
+// @Summary Get song cache statistics
+// @Description Get the hits and misses of the lyrics cache since startup. The backend is "none" when CACHE is off. Requires the cache:read permission, held by the admin role
+// @Tags cache
+// @Produce json
+// @Success 200 {object} models.CacheStats "Cache statistics"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /cache/stats [get]
+func CacheStats(store *repositories.CachedSongStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if store == nil {
+			c.JSON(http.StatusOK, models.CacheStats{Backend: "none"})
+			return
+		}
+		c.JSON(http.StatusOK, store.Stats())
+	}
+}
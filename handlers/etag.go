@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"case/models"
+	"case/repositories"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// songETag is the strong entity tag of the current version of a song.
+func songETag(song models.Song) string {
+	return `"v` + strconv.Itoa(song.Version) + `"`
+}
+
+// etagMatches reports whether an If-Match header lists etag. Weak tags never
+// match, as If-Match uses the strong comparison.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchVersion checks the If-Match header of a write to song id and
+// returns the version the write has to apply to, 0 for an unconditional
+// write. It replies and returns false if the write must not proceed.
+func (h *SongHandler) ifMatchVersion(c *gin.Context, id int) (int, bool) {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		if h.requireIfMatch {
+			c.JSON(http.StatusPreconditionRequired, models.ErrorResponse{Error: "If-Match header with the song's ETag is required"})
+			return 0, false
+		}
+		return 0, true
+	}
+
+	current, err := h.service.GetSong(id)
+	if errors.Is(err, repositories.ErrSongNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Song not found"})
+		return 0, false
+	}
+	if err != nil {
+		h.log.Errorf("Failed to get song: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get song"})
+		return 0, false
+	}
+
+	if !etagMatches(header, songETag(current)) {
+		h.respondStale(c, current)
+		return 0, false
+	}
+	return current.Version, true
+}
+
+// respondStale replies 412 with the current song and its ETag, so that the
+// client can merge its change and retry.
+func (h *SongHandler) respondStale(c *gin.Context, current models.Song) {
+	layout, err := dateLayout(c)
+	if err != nil {
+		layout = models.DateLayout
+	}
+	c.Header("ETag", songETag(current))
+	c.JSON(http.StatusPreconditionFailed, models.ToSongResponse(current, layout))
+}
+
+// respondConflict handles ErrVersionConflict from a write that lost a race
+// after its If-Match check passed.
+func (h *SongHandler) respondConflict(c *gin.Context, id int) {
+	current, err := h.service.GetSong(id)
+	if err != nil {
+		c.JSON(http.StatusPreconditionFailed, models.ErrorResponse{Error: "Song was changed concurrently"})
+		return
+	}
+	h.respondStale(c, current)
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"bufio"
+	"case/models"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// exportFlushEvery is the number of songs after which the export is flushed
+// to the client.
+const exportFlushEvery = 1000
+
+// exportContentTypes maps the export formats to their content types.
+var exportContentTypes = map[string]string{
+	"csv":    "text/csv; charset=utf-8",
+	"ndjson": "application/x-ndjson",
+	"json":   "application/json; charset=utf-8",
+}
+
+// ExportSongs
+// @Summary Export songs
+// @Description Stream every song matching the filters of GET /songs, in its sort order, as a CSV file with a header row, newline-delimited JSON or a JSON array. The export is read from a database cursor and sent as it is read; CSV exports can be imported again with POST /songs/import. The response is gzip-compressed when Accept-Encoding allows it. An export that fails midway is cut off without its end
+// @Tags songs
+// @Produce json,text/csv,application/x-ndjson
+// @Param format query string false "Export format" Enums(json, ndjson, csv) default(json)
+// @Param artist_id query int false "Filter by artist"
+// @Param group query string false "Filter by exact group"
+// @Param group_contains query string false "Filter by case-insensitive substring of group"
+// @Param group_prefix query string false "Filter by case-insensitive prefix of group"
+// @Param song query string false "Filter by exact song name"
+// @Param song_contains query string false "Filter by case-insensitive substring of song name"
+// @Param song_prefix query string false "Filter by case-insensitive prefix of song name"
+// @Param link query string false "Filter by exact link"
+// @Param link_contains query string false "Filter by case-insensitive substring of link"
+// @Param link_prefix query string false "Filter by case-insensitive prefix of link"
+// @Param text query string false "Filter by exact text"
+// @Param text_contains query string false "Filter by case-insensitive substring of text"
+// @Param text_prefix query string false "Filter by case-insensitive prefix of text"
+// @Param released_after query string false "Released on or after date (DD.MM.YYYY or YYYY-MM-DD)"
+// @Param released_before query string false "Released on or before date (DD.MM.YYYY or YYYY-MM-DD)"
+// @Param tag query []string false "Filter by tag name; repeat for several tags" collectionFormat(multi)
+// @Param tag_mode query string false "Whether songs must carry all or any of the tags" Enums(all, any) default(all)
+// @Param sort query string false "Sort field" Enums(id, group, song, release_date) default(id)
+// @Param order query string false "Sort order" Enums(asc, desc) default(asc)
+// @Param date_format query string false "Release date format" Enums(dmy, iso) default(dmy)
+// @Param Accept-Encoding header string false "gzip to compress the export"
+// @Success 200 {array} models.SongResponse "Songs"
+// @Header 200 {string} Content-Disposition "attachment with a file name such as songs-20240131.csv"
+// @Header 200 {string} Content-Encoding "gzip when accepted"
+// @Failure 400 {object} models.ErrorResponse "Invalid format, filter or sort"
+// @Failure 500 {object} models.ErrorResponse "Failed to export songs"
+// @Router /songs/export [get]
+func (h *SongHandler) ExportSongs(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: fmt.Sprintf("format must be json, ndjson or csv. Got %q", format)})
+		return
+	}
+
+	filter, err := parseSongFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	sort, err := parseSongSort(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	layout, err := dateLayout(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="songs-%s.%s"`, time.Now().UTC().Format("20060102"), format))
+	header.Set("Vary", "Accept-Encoding")
+
+	// Nothing reaches the client before the first flush, so that an export
+	// failing at once can still be answered with an error.
+	var out io.Writer = c.Writer
+	var gz *gzip.Writer
+	if acceptsGzip(c.GetHeader("Accept-Encoding")) {
+		header.Set("Content-Encoding", "gzip")
+		gz = gzip.NewWriter(c.Writer)
+		out = gz
+	}
+	buf := bufio.NewWriterSize(out, 32<<10)
+	exporter := newSongExporter(format, buf)
+
+	count := 0
+	err = h.service.ExportSongs(filter, sort, func(song models.Song) error {
+		if err := exporter.write(models.ToSongResponse(song, layout)); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushEvery == 0 {
+			return flushExport(c, exporter, buf, gz)
+		}
+		return nil
+	})
+	if err == nil {
+		if err = exporter.close(); err == nil {
+			err = flushExport(c, exporter, buf, gz)
+		}
+		if err == nil && gz != nil {
+			err = gz.Close()
+		}
+	}
+
+	if err != nil {
+		h.log.WithFields(logrus.Fields{
+			"error": err,
+			"songs": count,
+		}).Error("Failed to export songs")
+
+		if !c.Writer.Written() {
+			header.Del("Content-Disposition")
+			header.Del("Content-Encoding")
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to export songs"})
+			return
+		}
+		// The status is gone; dropping the connection at least keeps the
+		// truncated export from looking complete.
+		if conn, _, err := c.Writer.Hijack(); err == nil {
+			conn.Close()
+		}
+		return
+	}
+
+	h.log.WithFields(logrus.Fields{
+		"format": format,
+		"songs":  count,
+	}).Info("Songs exported")
+}
+
+func flushExport(c *gin.Context, exporter songExporter, buf *bufio.Writer, gz *gzip.Writer) error {
+	if err := exporter.flush(); err != nil {
+		return err
+	}
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	if gz != nil {
+		if err := gz.Flush(); err != nil {
+			return err
+		}
+	}
+	c.Writer.Flush()
+	return nil
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "gzip" && name != "x-gzip" && name != "*" {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// songExporter writes songs in one export format.
+type songExporter interface {
+	write(song models.SongResponse) error
+	// flush passes on what the exporter buffers itself.
+	flush() error
+	// close ends the export once every song is written.
+	close() error
+}
+
+func newSongExporter(format string, w io.Writer) songExporter {
+	switch format {
+	case "csv":
+		return newCSVExporter(w)
+	case "ndjson":
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		return &ndjsonExporter{encoder: encoder}
+	default:
+		return &jsonExporter{w: w}
+	}
+}
+
+// csvColumns are the CSV columns in the order of models.SongResponse.
+var csvColumns = []string{"id", "artist_id", "group", "song", "release_date", "text", "link"}
+
+type csvExporter struct {
+	w *csv.Writer
+	// err is the error writing the header, returned by the first write.
+	err error
+}
+
+func newCSVExporter(w io.Writer) *csvExporter {
+	writer := csv.NewWriter(w)
+	return &csvExporter{w: writer, err: writer.Write(csvColumns)}
+}
+
+func (e *csvExporter) write(song models.SongResponse) error {
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Write([]string{
+		strconv.Itoa(song.ID), strconv.Itoa(song.ArtistID), song.Group, song.Song,
+		song.ReleaseDate, song.Text, song.Link,
+	})
+}
+
+func (e *csvExporter) flush() error {
+	if e.err != nil {
+		return e.err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExporter) close() error {
+	return e.flush()
+}
+
+type ndjsonExporter struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonExporter) write(song models.SongResponse) error {
+	return e.encoder.Encode(song)
+}
+
+func (e *ndjsonExporter) flush() error {
+	return nil
+}
+
+func (e *ndjsonExporter) close() error {
+	return nil
+}
+
+// jsonExporter writes a JSON array one element at a time.
+type jsonExporter struct {
+	w       io.Writer
+	started bool
+}
+
+func (e *jsonExporter) write(song models.SongResponse) error {
+	data, err := json.Marshal(song)
+	if err != nil {
+		return err
+	}
+	separator := ",\n"
+	if !e.started {
+		separator, e.started = "[\n", true
+	}
+	if _, err := io.WriteString(e.w, separator); err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonExporter) flush() error {
+	return nil
+}
+
+func (e *jsonExporter) close() error {
+	end := "\n]\n"
+	if !e.started {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
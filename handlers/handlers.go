@@ -2,11 +2,13 @@ package handlers
 
 import (
 	"case/models"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
-	"time"
+	"strings"
 
+	"case/repositories"
 	"case/services"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -14,38 +16,73 @@ import (
 
 type SongHandler struct {
 	service *services.SongService
-	log     *logrus.Logger
+	// requireIfMatch makes updates and deletes without If-Match fail with 428.
+	requireIfMatch bool
+	log            *logrus.Logger
 }
 
-func NewSongHandler(service *services.SongService, log *logrus.Logger) *SongHandler {
-	return &SongHandler{service: service, log: log}
+func NewSongHandler(service *services.SongService, requireIfMatch bool, log *logrus.Logger) *SongHandler {
+	return &SongHandler{service: service, requireIfMatch: requireIfMatch, log: log}
 }
 
 // GetSongs
 // @Summary Get a list of songs
-// @Description Get a list of songs with optional filtering and pagination
+// @Description Get a list of songs with optional filtering and pagination. All filters are combined with AND; tags are matched according to tag_mode. Pages are addressed either by page number or, for large catalogs, by an opaque cursor that keeps the ordering stable while songs are added or removed
 // @Tags songs
 // @Accept json
 // @Produce json
-// @Param group query string false "Filter by group"
-// @Param song query string false "Filter by song name"
+// @Param artist_id query int false "Filter by artist"
+// @Param group query string false "Filter by exact group"
+// @Param group_contains query string false "Filter by case-insensitive substring of group"
+// @Param group_prefix query string false "Filter by case-insensitive prefix of group"
+// @Param song query string false "Filter by exact song name"
+// @Param song_contains query string false "Filter by case-insensitive substring of song name"
+// @Param song_prefix query string false "Filter by case-insensitive prefix of song name"
+// @Param link query string false "Filter by exact link"
+// @Param link_contains query string false "Filter by case-insensitive substring of link"
+// @Param link_prefix query string false "Filter by case-insensitive prefix of link"
+// @Param text query string false "Filter by exact text"
+// @Param text_contains query string false "Filter by case-insensitive substring of text"
+// @Param text_prefix query string false "Filter by case-insensitive prefix of text"
+// @Param released_after query string false "Released on or after date (DD.MM.YYYY or YYYY-MM-DD)"
+// @Param released_before query string false "Released on or before date (DD.MM.YYYY or YYYY-MM-DD)"
+// @Param tag query []string false "Filter by tag name; repeat for several tags" collectionFormat(multi)
+// @Param tag_mode query string false "Whether songs must carry all or any of the tags" Enums(all, any) default(all)
+// @Param facets query bool false "Include per-tag song counts for the whole filtered list" default(false)
+// @Param sort query string false "Sort field" Enums(id, group, song, release_date) default(id)
+// @Param order query string false "Sort order" Enums(asc, desc) default(asc)
+// @Param date_format query string false "Release date format in the response" Enums(dmy, iso) default(dmy)
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Number of items per page" default(10)
-// @Success 200 {object} models.SongListResponse "List of songs"
+// @Param cursor query string false "Keyset pagination cursor from next_cursor; pass it empty to start from the first song. Cannot be combined with page"
+// @Param If-None-Match header string false "ETag of a cached response"
+// @Param If-Modified-Since header string false "Last-Modified of a cached response"
+// @Success 200 {object} models.SongListResponse "List of songs with pagination metadata"
+// @Success 304 "Not modified since the cached response"
+// @Header 200 {integer} X-Total-Count "Total number of matching songs"
+// @Header 200 {string} Link "Links to the first, previous, next and last pages"
+// @Header 200 {string} ETag "Hash of the response"
+// @Header 200 {string} Last-Modified "Time of the latest write to any song"
+// @Header 200 {string} Cache-Control "CACHE_CONTROL_SONGS"
+// @Failure 400 {object} models.ErrorResponse "Invalid filter, sort or pagination"
 // @Failure 500 {object} models.ErrorResponse "Failed to get songs"
 // @Router /songs [get]
 func (h *SongHandler) GetSongs(c *gin.Context) {
-	filter := make(map[string]string)
-	if group := c.Query("group"); group != "" {
-		filter["group"] = group
+	filter, err := parseSongFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
 	}
-	if song := c.Query("song"); song != "" {
-		filter["song"] = song
+
+	sort, err := parseSongSort(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
 	}
 
-	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
-	if err != nil || page < 1 {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: fmt.Sprintf("page must be a positive integer. Got %v", page)})
+	layout, err := dateLayout(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
 		return
 	}
 
@@ -55,7 +92,76 @@ func (h *SongHandler) GetSongs(c *gin.Context) {
 		return
 	}
 
-	songs, err := h.service.GetSongs(filter, page, limit)
+	var facets []models.TagFacet
+	if withFacets, err := strconv.ParseBool(c.DefaultQuery("facets", "false")); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "facets must be true or false"})
+		return
+	} else if withFacets {
+		facets, err = h.service.GetTagFacets(filter)
+		if err != nil {
+			h.log.Errorf("Failed to get tag facets: %v", err)
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get songs"})
+			return
+		}
+	}
+
+	lastModified, err := h.service.GetSongsLastModified()
+	if err != nil {
+		h.log.Errorf("Failed to get songs: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get songs"})
+		return
+	}
+	setLastModified(c, lastModified)
+
+	if token, ok := c.GetQuery("cursor"); ok {
+		if c.Query("page") != "" {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "cursor cannot be combined with page"})
+			return
+		}
+		h.getSongsAfter(c, filter, sort, token, limit, layout, facets)
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: fmt.Sprintf("page must be a positive integer. Got %v", page)})
+		return
+	}
+
+	songs, total, err := h.service.GetSongs(filter, sort, page, limit)
+	if err != nil {
+		h.log.Errorf("Failed to get songs: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get songs"})
+		return
+	}
+
+	h.log.WithFields(logrus.Fields{
+		"count": len(songs),
+		"total": total,
+	}).Info("Songs retrieved successfully")
+
+	pagination := paginate(c, total, page, limit)
+	c.JSON(http.StatusOK, models.SongListResponse{
+		Songs:      models.ToSongResponseList(songs, layout),
+		Pagination: &pagination,
+		Facets:     facets,
+	})
+}
+
+// getSongsAfter serves GET /songs in keyset mode, where the page is addressed
+// by the cursor of the previous page instead of its number.
+func (h *SongHandler) getSongsAfter(c *gin.Context, filter models.SongFilter, sort models.SongSort, token string, limit int, layout string, facets []models.TagFacet) {
+	var cursor *models.SongCursor
+	if token != "" {
+		decoded, err := models.DecodeSongCursor(token)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid cursor"})
+			return
+		}
+		cursor = &decoded
+	}
+
+	songs, next, err := h.service.GetSongsAfter(filter, sort, cursor, limit)
 	if err != nil {
 		h.log.Errorf("Failed to get songs: %v", err)
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get songs"})
@@ -66,19 +172,77 @@ func (h *SongHandler) GetSongs(c *gin.Context) {
 		"count": len(songs),
 	}).Info("Songs retrieved successfully")
 
-	c.JSON(http.StatusOK, models.SongListResponse{Songs: models.ToSongResponseList(songs)})
+	response := models.SongListResponse{Songs: models.ToSongResponseList(songs, layout), Facets: facets}
+	if next != nil {
+		response.NextCursor = next.Encode()
+		c.Header("Link", fmt.Sprintf(`<%s>; rel="next"`, cursorURL(c, response.NextCursor)))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetSong
+// @Summary Get a song
+// @Description Get a song by ID. The ETag header carries the song's version for If-Match on updates and deletes
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param date_format query string false "Release date format in the response" Enums(dmy, iso) default(dmy)
+// @Success 200 {object} models.SongResponse "Song"
+// @Header 200 {string} ETag "ETag of the current version"
+// @Failure 400 {object} models.ErrorResponse "Invalid ID or date format"
+// @Failure 404 {object} models.ErrorResponse "Song not found"
+// @Failure 500 {object} models.ErrorResponse "Failed to get song"
+// @Router /songs/{id} [get]
+func (h *SongHandler) GetSong(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	layout, err := dateLayout(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	song, err := h.service.GetSong(id)
+	if errors.Is(err, repositories.ErrSongNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Song not found"})
+		return
+	}
+	if err != nil {
+		h.log.Errorf("Failed to get song: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get song"})
+		return
+	}
+
+	c.Header("ETag", songETag(song))
+	c.JSON(http.StatusOK, models.ToSongResponse(song, layout))
 }
 
 // GetSongLyrics
 // @Summary Get a lyrics of song
-// @Description Get a lyrics of song by its song's id
+// @Description Get a lyrics of song by its song's id, paginated by stanza. Each stanza has an index, a type (verse, chorus, pre-chorus, bridge, intro or outro) detected from labels like [Chorus] or from repetition, and its lines
 // @Tags songs
 // @Accept json
-// @Produce json
+// @Produce json,plain,html,application/x-lrc
 // @Param id path int true "Song ID"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Number of items per page" default(10)
-// @Success 200 {object} models.MessageResponse "Lyrics of song"
+// @Param format query string false "Response format; lrc exports the synchronized lyrics and ignores pagination" Enums(json, plain, html, lrc) default(json)
+// @Param If-None-Match header string false "ETag of a cached response"
+// @Param If-Modified-Since header string false "Last-Modified of a cached response"
+// @Success 200 {object} models.SongLyricsResponse "Page of stanzas with pagination metadata"
+// @Success 304 "Not modified since the cached response"
+// @Header 200 {integer} X-Total-Count "Total number of stanzas"
+// @Header 200 {string} Link "Links to the first, previous, next and last pages"
+// @Header 200 {string} ETag "Hash of the response"
+// @Header 200 {string} Last-Modified "Time of the last write to the song"
+// @Header 200 {string} Cache-Control "CACHE_CONTROL_LYRICS"
+// @Failure 400 {object} models.ErrorResponse "Invalid id, format or pagination"
+// @Failure 404 {object} models.ErrorResponse "Song not found"
 // @Failure 500 {object} models.ErrorResponse "Failed to get lyrics of the song"
 // @Router /songs/{id}/lyrics [get]
 func (h *SongHandler) GetSongLyrics(c *gin.Context) {
@@ -100,26 +264,116 @@ func (h *SongHandler) GetSongLyrics(c *gin.Context) {
 		return
 	}
 
-	lyrics, err := h.service.GetSongLyrics(id, page, limit)
+	format := c.DefaultQuery("format", "json")
+	if format == "lrc" {
+		h.getLRC(c, id)
+		return
+	}
+	if format != "json" && format != "plain" && format != "html" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: fmt.Sprintf("format must be json, plain, html or lrc. Got %q", format)})
+		return
+	}
+
+	lyrics, total, err := h.service.GetSongLyrics(id, page, limit)
+	if errors.Is(err, repositories.ErrSongNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Song not found"})
+		return
+	}
 	if err != nil {
 		h.log.Errorf("Failed to get lyrics: %v", err)
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get lyrics"})
 		return
 	}
+	// Taken from the cached lyrics, so that a cache hit needs no query.
+	setLastModified(c, lyrics.UpdatedAt)
+	stanzas := lyrics.Stanzas
+
+	pagination := paginate(c, total, page, limit)
+	switch format {
+	case "plain":
+		c.String(http.StatusOK, models.LyricsPlain(stanzas))
+	case "html":
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(models.LyricsHTML(stanzas)))
+	default:
+		c.JSON(http.StatusOK, models.SongLyricsResponse{
+			Stanzas:    stanzas,
+			Pagination: pagination,
+		})
+	}
+}
+
+// SearchSongs
+// @Summary Search songs by lyrics
+// @Description Full-text search over group, song name and lyrics. Words are combined with AND, "quoted phrases" must match in order and word* matches by prefix. Results are ranked and carry a highlighted snippet of the best matching verse
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param q query string true "Search query"
+// @Param date_format query string false "Release date format in the response" Enums(dmy, iso) default(dmy)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Success 200 {object} models.SongSearchResponse "Ranked search results"
+// @Failure 400 {object} models.ErrorResponse "Invalid query or pagination"
+// @Failure 500 {object} models.ErrorResponse "Failed to search songs"
+// @Router /songs/search [get]
+func (h *SongHandler) SearchSongs(c *gin.Context) {
+	query, err := models.ParseSearchQuery(c.Query("q"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	layout, err := dateLayout(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid page number"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid limit number"})
+		return
+	}
+
+	results, err := h.service.SearchSongs(query, page, limit)
+	if err != nil {
+		h.log.Errorf("Failed to search songs: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to search songs"})
+		return
+	}
 
-	c.JSON(http.StatusOK, models.MessageResponse{Message: lyrics})
+	h.log.WithFields(logrus.Fields{
+		"q":     c.Query("q"),
+		"count": len(results),
+	}).Info("Songs searched successfully")
+
+	c.JSON(http.StatusOK, models.ToSongSearchResponse(results, layout))
 }
 
 // DeleteSong
 // @Summary Delete a song
-// @Description Delete a song by ID
+// @Description Move a song to the trash by ID. Trashed songs are hidden everywhere, can be restored and are purged after the retention period
 // @Tags songs
 // @Accept json
 // @Produce json
 // @Param id path int true "Song ID"
+// @Param If-Match header string false "ETag of the song; required unless REQUIRE_IF_MATCH=false"
 // @Success 200 {object} models.MessageResponse "Song deleted"
 // @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 404 {object} models.ErrorResponse "Song not found"
+// @Failure 412 {object} models.SongResponse "Song changed since the given ETag; the body is the current song"
+// @Failure 428 {object} models.ErrorResponse "If-Match header required"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
 // @Failure 500 {object} models.ErrorResponse "Failed to delete song"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /songs/{id} [delete]
 func (h *SongHandler) DeleteSong(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -128,8 +382,22 @@ func (h *SongHandler) DeleteSong(c *gin.Context) {
 		return
 	}
 
-	err = h.service.DeleteSong(id)
+	version, ok := h.ifMatchVersion(c, id)
+	if !ok {
+		return
+	}
+
+	err = h.service.DeleteSong(id, version)
+	if errors.Is(err, repositories.ErrSongNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Song not found"})
+		return
+	}
+	if errors.Is(err, repositories.ErrVersionConflict) {
+		h.respondConflict(c, id)
+		return
+	}
 	if err != nil {
+		h.log.Errorf("Failed to delete song: %v", err)
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to delete song"})
 		return
 	}
@@ -139,15 +407,25 @@ func (h *SongHandler) DeleteSong(c *gin.Context) {
 
 // UpdateSong
 // @Summary Update a song
-// @Description Update an existing song by ID
+// @Description Update an existing song by ID. The song is linked to the artist given by artist_id or, without it, by group name or alias; unknown names create a new artist
 // @Tags songs
 // @Accept json
 // @Produce json
 // @Param id path int true "Song ID"
 // @Param song body models.Song true "Updated song data"
+// @Param date_format query string false "Release date format in the response" Enums(dmy, iso) default(dmy)
+// @Param If-Match header string false "ETag of the song; required unless REQUIRE_IF_MATCH=false"
 // @Success 200 {object} models.SongResponse "Song updated"
+// @Header 200 {string} ETag "ETag of the new version"
 // @Failure 400 {object} models.ErrorResponse "Invalid request body or ID"
+// @Failure 404 {object} models.ErrorResponse "Song not found"
+// @Failure 412 {object} models.SongResponse "Song changed since the given ETag; the body is the current song"
+// @Failure 428 {object} models.ErrorResponse "If-Match header required"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
 // @Failure 500 {object} models.ErrorResponse "Failed to update song"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /songs/{id} [put]
 func (h *SongHandler) UpdateSong(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -156,29 +434,55 @@ func (h *SongHandler) UpdateSong(c *gin.Context) {
 		return
 	}
 
+	layout, err := dateLayout(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
 	var song models.Song
 	if err := c.ShouldBindJSON(&song); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid song"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: invalidSongMessage(err)})
 		return
 	}
 	song.ID = id
 
-	if song.ReleaseDate == "" {
-		song.ReleaseDate = time.Now().Format("02.01.2006")
+	if (song.Group == "" && song.ArtistID == 0) || song.Song == "" {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid song"})
+		return
+	}
+
+	version, ok := h.ifMatchVersion(c, id)
+	if !ok {
+		return
 	}
+	song.Version = version
 
 	if err := h.service.UpdateSong(&song); err != nil {
 		h.log.WithFields(logrus.Fields{
 			"error": err,
 		}).Error("Failed to update song")
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update song"})
+		switch {
+		case errors.Is(err, repositories.ErrSongNotFound):
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Song not found"})
+		case errors.Is(err, repositories.ErrVersionConflict):
+			h.respondConflict(c, id)
+		case errors.Is(err, repositories.ErrArtistNotFound):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Unknown artist_id"})
+		case errors.Is(err, services.ErrArtistMismatch):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: invalidSongMessage(err)})
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update song"})
+		}
 		return
 	}
 
-	songResponse := models.ToSongResponse(song)
+	songResponse := models.ToSongResponse(song, layout)
 
+	c.Header("ETag", songETag(song))
 	c.JSON(http.StatusOK, models.SongResponse{
 		ID:          songResponse.ID,
+		ArtistID:    songResponse.ArtistID,
 		Group:       songResponse.Group,
 		Song:        songResponse.Song,
 		ReleaseDate: songResponse.ReleaseDate,
@@ -189,49 +493,66 @@ func (h *SongHandler) UpdateSong(c *gin.Context) {
 
 // AddSong
 // @Summary Add a new song
-// @Description Add a new song to the database
+// @Description Add a new song to the database. The song is linked to the artist given by artist_id or, without it, by group name or alias; unknown names create a new artist. Missing release date, text and link are fetched from the song info service; a song it does not know (404) is stored as given
 // @Tags songs
 // @Accept json
 // @Produce json
 // @Param song body models.Song true "Song data"
+// @Param date_format query string false "Release date format in the response" Enums(dmy, iso) default(dmy)
 // @Success 200 {object} models.SongResponse "Song added"
+// @Header 200 {string} ETag "ETag of the song"
 // @Failure 400 {object} models.ErrorResponse "Invalid request body"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
 // @Failure 500 {object} models.ErrorResponse "Failed to add song"
+// @Failure 502 {object} models.ErrorResponse "Song info service is unavailable"
+// @Failure 504 {object} models.ErrorResponse "Song info service timed out"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /songs [post]
 func (h *SongHandler) AddSong(c *gin.Context) {
+	layout, err := dateLayout(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
 	var song models.Song
 	if err := c.ShouldBindJSON(&song); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid song"})
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: invalidSongMessage(err)})
 		return
 	}
 
-	if song.Group == "" || song.Song == "" {
+	if (song.Group == "" && song.ArtistID == 0) || song.Song == "" {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid song"})
 		return
 	}
 
-	if song.ReleaseDate == "" {
-		song.ReleaseDate = time.Now().Format("02.01.2006")
-	}
-	if song.Text == "" {
-		song.Text = ""
-	}
-	if song.Link == "" {
-		song.Link = ""
-	}
-
-	if err := h.service.AddSong(&song); err != nil {
+	if err := h.service.AddSong(c.Request.Context(), &song); err != nil {
 		h.log.WithFields(logrus.Fields{
 			"error": err,
 		}).Error("Failed to add song")
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to add song"})
+		switch {
+		case errors.Is(err, repositories.ErrArtistNotFound):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Unknown artist_id"})
+		case errors.Is(err, services.ErrArtistMismatch):
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: invalidSongMessage(err)})
+		case errors.Is(err, services.ErrUpstreamTimeout):
+			c.JSON(http.StatusGatewayTimeout, models.ErrorResponse{Error: "Song info service timed out"})
+		case errors.Is(err, services.ErrUpstreamUnavailable):
+			c.JSON(http.StatusBadGateway, models.ErrorResponse{Error: "Song info service is unavailable"})
+		default:
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to add song"})
+		}
 		return
 	}
 
-	songResponse := models.ToSongResponse(song)
+	songResponse := models.ToSongResponse(song, layout)
 
+	c.Header("ETag", songETag(song))
 	c.JSON(http.StatusOK, models.SongResponse{
 		ID:          songResponse.ID,
+		ArtistID:    songResponse.ArtistID,
 		Group:       songResponse.Group,
 		Song:        songResponse.Song,
 		ReleaseDate: songResponse.ReleaseDate,
@@ -239,3 +560,100 @@ func (h *SongHandler) AddSong(c *gin.Context) {
 		Link:        songResponse.Link,
 	})
 }
+
+func parseSongFilter(c *gin.Context) (models.SongFilter, error) {
+	var filter models.SongFilter
+
+	if value := c.Query("artist_id"); value != "" {
+		artistID, err := strconv.Atoi(value)
+		if err != nil {
+			return filter, fmt.Errorf("artist_id must be an integer. Got %q", value)
+		}
+		filter.ArtistID = &artistID
+	}
+
+	for _, field := range models.FilterFields {
+		if value := c.Query(field); value != "" {
+			filter.Matches = append(filter.Matches, models.FieldMatch{Field: field, Match: models.MatchExact, Value: value})
+		}
+		if value := c.Query(field + "_contains"); value != "" {
+			filter.Matches = append(filter.Matches, models.FieldMatch{Field: field, Match: models.MatchContains, Value: value})
+		}
+		if value := c.Query(field + "_prefix"); value != "" {
+			filter.Matches = append(filter.Matches, models.FieldMatch{Field: field, Match: models.MatchPrefix, Value: value})
+		}
+	}
+
+	if value := c.Query("released_after"); value != "" {
+		date, err := models.ParseDate(value)
+		if err != nil {
+			return filter, fmt.Errorf("released_after: %w", err)
+		}
+		filter.ReleasedAfter = &date
+	}
+	if value := c.Query("released_before"); value != "" {
+		date, err := models.ParseDate(value)
+		if err != nil {
+			return filter, fmt.Errorf("released_before: %w", err)
+		}
+		filter.ReleasedBefore = &date
+	}
+
+	filter.Tags = c.QueryArray("tag")
+	switch mode := models.TagMode(c.DefaultQuery("tag_mode", string(models.TagMatchAll))); mode {
+	case models.TagMatchAll, models.TagMatchAny:
+		filter.TagMode = mode
+	default:
+		return filter, fmt.Errorf("tag_mode must be all or any. Got %q", mode)
+	}
+
+	return filter, nil
+}
+
+func parseSongSort(c *gin.Context) (models.SongSort, error) {
+	sort := models.SongSort{Field: c.DefaultQuery("sort", "id")}
+
+	known := false
+	for _, field := range models.SortFields {
+		if field == sort.Field {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return sort, fmt.Errorf("sort must be one of %s. Got %q", strings.Join(models.SortFields, ", "), sort.Field)
+	}
+
+	switch order := c.DefaultQuery("order", "asc"); order {
+	case "asc":
+	case "desc":
+		sort.Desc = true
+	default:
+		return sort, fmt.Errorf("order must be asc or desc. Got %q", order)
+	}
+
+	return sort, nil
+}
+
+// dateLayout picks how release dates are rendered: DD.MM.YYYY by default,
+// ISO 8601 with date_format=iso.
+func dateLayout(c *gin.Context) (string, error) {
+	switch format := c.DefaultQuery("date_format", "dmy"); format {
+	case "dmy":
+		return models.DateLayout, nil
+	case "iso":
+		return models.ISODateLayout, nil
+	default:
+		return "", fmt.Errorf("date_format must be dmy or iso. Got %q", format)
+	}
+}
+
+func invalidSongMessage(err error) string {
+	if errors.Is(err, models.ErrInvalidDate) {
+		return "Invalid song: release_date " + strings.TrimPrefix(err.Error(), "date ")
+	}
+	if errors.Is(err, services.ErrInvalidSong) || errors.Is(err, services.ErrArtistMismatch) {
+		return "Invalid song: " + err.Error()
+	}
+	return "Invalid song"
+}
@@ -0,0 +1,602 @@
+package handlers
+
+import (
+	"case/cache"
+	"case/models"
+	"case/repositories"
+	"case/services"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const testSecret = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// testServer serves the API's routes over in-memory stores holding one
+// song, one album, one private playlist owned by "alice" and one API key. Subjects get
+// the reader role unless granted another.
+type testServer struct {
+	router   *gin.Engine
+	songs    repositories.SongStore
+	tokens   *services.TokenService
+	authz    *services.AuthzService
+	songID   int
+	songETag string
+	albumID  int
+	// playlistID is private to alice.
+	playlistID int
+	// apiKey only carries songs:write.
+	apiKey string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	return newTestServerWith(t, nil)
+}
+
+// newTestServerWith serves the songs through wrap(store) when wrap is not
+// nil, as main does with the cache.
+func newTestServerWith(t *testing.T, wrap func(repositories.SongStore, *logrus.Logger) repositories.SongStore) *testServer {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	songRepo := repositories.NewMemorySongRepository(log)
+	artistRepo := repositories.NewMemoryArtistRepository(songRepo, log)
+	tagRepo := repositories.NewMemoryTagRepository(songRepo, log)
+	playlistRepo := repositories.NewMemoryPlaylistRepository(songRepo, log)
+	albumRepo := repositories.NewMemoryAlbumRepository(songRepo, artistRepo, log)
+
+	var songStore repositories.SongStore = songRepo
+	if wrap != nil {
+		songStore = wrap(songRepo, log)
+	}
+
+	tokens, err := services.NewHS256TokenService([]byte(testSecret), "", time.Hour)
+	if err != nil {
+		t.Fatalf("NewHS256TokenService: %v", err)
+	}
+	info := services.NewInfoClient("", time.Second, 0, log)
+	service := services.NewSongService(songStore, artistRepo, tagRepo, info)
+	apiKeyService := services.NewAPIKeyService(repositories.NewMemoryAPIKeyRepository(log))
+	authz := services.NewAuthzService(repositories.NewMemoryRoleRepository(log), models.RoleReader)
+
+	routes := Routes{
+		Songs:     NewSongHandler(service, true, log),
+		Artists:   NewArtistHandler(services.NewArtistService(artistRepo), log),
+		Albums:    NewAlbumHandler(services.NewAlbumService(albumRepo, artistRepo), log),
+		Tags:      NewTagHandler(services.NewTagService(tagRepo), log),
+		Playlists: NewPlaylistHandler(services.NewPlaylistService(playlistRepo), log),
+		APIKeys:   NewAPIKeyHandler(apiKeyService, log),
+		Tokens:    tokens,
+		Keys:      apiKeyService,
+		Authz:     authz,
+	}
+	r := gin.New()
+	routes.Register(r, log)
+
+	song := models.Song{Group: "Muse", Song: "Uprising", Text: "Paranoia is in bloom"}
+	if err := songStore.AddSong(&song); err != nil {
+		t.Fatalf("AddSong: %v", err)
+	}
+	album := models.Album{Title: "The Resistance"}
+	if err := albumRepo.AddAlbum(&album); err != nil {
+		t.Fatalf("AddAlbum: %v", err)
+	}
+	playlist := models.Playlist{Name: "Road trip", Owner: "alice"}
+	if err := playlistRepo.AddPlaylist(&playlist); err != nil {
+		t.Fatalf("AddPlaylist: %v", err)
+	}
+	_, apiKey, err := apiKeyService.CreateAPIKey(models.CreateAPIKeyRequest{Name: "ci", Scopes: []string{models.PermSongsWrite}})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+
+	return &testServer{
+		router:     r,
+		songs:      songStore,
+		tokens:     tokens,
+		authz:      authz,
+		songID:     song.ID,
+		songETag:   songETag(song),
+		albumID:    album.ID,
+		playlistID: playlist.ID,
+		apiKey:     apiKey,
+	}
+}
+
+// bearer returns an Authorization header value for subject, granting it
+// role first unless role is empty.
+func (s *testServer) bearer(t *testing.T, subject, role string) string {
+	t.Helper()
+
+	if role != "" {
+		if err := s.authz.GrantRole(subject, role); err != nil {
+			t.Fatalf("GrantRole: %v", err)
+		}
+	}
+	token, _, err := s.tokens.Issue(subject)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	return "Bearer " + token
+}
+
+func (s *testServer) do(method, path string, headers map[string]string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// statusCase is a request against a fresh testServer, after setup if that
+// is set, and the response it must get. path defaults to the song's URL.
+type statusCase struct {
+	name       string
+	setup      func(t *testing.T, s *testServer)
+	method     string
+	path       func(s *testServer) string
+	headers    func(t *testing.T, s *testServer) map[string]string
+	body       string
+	wantStatus int
+	wantReason string
+	wantETag   string
+}
+
+func runStatusCases(t *testing.T, tests []statusCase) {
+	t.Helper()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			if tt.setup != nil {
+				tt.setup(t, s)
+			}
+
+			path := "/songs/" + strconv.Itoa(s.songID)
+			if tt.path != nil {
+				path = tt.path(s)
+			}
+			var headers map[string]string
+			if tt.headers != nil {
+				headers = tt.headers(t, s)
+			}
+
+			w := s.do(tt.method, path, headers, tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("%s %s = %d %s; want %d", tt.method, path, w.Code, w.Body, tt.wantStatus)
+			}
+			if tt.wantReason != "" {
+				var response models.ErrorResponse
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("decode %s: %v", w.Body, err)
+				}
+				if response.Reason != tt.wantReason {
+					t.Errorf("reason = %q; want %q", response.Reason, tt.wantReason)
+				}
+			}
+			if tt.wantETag != "" {
+				if got := w.Header().Get("ETag"); got != tt.wantETag {
+					t.Errorf("ETag = %q; want %q", got, tt.wantETag)
+				}
+			}
+		})
+	}
+}
+
+func otherSong(s *testServer) string { return "/songs/" + strconv.Itoa(s.songID+1) }
+
+func TestSongStatusCodes(t *testing.T) {
+	runStatusCases(t, []statusCase{
+		{name: "song", method: http.MethodGet, wantStatus: http.StatusOK},
+		{name: "missing song", method: http.MethodGet, path: otherSong, wantStatus: http.StatusNotFound},
+		{name: "invalid id", method: http.MethodGet, path: func(*testServer) string { return "/songs/x" }, wantStatus: http.StatusBadRequest},
+		{
+			name:   "replace without a title",
+			method: http.MethodPut,
+			headers: func(t *testing.T, s *testServer) map[string]string {
+				return map[string]string{"Authorization": s.bearer(t, "eve", models.RoleEditor), "If-Match": s.songETag}
+			},
+			body:       `{"group":"Muse","text":"Paranoia is in bloom"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	})
+}
+
+func privatePlaylist(s *testServer) string { return "/playlists/" + strconv.Itoa(s.playlistID) }
+
+func cacheStats(*testServer) string { return "/cache/stats" }
+
+// trashSong moves the seeded song to the trash.
+func trashSong(t *testing.T, s *testServer) {
+	t.Helper()
+	if err := s.songs.DeleteSong(s.songID, 0); err != nil {
+		t.Fatalf("DeleteSong: %v", err)
+	}
+}
+
+func albumTracks(s *testServer) string { return "/albums/" + strconv.Itoa(s.albumID) + "/tracks" }
+
+func TestAlbumStatusCodes(t *testing.T) {
+	runStatusCases(t, []statusCase{
+		{
+			name:   "add a track",
+			method: http.MethodPost,
+			path:   albumTracks,
+			headers: func(t *testing.T, s *testServer) map[string]string {
+				return map[string]string{"Authorization": s.bearer(t, "eve", models.RoleEditor)}
+			},
+			body:       `{"song_id":1,"disc_number":1}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "add a trashed song as a track",
+			setup:  trashSong,
+			method: http.MethodPost,
+			path:   albumTracks,
+			headers: func(t *testing.T, s *testServer) map[string]string {
+				return map[string]string{"Authorization": s.bearer(t, "eve", models.RoleEditor)}
+			},
+			body:       `{"song_id":1,"disc_number":1}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	})
+}
+
+func TestPlaylistStatusCodes(t *testing.T) {
+	runStatusCases(t, []statusCase{
+		{
+			name:       "private playlist without credentials",
+			method:     http.MethodGet,
+			path:       privatePlaylist,
+			wantStatus: http.StatusUnauthorized,
+			wantReason: models.ReasonMissingToken,
+		},
+		{
+			name:   "private playlist of another subject",
+			method: http.MethodGet,
+			path:   privatePlaylist,
+			headers: func(t *testing.T, s *testServer) map[string]string {
+				return map[string]string{"Authorization": s.bearer(t, "bob", "")}
+			},
+			wantStatus: http.StatusForbidden,
+			wantReason: models.ReasonNotOwner,
+		},
+		{
+			name:   "private playlist of its owner",
+			method: http.MethodGet,
+			path:   privatePlaylist,
+			headers: func(t *testing.T, s *testServer) map[string]string {
+				return map[string]string{"Authorization": s.bearer(t, "alice", "")}
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "missing playlist",
+			method: http.MethodGet,
+			path:   func(s *testServer) string { return "/playlists/" + strconv.Itoa(s.playlistID+1) },
+			headers: func(t *testing.T, s *testServer) map[string]string {
+				return map[string]string{"Authorization": s.bearer(t, "alice", "")}
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:   "add a song",
+			method: http.MethodPost,
+			path:   func(s *testServer) string { return privatePlaylist(s) + "/entries" },
+			headers: func(t *testing.T, s *testServer) map[string]string {
+				return map[string]string{"Authorization": s.bearer(t, "alice", "")}
+			},
+			body:       `{"song_id":1}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "add a trashed song",
+			setup:  trashSong,
+			method: http.MethodPost,
+			path:   func(s *testServer) string { return privatePlaylist(s) + "/entries" },
+			headers: func(t *testing.T, s *testServer) map[string]string {
+				return map[string]string{"Authorization": s.bearer(t, "alice", "")}
+			},
+			body:       `{"song_id":1}`,
+			wantStatus: http.StatusBadRequest,
+		},
+	})
+}
+
+func TestAuthStatusCodes(t *testing.T) {
+	runStatusCases(t, []statusCase{
+		{
+			name:       "no credentials",
+			method:     http.MethodDelete,
+			wantStatus: http.StatusUnauthorized,
+			wantReason: models.ReasonMissingToken,
+		},
+		{
+			name:   "not a bearer token",
+			method: http.MethodDelete,
+			headers: func(t *testing.T, s *testServer) map[string]string {
+				return map[string]string{"Authorization": "Basic YWxpY2U6c2VjcmV0"}
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantReason: models.ReasonMissingToken,
+		},
+		{
+			name:   "forged token",
+			method: http.MethodDelete,
+			headers: func(t *testing.T, s *testServer) map[string]string {
+				return map[string]string{"Authorization": "Bearer e30.e30.c2ln"}
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantReason: models.ReasonInvalidToken,
+		},
+		{
+			name:       "reads stay public",
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+		},
+	})
+}
+
+func TestPermissionStatusCodes(t *testing.T) {
+	runStatusCases(t, []statusCase{
+		{
+			name:   "reader cannot delete",
+			method: http.MethodDelete,
+			headers: func(t *testing.T, s *testServer) map[string]string {
+				return map[string]string{"Authorization": s.bearer(t, "bob", ""), "If-Match": s.songETag}
+			},
+			wantStatus: http.StatusForbidden,
+			wantReason: models.ReasonMissingPermission,
+		},
+		{
+			name:   "editor cannot delete",
+			method: http.MethodDelete,
+			headers: func(t *testing.T, s *testServer) map[string]string {
+				return map[string]string{"Authorization": s.bearer(t, "eve", models.RoleEditor), "If-Match": s.songETag}
+			},
+			wantStatus: http.StatusForbidden,
+			wantReason: models.ReasonMissingPermission,
+		},
+		{
+			name:   "moderator can delete",
+			method: http.MethodDelete,
+			headers: func(t *testing.T, s *testServer) map[string]string {
+				return map[string]string{"Authorization": s.bearer(t, "mod", models.RoleModerator), "If-Match": s.songETag}
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "rename another subject's playlist",
+			method: http.MethodPut,
+			path:   privatePlaylist,
+			headers: func(t *testing.T, s *testServer) map[string]string {
+				return map[string]string{"Authorization": s.bearer(t, "bob", "")}
+			},
+			body:       `{"name":"Mine now","public":true}`,
+			wantStatus: http.StatusForbidden,
+			wantReason: models.ReasonNotOwner,
+		},
+		{
+			name:       "cache stats without a token",
+			method:     http.MethodGet,
+			path:       cacheStats,
+			wantStatus: http.StatusUnauthorized,
+			wantReason: models.ReasonMissingToken,
+		},
+		{
+			name:   "moderator cannot read cache stats",
+			method: http.MethodGet,
+			path:   cacheStats,
+			headers: func(t *testing.T, s *testServer) map[string]string {
+				return map[string]string{"Authorization": s.bearer(t, "mod", models.RoleModerator)}
+			},
+			wantStatus: http.StatusForbidden,
+			wantReason: models.ReasonMissingPermission,
+		},
+		{
+			name:   "admin reads cache stats",
+			method: http.MethodGet,
+			path:   cacheStats,
+			headers: func(t *testing.T, s *testServer) map[string]string {
+				return map[string]string{"Authorization": s.bearer(t, "root", models.RoleAdmin)}
+			},
+			wantStatus: http.StatusOK,
+		},
+	})
+}
+
+func TestAPIKeyStatusCodes(t *testing.T) {
+	runStatusCases(t, []statusCase{
+		{
+			name:   "unknown API key",
+			method: http.MethodDelete,
+			headers: func(t *testing.T, s *testServer) map[string]string {
+				return map[string]string{apiKeyHeader: "sk_000000000000_guess"}
+			},
+			wantStatus: http.StatusUnauthorized,
+			wantReason: models.ReasonInvalidAPIKey,
+		},
+		{
+			name:   "API key outside its scopes",
+			method: http.MethodDelete,
+			headers: func(t *testing.T, s *testServer) map[string]string {
+				return map[string]string{apiKeyHeader: s.apiKey, "If-Match": s.songETag}
+			},
+			wantStatus: http.StatusForbidden,
+			wantReason: models.ReasonMissingPermission,
+		},
+		{
+			name:   "API key within its scopes",
+			method: http.MethodPut,
+			headers: func(t *testing.T, s *testServer) map[string]string {
+				return map[string]string{apiKeyHeader: s.apiKey, "If-Match": s.songETag}
+			},
+			body:       `{"group":"Muse","song":"Uprising","release_date":"07.09.2009","text":"They will not force us"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "API keys cannot manage API keys",
+			method: http.MethodGet,
+			path:   func(*testServer) string { return "/apikeys" },
+			headers: func(t *testing.T, s *testServer) map[string]string {
+				return map[string]string{apiKeyHeader: s.apiKey}
+			},
+			wantStatus: http.StatusForbidden,
+			wantReason: models.ReasonMissingPermission,
+		},
+	})
+}
+
+func TestIfMatchStatusCodes(t *testing.T) {
+	const update = `{"group":"Muse","song":"Uprising","release_date":"07.09.2009","text":"They will not force us"}`
+	moderator := func(ifMatch string) func(t *testing.T, s *testServer) map[string]string {
+		return func(t *testing.T, s *testServer) map[string]string {
+			headers := map[string]string{"Authorization": s.bearer(t, "mod", models.RoleModerator)}
+			if ifMatch != "" {
+				headers["If-Match"] = ifMatch
+			}
+			return headers
+		}
+	}
+	current := func(t *testing.T, s *testServer) map[string]string {
+		return moderator(s.songETag)(t, s)
+	}
+
+	runStatusCases(t, []statusCase{
+		{name: "GET sends the ETag", method: http.MethodGet, wantStatus: http.StatusOK, wantETag: `"v1"`},
+		{name: "delete without If-Match", method: http.MethodDelete, headers: moderator(""), wantStatus: http.StatusPreconditionRequired},
+		{name: "update without If-Match", method: http.MethodPut, headers: moderator(""), body: update, wantStatus: http.StatusPreconditionRequired},
+		{
+			name:       "LRC upload without If-Match",
+			method:     http.MethodPut,
+			path:       func(s *testServer) string { return "/songs/" + strconv.Itoa(s.songID) + "/lyrics/sync" },
+			headers:    moderator(""),
+			body:       "[00:01.00]Paranoia",
+			wantStatus: http.StatusPreconditionRequired,
+		},
+		{
+			name:       "revision restore without If-Match",
+			method:     http.MethodPost,
+			path:       func(s *testServer) string { return "/songs/" + strconv.Itoa(s.songID) + "/revisions/1/restore" },
+			headers:    moderator(""),
+			wantStatus: http.StatusPreconditionRequired,
+		},
+		{name: "If-Match on a missing song", method: http.MethodDelete, path: otherSong, headers: current, wantStatus: http.StatusNotFound},
+		{name: "stale If-Match", method: http.MethodPut, headers: moderator(`"v0"`), body: update, wantStatus: http.StatusPreconditionFailed, wantETag: `"v1"`},
+		{
+			name:       "weak If-Match never matches",
+			method:     http.MethodPut,
+			headers:    func(t *testing.T, s *testServer) map[string]string { return moderator("W/"+s.songETag)(t, s) },
+			body:       update,
+			wantStatus: http.StatusPreconditionFailed,
+		},
+		{name: "current If-Match", method: http.MethodPut, headers: current, body: update, wantStatus: http.StatusOK, wantETag: `"v2"`},
+		{name: "wildcard If-Match", method: http.MethodDelete, headers: moderator("*"), wantStatus: http.StatusOK},
+		{name: "delete with the current If-Match", method: http.MethodDelete, headers: current, wantStatus: http.StatusOK},
+	})
+}
+
+func TestConditionalNotModified(t *testing.T) {
+	s := newTestServer(t)
+	path := "/songs/" + strconv.Itoa(s.songID) + "/lyrics"
+
+	first := s.do(http.MethodGet, path, nil, "")
+	if first.Code != http.StatusOK {
+		t.Fatalf("GET %s = %d; want 200", path, first.Code)
+	}
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("GET response has no ETag")
+	}
+	lastModified := first.Header().Get("Last-Modified")
+	if lastModified == "" {
+		t.Fatal("GET response has no Last-Modified")
+	}
+
+	tests := []struct {
+		name       string
+		headers    map[string]string
+		wantStatus int
+	}{
+		{"same ETag", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"weak form of the ETag", map[string]string{"If-None-Match": "W/" + etag}, http.StatusNotModified},
+		{"one of several", map[string]string{"If-None-Match": `"stale", ` + etag}, http.StatusNotModified},
+		{"wildcard", map[string]string{"If-None-Match": "*"}, http.StatusNotModified},
+		{"other ETag", map[string]string{"If-None-Match": `"stale"`}, http.StatusOK},
+		{"not modified since", map[string]string{"If-Modified-Since": lastModified}, http.StatusNotModified},
+		{"modified since", map[string]string{"If-Modified-Since": "Mon, 02 Jan 2006 15:04:05 GMT"}, http.StatusOK},
+		{"If-None-Match wins", map[string]string{"If-None-Match": `"stale"`, "If-Modified-Since": lastModified}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := s.do(http.MethodGet, path, tt.headers, "")
+			if w.Code != tt.wantStatus {
+				t.Fatalf("GET with %v = %d; want %d", tt.headers, w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("ETag"); got != etag {
+				t.Errorf("ETag = %q; want %q", got, etag)
+			}
+			if tt.wantStatus == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Errorf("304 carries a body: %s", w.Body)
+			}
+		})
+	}
+}
+
+// countingSongStore counts the reads that reach the store behind the cache.
+type countingSongStore struct {
+	repositories.SongStore
+	getSong, getSongLyrics int
+}
+
+func (s *countingSongStore) GetSong(id int) (models.Song, error) {
+	s.getSong++
+	return s.SongStore.GetSong(id)
+}
+
+func (s *countingSongStore) GetSongLyrics(id, page, limit int) (models.Lyrics, int, error) {
+	s.getSongLyrics++
+	return s.SongStore.GetSongLyrics(id, page, limit)
+}
+
+func TestCachedLyricsSkipTheStore(t *testing.T) {
+	var store *countingSongStore
+	s := newTestServerWith(t, func(songs repositories.SongStore, log *logrus.Logger) repositories.SongStore {
+		store = &countingSongStore{SongStore: songs}
+		return repositories.NewCachedSongStore(store, cache.NewLRU(0), "lru", time.Minute, log)
+	})
+	path := "/songs/" + strconv.Itoa(s.songID) + "/lyrics"
+
+	var lastModified string
+	for i := 0; i < 3; i++ {
+		w := s.do(http.MethodGet, path, nil, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d; want 200", path, w.Code)
+		}
+		if lastModified = w.Header().Get("Last-Modified"); lastModified == "" {
+			t.Fatal("GET response has no Last-Modified")
+		}
+	}
+
+	if store.getSong != 0 || store.getSongLyrics != 1 {
+		t.Errorf("store saw %d GetSong and %d GetSongLyrics calls; want 0 and 1", store.getSong, store.getSongLyrics)
+	}
+}
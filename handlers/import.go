@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"case/models"
+	"case/services"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultImportBatch = 500
+	maxImportBatch     = 10000
+)
+
+// importFormats maps the accepted content types to import formats.
+var importFormats = map[string]string{
+	"text/csv":              models.ImportCSV,
+	"application/x-ndjson":  models.ImportNDJSON,
+	"application/jsonl":     models.ImportNDJSON,
+	"application/jsonlines": models.ImportNDJSON,
+}
+
+// ImportSongs
+// @Summary Import songs in bulk
+// @Description Import songs from a CSV file with a header row or from newline-delimited JSON objects. Columns or keys named like song fields (group, song, release_date, text, link, artist_id) are used as is, others can be mapped with map=column:field and the rest are ignored. Songs are linked to artists like in POST /songs, missing release dates stay empty and the song info service is not called. Rows are saved in batches of batch_size, one transaction each; invalid rows fail without affecting the others and, unless allow_duplicates is set, songs with the group and title of an existing song or an earlier row are skipped. The report lists the outcome of every row
+// @Tags songs
+// @Accept plain
+// @Produce json
+// @Param body body string true "CSV or NDJSON rows"
+// @Param format query string false "Input format; defaults to the one given by Content-Type" Enums(csv, ndjson)
+// @Param map query []string false "Column mapping as column:field; repeat for several columns" collectionFormat(multi)
+// @Param delimiter query string false "CSV field delimiter" default(,)
+// @Param batch_size query int false "Rows per transaction" default(500)
+// @Param allow_duplicates query bool false "Import songs whose group and title already exist" default(false)
+// @Success 200 {object} models.ImportReport "Per-row import report"
+// @Failure 400 {object} models.ErrorResponse "Invalid header, mapping or options, or unreadable input (with the report so far)"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 415 {object} models.ErrorResponse "Unsupported content type"
+// @Failure 500 {object} models.ImportReport "Import aborted; the report covers the rows read so far"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /songs/import [post]
+func (h *SongHandler) ImportSongs(c *gin.Context) {
+	format := c.Query("format")
+	if format == "" {
+		var ok bool
+		if format, ok = importFormats[c.ContentType()]; !ok {
+			c.JSON(http.StatusUnsupportedMediaType, models.ErrorResponse{Error: fmt.Sprintf("Content-Type must be text/csv or application/x-ndjson, or pass format. Got %q", c.ContentType())})
+			return
+		}
+	}
+
+	mapping, err := models.ParseImportMapping(c.QueryArray("map"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	delimiter, err := models.ParseImportDelimiter(c.DefaultQuery("delimiter", ","))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	batchSize, err := strconv.Atoi(c.DefaultQuery("batch_size", strconv.Itoa(defaultImportBatch)))
+	if err != nil || batchSize < 1 || batchSize > maxImportBatch {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: fmt.Sprintf("batch_size must be between 1 and %d", maxImportBatch)})
+		return
+	}
+
+	allowDuplicates, err := strconv.ParseBool(c.DefaultQuery("allow_duplicates", "false"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "allow_duplicates must be true or false"})
+		return
+	}
+
+	reader, err := models.NewSongReader(c.Request.Body, format, mapping, delimiter)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	report, err := h.service.ImportSongs(reader, batchSize, allowDuplicates)
+	if errors.Is(err, services.ErrImportInput) {
+		c.JSON(http.StatusBadRequest, report)
+		return
+	}
+	if err != nil {
+		h.log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("Failed to import songs")
+		report.Error = "Failed to save songs, the import was aborted"
+		c.JSON(http.StatusInternalServerError, report)
+		return
+	}
+
+	h.log.WithFields(logrus.Fields{
+		"created": report.Created,
+		"skipped": report.Skipped,
+		"failed":  report.Failed,
+	}).Info("Songs imported")
+
+	c.JSON(http.StatusOK, report)
+}
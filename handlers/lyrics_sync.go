@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"case/models"
+	"case/repositories"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const maxLRCSize = 1 << 20
+
+// UploadLRC
+// @Summary Upload synchronized lyrics
+// @Description Replace the time-stamped lyrics of a song with an .lrc file, sent either as the raw request body or as the "file" field of a multipart form. Timestamps must not go backwards. The song moves to a new version
+// @Tags lyrics
+// @Accept plain,mpfd
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param If-Match header string false "ETag of the song; required unless REQUIRE_IF_MATCH=false"
+// @Param file formData file false "LRC file"
+// @Success 200 {object} models.SyncedLyricsResponse "Imported lines"
+// @Failure 400 {object} models.ErrorResponse "Invalid id or LRC file"
+// @Failure 404 {object} models.ErrorResponse "Song not found"
+// @Failure 412 {object} models.SongResponse "Song changed since the given ETag; the body is the current song"
+// @Failure 428 {object} models.ErrorResponse "If-Match header required"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to save synchronized lyrics"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /songs/{id}/lyrics/sync [put]
+func (h *SongHandler) UploadLRC(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	data, err := readLRC(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid LRC file"})
+		return
+	}
+
+	version, ok := h.ifMatchVersion(c, id)
+	if !ok {
+		return
+	}
+
+	lines, err := h.service.ImportLRC(id, version, data)
+	switch {
+	case errors.Is(err, models.ErrInvalidLRC):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	case errors.Is(err, repositories.ErrSongNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Song not found"})
+		return
+	case errors.Is(err, repositories.ErrVersionConflict):
+		h.respondConflict(c, id)
+		return
+	case err != nil:
+		h.log.Errorf("Failed to save synchronized lyrics: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to save synchronized lyrics"})
+		return
+	}
+
+	h.log.WithFields(logrus.Fields{
+		"id":    id,
+		"lines": len(lines),
+	}).Info("Synchronized lyrics imported")
+
+	c.JSON(http.StatusOK, models.SyncedLyricsResponse{Lines: lines})
+}
+
+// GetSyncedLyrics
+// @Summary Get synchronized lyrics
+// @Description Get the time-stamped lines of a song in playback order
+// @Tags lyrics
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {object} models.SyncedLyricsResponse "Time-stamped lines"
+// @Failure 400 {object} models.ErrorResponse "Invalid id"
+// @Failure 404 {object} models.ErrorResponse "Song not found"
+// @Failure 500 {object} models.ErrorResponse "Failed to get synchronized lyrics"
+// @Router /songs/{id}/lyrics/sync [get]
+func (h *SongHandler) GetSyncedLyrics(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	lines, err := h.service.GetSyncedLyrics(id)
+	if errors.Is(err, repositories.ErrSongNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Song not found"})
+		return
+	}
+	if err != nil {
+		h.log.Errorf("Failed to get synchronized lyrics: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get synchronized lyrics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SyncedLyricsResponse{Lines: lines})
+}
+
+// GetActiveLine
+// @Summary Get the line active at a playback offset
+// @Description Get the synchronized lyrics line shown at the given playback position
+// @Tags lyrics
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param at query string true "Playback offset in seconds (83.5) or as mm:ss.xx (01:23.50)"
+// @Success 200 {object} models.SyncedLine "Active line"
+// @Failure 400 {object} models.ErrorResponse "Invalid id or offset"
+// @Failure 404 {object} models.ErrorResponse "Song not found or no line is active yet"
+// @Failure 500 {object} models.ErrorResponse "Failed to get active line"
+// @Router /songs/{id}/lyrics/sync/active [get]
+func (h *SongHandler) GetActiveLine(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	offset, err := models.ParsePlaybackOffset(c.Query("at"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	line, err := h.service.GetSyncedLineAt(id, offset)
+	switch {
+	case errors.Is(err, repositories.ErrSongNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Song not found"})
+		return
+	case errors.Is(err, repositories.ErrNoActiveLine):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "No line is active at this offset"})
+		return
+	case err != nil:
+		h.log.Errorf("Failed to get active line: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get active line"})
+		return
+	}
+
+	c.JSON(http.StatusOK, line)
+}
+
+// getLRC serves GET /songs/:id/lyrics?format=lrc.
+func (h *SongHandler) getLRC(c *gin.Context, id int) {
+	song, err := h.service.GetSong(id)
+	if errors.Is(err, repositories.ErrSongNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Song not found"})
+		return
+	}
+	if err != nil {
+		h.log.Errorf("Failed to get lyrics: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get lyrics"})
+		return
+	}
+	setLastModified(c, song.UpdatedAt)
+
+	lines, err := h.service.GetSyncedLyrics(id)
+	if err != nil {
+		h.log.Errorf("Failed to get synchronized lyrics: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get lyrics"})
+		return
+	}
+	if len(lines) == 0 {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Song has no synchronized lyrics"})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+strconv.Itoa(id)+`.lrc"`)
+	c.Data(http.StatusOK, "application/x-lrc; charset=utf-8", []byte(models.FormatLRC(song, lines)))
+}
+
+func readLRC(c *gin.Context) (string, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxLRCSize)
+
+	if c.ContentType() == "multipart/form-data" {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return "", err
+		}
+		file, err := header.Open()
+		if err != nil {
+			return "", err
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(io.LimitReader(file, maxLRCSize))
+		return string(data), err
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	return string(data), err
+}
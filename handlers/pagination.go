@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"case/models"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// paginate builds the pagination metadata for a list response and mirrors it
+// in the X-Total-Count and Link headers.
+func paginate(c *gin.Context, total, page, limit int) models.Pagination {
+	pages := (total + limit - 1) / limit
+
+	p := models.Pagination{
+		Total: total,
+		Page:  page,
+		Limit: limit,
+		Pages: pages,
+	}
+	if page < pages {
+		p.Next = pageURL(c, page+1)
+	}
+	if page > 1 {
+		p.Prev = pageURL(c, min(page-1, max(pages, 1)))
+	}
+
+	links := []string{fmt.Sprintf(`<%s>; rel="first"`, pageURL(c, 1))}
+	if p.Prev != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, p.Prev))
+	}
+	if p.Next != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, p.Next))
+	}
+	links = append(links, fmt.Sprintf(`<%s>; rel="last"`, pageURL(c, max(pages, 1))))
+
+	c.Header("X-Total-Count", strconv.Itoa(total))
+	c.Header("Link", strings.Join(links, ", "))
+
+	return p
+}
+
+// pageURL returns the current request path and query with page replaced.
+func pageURL(c *gin.Context, page int) string {
+	u := *c.Request.URL
+	q := u.Query()
+	q.Set("page", strconv.Itoa(page))
+	u.RawQuery = q.Encode()
+	return u.RequestURI()
+}
+
+// cursorURL returns the current request path and query with cursor replaced.
+func cursorURL(c *gin.Context, cursor string) string {
+	u := *c.Request.URL
+	q := u.Query()
+	q.Set("cursor", cursor)
+	u.RawQuery = q.Encode()
+	return u.RequestURI()
+}
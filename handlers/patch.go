@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"case/models"
+	"case/repositories"
+	"case/services"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	mergePatchType = "application/merge-patch+json"
+	jsonPatchType  = "application/json-patch+json"
+
+	maxPatchSize = 1 << 20
+)
+
+// PatchSong
+// @Summary Partially update a song
+// @Description Update only the given fields of a song. With application/merge-patch+json (or application/json) the body is a JSON Merge Patch (RFC 7386): listed fields are replaced and null clears them. With application/json-patch+json it is a JSON Patch (RFC 6902) applied to the song as returned by GET. Changing group without artist_id links the song to the artist of that name
+// @Tags songs
+// @Accept application/merge-patch+json,application/json-patch+json,json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param patch body object true "Merge patch or JSON Patch"
+// @Param date_format query string false "Release date format in the response" Enums(dmy, iso) default(dmy)
+// @Param If-Match header string false "ETag of the song; required unless REQUIRE_IF_MATCH=false"
+// @Success 200 {object} models.SongResponse "Song updated"
+// @Header 200 {string} ETag "ETag of the new version"
+// @Failure 400 {object} models.ErrorResponse "Invalid patch, ID or resulting song"
+// @Failure 404 {object} models.ErrorResponse "Song not found"
+// @Failure 409 {object} models.ErrorResponse "A JSON Patch test operation failed"
+// @Failure 412 {object} models.SongResponse "Song changed since the given ETag; the body is the current song"
+// @Failure 428 {object} models.ErrorResponse "If-Match header required"
+// @Failure 415 {object} models.ErrorResponse "Unsupported patch format"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to update song"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /songs/{id} [patch]
+func (h *SongHandler) PatchSong(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	layout, err := dateLayout(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	var apply models.PatchFunc
+	switch c.ContentType() {
+	case mergePatchType, "application/json":
+		apply = models.MergePatch
+	case jsonPatchType:
+		apply = models.JSONPatch
+	default:
+		c.Header("Accept-Patch", mergePatchType+", "+jsonPatchType)
+		c.JSON(http.StatusUnsupportedMediaType, models.ErrorResponse{
+			Error: "Content-Type must be " + mergePatchType + " or " + jsonPatchType,
+		})
+		return
+	}
+
+	patch, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxPatchSize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid patch"})
+		return
+	}
+
+	version, ok := h.ifMatchVersion(c, id)
+	if !ok {
+		return
+	}
+
+	song, err := h.service.PatchSong(id, patch, apply, version)
+	switch {
+	case errors.Is(err, repositories.ErrSongNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Song not found"})
+		return
+	case errors.Is(err, repositories.ErrVersionConflict):
+		h.respondConflict(c, id)
+		return
+	case errors.Is(err, models.ErrPatchTestFailed):
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: err.Error()})
+		return
+	case errors.Is(err, models.ErrInvalidPatch):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	case errors.Is(err, models.ErrInvalidDate), errors.Is(err, services.ErrInvalidSong),
+		errors.Is(err, services.ErrArtistMismatch):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: invalidSongMessage(err)})
+		return
+	case errors.Is(err, repositories.ErrArtistNotFound):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Unknown artist_id"})
+		return
+	case err != nil:
+		h.log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error("Failed to update song")
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to update song"})
+		return
+	}
+
+	c.Header("ETag", songETag(song))
+	c.JSON(http.StatusOK, models.ToSongResponse(song, layout))
+}
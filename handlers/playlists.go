@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"case/models"
+	"case/repositories"
+	"case/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type PlaylistHandler struct {
+	service *services.PlaylistService
+	log     *logrus.Logger
+}
+
+func NewPlaylistHandler(service *services.PlaylistService, log *logrus.Logger) *PlaylistHandler {
+	return &PlaylistHandler{service: service, log: log}
+}
+
+// GetPlaylists
+// @Summary Get a list of playlists
+// @Description Get a list of playlists without their entries. Private playlists are listed only to their owner
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param public query bool false "Only public (true) or only private (false) playlists"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Success 200 {object} models.PlaylistListResponse "List of playlists"
+// @Failure 400 {object} models.ErrorResponse "Invalid filter or pagination"
+// @Failure 401 {object} models.ErrorResponse "Invalid or expired token"
+// @Failure 500 {object} models.ErrorResponse "Failed to get playlists"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /playlists [get]
+func (h *PlaylistHandler) GetPlaylists(c *gin.Context) {
+	var public *bool
+	if value := c.Query("public"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "public must be true or false"})
+			return
+		}
+		public = &parsed
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid page number"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid limit number"})
+		return
+	}
+
+	playlists, total, err := h.service.GetPlaylists(public, currentSubject(c), page, limit)
+	if err != nil {
+		h.log.Errorf("Failed to get playlists: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get playlists"})
+		return
+	}
+
+	response := models.PlaylistListResponse{
+		Playlists:  []models.PlaylistResponse{},
+		Pagination: paginate(c, total, page, limit),
+	}
+	for _, playlist := range playlists {
+		response.Playlists = append(response.Playlists, models.ToPlaylistResponse(playlist, nil, models.DateLayout))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetPlaylist
+// @Summary Get a playlist
+// @Description Get a playlist by ID with its entries in order. The same song may appear in several entries. A private playlist is only shown to its owner
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param id path int true "Playlist ID"
+// @Param date_format query string false "Release date format in the response" Enums(dmy, iso) default(dmy)
+// @Success 200 {object} models.PlaylistResponse "Playlist with entries"
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 401 {object} models.ErrorResponse "Private playlist without a token, or an invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Private playlist of another subject"
+// @Failure 404 {object} models.ErrorResponse "Playlist not found"
+// @Failure 500 {object} models.ErrorResponse "Failed to get playlist"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /playlists/{id} [get]
+func (h *PlaylistHandler) GetPlaylist(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	layout, err := dateLayout(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	playlist, entries, err := h.service.GetPlaylist(id, currentSubject(c))
+	if err != nil {
+		h.respondError(c, err, "Failed to get playlist")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ToPlaylistResponse(playlist, entries, layout))
+}
+
+// AddPlaylist
+// @Summary Create a playlist
+// @Description Create an empty playlist owned by the subject of the token
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param playlist body models.Playlist true "Playlist data"
+// @Success 200 {object} models.PlaylistResponse "Playlist created"
+// @Failure 400 {object} models.ErrorResponse "Invalid request body"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to add playlist"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /playlists [post]
+func (h *PlaylistHandler) AddPlaylist(c *gin.Context) {
+	var playlist models.Playlist
+	if err := c.ShouldBindJSON(&playlist); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid playlist"})
+		return
+	}
+	playlist.Owner = currentSubject(c)
+
+	if err := h.service.AddPlaylist(&playlist); err != nil {
+		h.respondError(c, err, "Failed to add playlist")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ToPlaylistResponse(playlist, nil, models.DateLayout))
+}
+
+// UpdatePlaylist
+// @Summary Rename a playlist
+// @Description Change the name and visibility of a playlist. Its entries are left unchanged. Only the owner may change a playlist
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param id path int true "Playlist ID"
+// @Param playlist body models.Playlist true "Updated playlist data"
+// @Success 200 {object} models.PlaylistResponse "Playlist updated"
+// @Failure 400 {object} models.ErrorResponse "Invalid request body or ID"
+// @Failure 404 {object} models.ErrorResponse "Playlist not found"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission or not the owner"
+// @Failure 500 {object} models.ErrorResponse "Failed to update playlist"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /playlists/{id} [put]
+func (h *PlaylistHandler) UpdatePlaylist(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	var playlist models.Playlist
+	if err := c.ShouldBindJSON(&playlist); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid playlist"})
+		return
+	}
+	playlist.ID = id
+
+	if err := h.service.UpdatePlaylist(&playlist, currentSubject(c)); err != nil {
+		h.respondError(c, err, "Failed to update playlist")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ToPlaylistResponse(playlist, nil, models.DateLayout))
+}
+
+// DeletePlaylist
+// @Summary Delete a playlist
+// @Description Delete a playlist by ID. Its songs stay in the catalog. Only the owner may delete a playlist
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param id path int true "Playlist ID"
+// @Success 200 {object} models.MessageResponse "Playlist deleted"
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 404 {object} models.ErrorResponse "Playlist not found"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission or not the owner"
+// @Failure 500 {object} models.ErrorResponse "Failed to delete playlist"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /playlists/{id} [delete]
+func (h *PlaylistHandler) DeletePlaylist(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	if err := h.service.DeletePlaylist(id, currentSubject(c)); err != nil {
+		h.respondError(c, err, "Failed to delete playlist")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MessageResponse{Message: "playlist deleted"})
+}
+
+// AddEntry
+// @Summary Add a song to a playlist
+// @Description Insert a song after the entry after_entry_id (0 for the start); without after_entry_id it is appended. A song may be added several times
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param id path int true "Playlist ID"
+// @Param entry body models.AddPlaylistEntryRequest true "Song and position"
+// @Success 200 {object} models.PlaylistResponse "Playlist with entries"
+// @Failure 400 {object} models.ErrorResponse "Invalid request body or unknown song"
+// @Failure 404 {object} models.ErrorResponse "Playlist or entry after_entry_id not found"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission or not the owner"
+// @Failure 500 {object} models.ErrorResponse "Failed to add song to playlist"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /playlists/{id}/entries [post]
+func (h *PlaylistHandler) AddEntry(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	var req models.AddPlaylistEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid entry"})
+		return
+	}
+
+	if _, err := h.service.AddEntry(id, currentSubject(c), req); err != nil {
+		h.respondError(c, err, "Failed to add song to playlist")
+		return
+	}
+
+	h.respondPlaylist(c, id)
+}
+
+// RemoveEntry
+// @Summary Remove an entry from a playlist
+// @Description Remove one entry from a playlist; other entries of the same song stay
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param id path int true "Playlist ID"
+// @Param entry_id path int true "Entry ID"
+// @Success 200 {object} models.PlaylistResponse "Playlist with entries"
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 404 {object} models.ErrorResponse "Playlist or entry not found"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission or not the owner"
+// @Failure 500 {object} models.ErrorResponse "Failed to remove entry"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /playlists/{id}/entries/{entry_id} [delete]
+func (h *PlaylistHandler) RemoveEntry(c *gin.Context) {
+	id, entryID, ok := entryParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveEntry(id, entryID, currentSubject(c)); err != nil {
+		h.respondError(c, err, "Failed to remove entry")
+		return
+	}
+
+	h.respondPlaylist(c, id)
+}
+
+// MoveEntry
+// @Summary Move an entry within a playlist
+// @Description Move an entry after the entry after_entry_id (0 for the start); without after_entry_id it is moved to the end. Entries are addressed by ID, so concurrent edits do not shift the target
+// @Tags playlists
+// @Accept json
+// @Produce json
+// @Param id path int true "Playlist ID"
+// @Param entry_id path int true "Entry ID"
+// @Param position body models.MovePlaylistEntryRequest true "New position"
+// @Success 200 {object} models.PlaylistResponse "Playlist with entries"
+// @Failure 400 {object} models.ErrorResponse "Invalid request body or ID"
+// @Failure 404 {object} models.ErrorResponse "Playlist or entry not found"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission or not the owner"
+// @Failure 500 {object} models.ErrorResponse "Failed to move entry"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /playlists/{id}/entries/{entry_id}/move [post]
+func (h *PlaylistHandler) MoveEntry(c *gin.Context) {
+	id, entryID, ok := entryParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.MovePlaylistEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid position"})
+		return
+	}
+
+	if err := h.service.MoveEntry(id, entryID, currentSubject(c), req); err != nil {
+		h.respondError(c, err, "Failed to move entry")
+		return
+	}
+
+	h.respondPlaylist(c, id)
+}
+
+func entryParams(c *gin.Context) (int, int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return 0, 0, false
+	}
+
+	entryID, err := strconv.Atoi(c.Param("entry_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid entry_id"})
+		return 0, 0, false
+	}
+
+	return id, entryID, true
+}
+
+// respondPlaylist replies with the playlist and its current entries after an
+// entry change.
+func (h *PlaylistHandler) respondPlaylist(c *gin.Context, id int) {
+	playlist, entries, err := h.service.GetPlaylist(id, currentSubject(c))
+	if err != nil {
+		h.respondError(c, err, "Failed to get playlist")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ToPlaylistResponse(playlist, entries, models.DateLayout))
+}
+
+func (h *PlaylistHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidPlaylist):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid playlist: " + err.Error()})
+	case errors.Is(err, services.ErrPrivatePlaylist):
+		if _, ok := CurrentClaims(c); !ok {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Error:  "Playlist is private",
+				Reason: models.ReasonMissingToken,
+			})
+			return
+		}
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:  "Playlist is private",
+			Reason: models.ReasonNotOwner,
+		})
+	case errors.Is(err, services.ErrNotPlaylistOwner):
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Error:  "Only the owner may change the playlist",
+			Reason: models.ReasonNotOwner,
+		})
+	case errors.Is(err, repositories.ErrSongNotFound):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Unknown song_id"})
+	case errors.Is(err, repositories.ErrPlaylistNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Playlist not found"})
+	case errors.Is(err, repositories.ErrEntryNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Playlist entry not found"})
+	default:
+		h.log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error(message)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: message})
+	}
+}
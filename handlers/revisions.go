@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"case/models"
+	"case/repositories"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// GetSongRevisions
+// @Summary Get the revisions of a song
+// @Description Get the stored versions of a song, newest first. Every add, update and restore appends a revision
+// @Tags revisions
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param date_format query string false "Release date format in the response" Enums(dmy, iso) default(dmy)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Success 200 {object} models.SongRevisionListResponse "Revisions of the song"
+// @Failure 400 {object} models.ErrorResponse "Invalid id or pagination"
+// @Failure 404 {object} models.ErrorResponse "Song not found"
+// @Failure 500 {object} models.ErrorResponse "Failed to get revisions"
+// @Router /songs/{id}/revisions [get]
+func (h *SongHandler) GetSongRevisions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	layout, err := dateLayout(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid page number"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid limit number"})
+		return
+	}
+
+	revisions, total, err := h.service.GetSongRevisions(id, page, limit)
+	if err != nil {
+		h.respondRevisionError(c, err, "Failed to get revisions")
+		return
+	}
+
+	response := models.SongRevisionListResponse{
+		Revisions:  []models.SongRevisionResponse{},
+		Pagination: paginate(c, total, page, limit),
+	}
+	for _, revision := range revisions {
+		response.Revisions = append(response.Revisions, models.ToSongRevisionResponse(revision, layout))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetSongRevision
+// @Summary Get a revision of a song
+// @Description Get a song as it was stored by one revision
+// @Tags revisions
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param rev path int true "Revision number"
+// @Param date_format query string false "Release date format in the response" Enums(dmy, iso) default(dmy)
+// @Success 200 {object} models.SongRevisionResponse "Revision"
+// @Failure 400 {object} models.ErrorResponse "Invalid id or revision"
+// @Failure 404 {object} models.ErrorResponse "Song or revision not found"
+// @Failure 500 {object} models.ErrorResponse "Failed to get revision"
+// @Router /songs/{id}/revisions/{rev} [get]
+func (h *SongHandler) GetSongRevision(c *gin.Context) {
+	id, rev, ok := revisionParams(c)
+	if !ok {
+		return
+	}
+
+	layout, err := dateLayout(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	revision, err := h.service.GetSongRevision(id, rev)
+	if err != nil {
+		h.respondRevisionError(c, err, "Failed to get revision")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ToSongRevisionResponse(revision, layout))
+}
+
+// DiffSongRevisions
+// @Summary Compare two revisions of a song
+// @Description Get a line-level diff of the text between two revisions of a song. Lines are listed in order with op equal, delete (only in from) or insert (only in to). Apart from the lines both texts start and end with, each side may have at most 1000 lines
+// @Tags revisions
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param from query int true "Older revision number"
+// @Param to query int false "Newer revision number; the latest revision if omitted"
+// @Success 200 {object} models.SongRevisionDiffResponse "Line diff"
+// @Failure 400 {object} models.ErrorResponse "Invalid id or revision, or texts differing in more than 1000 lines"
+// @Failure 404 {object} models.ErrorResponse "Song or revision not found"
+// @Failure 500 {object} models.ErrorResponse "Failed to compare revisions"
+// @Router /songs/{id}/revisions/diff [get]
+func (h *SongHandler) DiffSongRevisions(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil || from < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid from revision"})
+		return
+	}
+
+	to := 0
+	if value := c.Query("to"); value != "" {
+		to, err = strconv.Atoi(value)
+		if err != nil || to < 1 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid to revision"})
+			return
+		}
+	}
+
+	lines, to, err := h.service.DiffSongRevisions(id, from, to)
+	if errors.Is(err, models.ErrDiffTooLarge) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Revisions differ too much to compare: " + err.Error()})
+		return
+	}
+	if err != nil {
+		h.respondRevisionError(c, err, "Failed to compare revisions")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SongRevisionDiffResponse{SongID: id, From: from, To: to, Lines: lines})
+}
+
+// RestoreSongRevision
+// @Summary Restore a revision of a song
+// @Description Write a song back to the state of an earlier revision. The restore is stored as a new revision
+// @Tags revisions
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param rev path int true "Revision number"
+// @Param If-Match header string false "ETag of the song; required unless REQUIRE_IF_MATCH=false"
+// @Param date_format query string false "Release date format in the response" Enums(dmy, iso) default(dmy)
+// @Success 200 {object} models.SongResponse "Song restored"
+// @Header 200 {string} ETag "ETag of the new version"
+// @Failure 400 {object} models.ErrorResponse "Invalid id or revision"
+// @Failure 404 {object} models.ErrorResponse "Song or revision not found"
+// @Failure 409 {object} models.ErrorResponse "Artist of the revision no longer exists"
+// @Failure 412 {object} models.SongResponse "Song changed since the given ETag; the body is the current song"
+// @Failure 428 {object} models.ErrorResponse "If-Match header required"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to restore revision"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /songs/{id}/revisions/{rev}/restore [post]
+func (h *SongHandler) RestoreSongRevision(c *gin.Context) {
+	id, rev, ok := revisionParams(c)
+	if !ok {
+		return
+	}
+
+	layout, err := dateLayout(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	version, ok := h.ifMatchVersion(c, id)
+	if !ok {
+		return
+	}
+
+	song, err := h.service.RestoreSongRevision(id, rev, version)
+	if errors.Is(err, repositories.ErrArtistNotFound) {
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Artist of the revision no longer exists"})
+		return
+	}
+	if errors.Is(err, repositories.ErrVersionConflict) {
+		h.respondConflict(c, id)
+		return
+	}
+	if err != nil {
+		h.respondRevisionError(c, err, "Failed to restore revision")
+		return
+	}
+
+	h.log.WithFields(logrus.Fields{
+		"id":       id,
+		"revision": rev,
+	}).Info("Song revision restored")
+
+	c.Header("ETag", songETag(song))
+	c.JSON(http.StatusOK, models.ToSongResponse(song, layout))
+}
+
+// revisionParams parses the song id and revision number of the route,
+// replying with 400 if either is invalid.
+func revisionParams(c *gin.Context) (int, int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return 0, 0, false
+	}
+
+	rev, err := strconv.Atoi(c.Param("rev"))
+	if err != nil || rev < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid revision"})
+		return 0, 0, false
+	}
+
+	return id, rev, true
+}
+
+func (h *SongHandler) respondRevisionError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrSongNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Song not found"})
+	case errors.Is(err, repositories.ErrRevisionNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Revision not found"})
+	default:
+		h.log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error(message)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: message})
+	}
+}
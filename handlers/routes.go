@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"case/models"
+	"case/repositories"
+	"case/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Routes holds the handlers and the authentication services the API is
+// served with. main and the handler tests register the same routes from it.
+type Routes struct {
+	Songs     *SongHandler
+	Artists   *ArtistHandler
+	Albums    *AlbumHandler
+	Tags      *TagHandler
+	Playlists *PlaylistHandler
+	APIKeys   *APIKeyHandler
+
+	// Tokens is nil when authentication is disabled; Authz is then nil too.
+	Tokens *services.TokenService
+	Keys   *services.APIKeyService
+	Authz  *services.AuthzService
+	// Cache is nil when CACHE is off.
+	Cache *repositories.CachedSongStore
+
+	CacheControlSongs  string
+	CacheControlLyrics string
+}
+
+// Register adds every API route to r.
+func (rt Routes) Register(r gin.IRouter, log *logrus.Logger) {
+	auth := RequireAuth(rt.Tokens, rt.Keys, log)
+	optionalAuth := OptionalAuth(rt.Tokens, rt.Keys, log)
+	can := func(permission string) gin.HandlerFunc {
+		return RequirePermission(rt.Authz, permission, log)
+	}
+
+	r.GET("/auth/me", auth, WhoAmI(rt.Authz, log))
+	r.GET("/cache/stats", auth, can(models.PermCacheRead), CacheStats(rt.Cache))
+
+	r.GET("/songs", Conditional(rt.CacheControlSongs), rt.Songs.GetSongs)
+	r.GET("/songs/search", rt.Songs.SearchSongs)
+	r.GET("/songs/export", rt.Songs.ExportSongs)
+	r.GET("/songs/trash", auth, can(models.PermSongsDelete), rt.Songs.GetTrash)
+	r.GET("/songs/:id", rt.Songs.GetSong)
+	r.GET("/songs/:id/lyrics", Conditional(rt.CacheControlLyrics), rt.Songs.GetSongLyrics)
+	r.GET("/songs/:id/lyrics/sync", Conditional(rt.CacheControlLyrics), rt.Songs.GetSyncedLyrics)
+	r.GET("/songs/:id/lyrics/sync/active", rt.Songs.GetActiveLine)
+	r.PUT("/songs/:id/lyrics/sync", auth, can(models.PermSongsWrite), rt.Songs.UploadLRC)
+	r.GET("/songs/:id/tags", rt.Tags.GetSongTags)
+	r.PUT("/songs/:id/tags", auth, can(models.PermSongsWrite), rt.Tags.SetSongTags)
+	r.DELETE("/songs/:id", auth, can(models.PermSongsDelete), rt.Songs.DeleteSong)
+	r.POST("/songs/:id/restore", auth, can(models.PermSongsDelete), rt.Songs.RestoreSong)
+	r.PUT("/songs/:id", auth, can(models.PermSongsWrite), rt.Songs.UpdateSong)
+	r.PATCH("/songs/:id", auth, can(models.PermSongsWrite), rt.Songs.PatchSong)
+	r.GET("/songs/:id/revisions", rt.Songs.GetSongRevisions)
+	r.GET("/songs/:id/revisions/diff", rt.Songs.DiffSongRevisions)
+	r.GET("/songs/:id/revisions/:rev", rt.Songs.GetSongRevision)
+	r.POST("/songs/:id/revisions/:rev/restore", auth, can(models.PermSongsWrite), rt.Songs.RestoreSongRevision)
+	r.POST("/songs", auth, can(models.PermSongsWrite), rt.Songs.AddSong)
+	r.POST("/songs/import", auth, can(models.PermSongsWrite), rt.Songs.ImportSongs)
+
+	r.GET("/artists", rt.Artists.GetArtists)
+	r.GET("/artists/:id", rt.Artists.GetArtist)
+	r.POST("/artists", auth, can(models.PermCatalogWrite), rt.Artists.AddArtist)
+	r.PUT("/artists/:id", auth, can(models.PermCatalogWrite), rt.Artists.UpdateArtist)
+	r.DELETE("/artists/:id", auth, can(models.PermCatalogDelete), rt.Artists.DeleteArtist)
+
+	r.GET("/albums", rt.Albums.GetAlbums)
+	r.GET("/albums/:id", rt.Albums.GetAlbum)
+	r.POST("/albums", auth, can(models.PermCatalogWrite), rt.Albums.AddAlbum)
+	r.PUT("/albums/:id", auth, can(models.PermCatalogWrite), rt.Albums.UpdateAlbum)
+	r.DELETE("/albums/:id", auth, can(models.PermCatalogDelete), rt.Albums.DeleteAlbum)
+	r.POST("/albums/:id/tracks", auth, can(models.PermCatalogWrite), rt.Albums.AddTrack)
+	r.PUT("/albums/:id/tracks", auth, can(models.PermCatalogWrite), rt.Albums.ReorderTracks)
+	r.DELETE("/albums/:id/tracks/:song_id", auth, can(models.PermCatalogWrite), rt.Albums.RemoveTrack)
+
+	r.GET("/tags", rt.Tags.GetTags)
+	r.GET("/tags/:id", rt.Tags.GetTag)
+	r.POST("/tags", auth, can(models.PermCatalogWrite), rt.Tags.AddTag)
+	r.PUT("/tags/:id", auth, can(models.PermCatalogWrite), rt.Tags.UpdateTag)
+	r.DELETE("/tags/:id", auth, can(models.PermCatalogDelete), rt.Tags.DeleteTag)
+
+	r.GET("/playlists", optionalAuth, rt.Playlists.GetPlaylists)
+	r.GET("/playlists/:id", optionalAuth, rt.Playlists.GetPlaylist)
+	r.POST("/playlists", auth, can(models.PermPlaylistsWrite), rt.Playlists.AddPlaylist)
+	r.PUT("/playlists/:id", auth, can(models.PermPlaylistsWrite), rt.Playlists.UpdatePlaylist)
+	r.DELETE("/playlists/:id", auth, can(models.PermPlaylistsWrite), rt.Playlists.DeletePlaylist)
+	r.POST("/playlists/:id/entries", auth, can(models.PermPlaylistsWrite), rt.Playlists.AddEntry)
+	r.DELETE("/playlists/:id/entries/:entry_id", auth, can(models.PermPlaylistsWrite), rt.Playlists.RemoveEntry)
+	r.POST("/playlists/:id/entries/:entry_id/move", auth, can(models.PermPlaylistsWrite), rt.Playlists.MoveEntry)
+
+	r.GET("/apikeys", auth, can(models.PermAPIKeysManage), rt.APIKeys.GetAPIKeys)
+	r.POST("/apikeys", auth, can(models.PermAPIKeysManage), rt.APIKeys.AddAPIKey)
+	r.POST("/apikeys/:id/rotate", auth, can(models.PermAPIKeysManage), rt.APIKeys.RotateAPIKey)
+	r.DELETE("/apikeys/:id", auth, can(models.PermAPIKeysManage), rt.APIKeys.RevokeAPIKey)
+}
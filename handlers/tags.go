@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"case/models"
+	"case/repositories"
+	"case/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type TagHandler struct {
+	service *services.TagService
+	log     *logrus.Logger
+}
+
+func NewTagHandler(service *services.TagService, log *logrus.Logger) *TagHandler {
+	return &TagHandler{service: service, log: log}
+}
+
+// GetTags
+// @Summary Get a list of tags
+// @Description Get a list of genres, moods and free-form tags ordered by name
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param kind query string false "Filter by kind" Enums(genre, mood, tag)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Success 200 {object} models.TagListResponse "List of tags"
+// @Failure 400 {object} models.ErrorResponse "Invalid kind or pagination"
+// @Failure 500 {object} models.ErrorResponse "Failed to get tags"
+// @Router /tags [get]
+func (h *TagHandler) GetTags(c *gin.Context) {
+	kind := models.TagKind(c.Query("kind"))
+	if kind != "" && !kind.Valid() {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "kind must be genre, mood or tag"})
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid page number"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid limit number"})
+		return
+	}
+
+	tags, total, err := h.service.GetTags(kind, page, limit)
+	if err != nil {
+		h.log.Errorf("Failed to get tags: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get tags"})
+		return
+	}
+	if tags == nil {
+		tags = []models.Tag{}
+	}
+
+	c.JSON(http.StatusOK, models.TagListResponse{
+		Tags:       tags,
+		Pagination: paginate(c, total, page, limit),
+	})
+}
+
+// GetTag
+// @Summary Get a tag
+// @Description Get a tag by ID
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "Tag ID"
+// @Success 200 {object} models.Tag "Tag"
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 404 {object} models.ErrorResponse "Tag not found"
+// @Failure 500 {object} models.ErrorResponse "Failed to get tag"
+// @Router /tags/{id} [get]
+func (h *TagHandler) GetTag(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	tag, err := h.service.GetTag(id)
+	if err != nil {
+		h.respondError(c, err, "Failed to get tag")
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// AddTag
+// @Summary Add a new tag
+// @Description Add a new genre, mood or free-form tag. Names are stored in lower case and must be unique
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param tag body models.Tag true "Tag data"
+// @Success 200 {object} models.Tag "Tag added"
+// @Failure 400 {object} models.ErrorResponse "Invalid request body"
+// @Failure 409 {object} models.ErrorResponse "Tag already exists"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to add tag"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /tags [post]
+func (h *TagHandler) AddTag(c *gin.Context) {
+	var tag models.Tag
+	if err := c.ShouldBindJSON(&tag); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid tag"})
+		return
+	}
+
+	if err := h.service.AddTag(&tag); err != nil {
+		h.respondError(c, err, "Failed to add tag")
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// UpdateTag
+// @Summary Update a tag
+// @Description Rename a tag or change its kind
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "Tag ID"
+// @Param tag body models.Tag true "Updated tag data"
+// @Success 200 {object} models.Tag "Tag updated"
+// @Failure 400 {object} models.ErrorResponse "Invalid request body or ID"
+// @Failure 404 {object} models.ErrorResponse "Tag not found"
+// @Failure 409 {object} models.ErrorResponse "Tag already exists"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to update tag"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /tags/{id} [put]
+func (h *TagHandler) UpdateTag(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	var tag models.Tag
+	if err := c.ShouldBindJSON(&tag); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid tag"})
+		return
+	}
+	tag.ID = id
+
+	if err := h.service.UpdateTag(&tag); err != nil {
+		h.respondError(c, err, "Failed to update tag")
+		return
+	}
+
+	c.JSON(http.StatusOK, tag)
+}
+
+// DeleteTag
+// @Summary Delete a tag
+// @Description Delete a tag by ID and remove it from all songs
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "Tag ID"
+// @Success 200 {object} models.MessageResponse "Tag deleted"
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 404 {object} models.ErrorResponse "Tag not found"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to delete tag"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /tags/{id} [delete]
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	if err := h.service.DeleteTag(id); err != nil {
+		h.respondError(c, err, "Failed to delete tag")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.MessageResponse{Message: "tag deleted"})
+}
+
+// GetSongTags
+// @Summary Get the tags of a song
+// @Description Get the genres, moods and tags of a song ordered by name
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {object} models.SongTagsResponse "Tags of the song"
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 404 {object} models.ErrorResponse "Song not found"
+// @Failure 500 {object} models.ErrorResponse "Failed to get song tags"
+// @Router /songs/{id}/tags [get]
+func (h *TagHandler) GetSongTags(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	tags, err := h.service.GetSongTags(id)
+	if err != nil {
+		h.respondError(c, err, "Failed to get song tags")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SongTagsResponse{SongID: id, Tags: tags})
+}
+
+// SetSongTags
+// @Summary Replace the tags of a song
+// @Description Replace the tags of a song by name. Names that are not tags yet are created with kind "tag"
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param tags body models.SongTagsRequest true "Tag names"
+// @Success 200 {object} models.SongTagsResponse "Tags of the song"
+// @Failure 400 {object} models.ErrorResponse "Invalid request body or ID"
+// @Failure 404 {object} models.ErrorResponse "Song not found"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to set song tags"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /songs/{id}/tags [put]
+func (h *TagHandler) SetSongTags(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	var req models.SongTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid tags"})
+		return
+	}
+
+	tags, err := h.service.SetSongTags(id, req.Tags)
+	if err != nil {
+		h.respondError(c, err, "Failed to set song tags")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.SongTagsResponse{SongID: id, Tags: tags})
+}
+
+func (h *TagHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidTag):
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+	case errors.Is(err, repositories.ErrTagNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Tag not found"})
+	case errors.Is(err, repositories.ErrSongNotFound):
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Song not found"})
+	case errors.Is(err, repositories.ErrTagExists):
+		c.JSON(http.StatusConflict, models.ErrorResponse{Error: "Tag already exists"})
+	default:
+		h.log.WithFields(logrus.Fields{
+			"error": err,
+		}).Error(message)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: message})
+	}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"case/models"
+	"case/repositories"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// GetTrash
+// @Summary Get the trash
+// @Description Get deleted songs that have not been purged yet, most recently deleted first
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param date_format query string false "Release date format in the response" Enums(dmy, iso) default(dmy)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Number of items per page" default(10)
+// @Success 200 {object} models.TrashListResponse "Trashed songs"
+// @Failure 400 {object} models.ErrorResponse "Invalid pagination"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to get trash"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /songs/trash [get]
+func (h *SongHandler) GetTrash(c *gin.Context) {
+	layout, err := dateLayout(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid page number"})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid limit number"})
+		return
+	}
+
+	songs, total, err := h.service.GetTrashedSongs(page, limit)
+	if err != nil {
+		h.log.Errorf("Failed to get trash: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to get trash"})
+		return
+	}
+
+	response := models.TrashListResponse{
+		Songs:      []models.TrashedSongResponse{},
+		Pagination: paginate(c, total, page, limit),
+	}
+	for _, song := range songs {
+		response.Songs = append(response.Songs, models.ToTrashedSongResponse(song, layout))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// RestoreSong
+// @Summary Restore a song from the trash
+// @Description Move a deleted song back out of the trash together with its lyrics, tags, revisions, album tracks and playlist entries
+// @Tags songs
+// @Accept json
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param date_format query string false "Release date format in the response" Enums(dmy, iso) default(dmy)
+// @Success 200 {object} models.SongResponse "Song restored"
+// @Header 200 {string} ETag "ETag of the new version"
+// @Failure 400 {object} models.ErrorResponse "Invalid ID"
+// @Failure 404 {object} models.ErrorResponse "Song not in the trash"
+// @Failure 401 {object} models.ErrorResponse "Missing, invalid or expired token"
+// @Failure 403 {object} models.ErrorResponse "Missing permission"
+// @Failure 500 {object} models.ErrorResponse "Failed to restore song"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /songs/{id}/restore [post]
+func (h *SongHandler) RestoreSong(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid id"})
+		return
+	}
+
+	layout, err := dateLayout(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	song, err := h.service.RestoreSong(id)
+	if errors.Is(err, repositories.ErrSongNotFound) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Song not in the trash"})
+		return
+	}
+	if err != nil {
+		h.log.Errorf("Failed to restore song: %v", err)
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Failed to restore song"})
+		return
+	}
+
+	h.log.WithFields(logrus.Fields{
+		"id": id,
+	}).Info("Song restored from trash")
+
+	c.Header("ETag", songETag(song))
+	c.JSON(http.StatusOK, models.ToSongResponse(song, layout))
+}
@@ -1,14 +1,25 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"flag"
+	"fmt"
 	swaggerFiles "github.com/swaggo/files"
 	_ "log"
+	"net"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
+	"case/cache"
 	"case/config"
 	_ "case/docs"
 	"case/handlers"
+	"case/migrations"
+	"case/models"
 	"case/repositories"
 	"case/services"
 	"github.com/gin-gonic/gin"
@@ -19,6 +30,15 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT sent as "Bearer <token>". Required for every POST, PUT and DELETE request
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key for service clients, accepted instead of a bearer token and limited to its scopes
 func main() {
 	cfg := config.LoadConfig()
 
@@ -27,27 +47,116 @@ func main() {
 	log.SetLevel(logrus.DebugLevel)
 	log.SetFormatter(&logrus.JSONFormatter{})
 
-	db, err := sql.Open("postgres", cfg.DatabaseURL)
-	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(cfg, log, os.Args[2:])
+		return
 	}
-	defer func(db *sql.DB) {
-		err := db.Close()
-		if err != nil {
-			log.Fatal("Failed to close database connection:", err)
+	if len(os.Args) > 1 && os.Args[1] == "roles" {
+		runRoles(cfg, log, os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		runImport(cfg, log, os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "cache-server" {
+		runCacheServer(cfg, log, os.Args[2:])
+		return
+	}
+
+	tokens := newTokenService(cfg, log)
+	if len(os.Args) > 1 && os.Args[1] == "token" {
+		runToken(tokens, log, os.Args[2:])
+		return
+	}
+
+	var repo repositories.SongStore
+	var artistRepo repositories.ArtistStore
+	var albumRepo repositories.AlbumStore
+	var tagRepo repositories.TagStore
+	var playlistRepo repositories.PlaylistStore
+	var roleRepo repositories.RoleStore
+	var apiKeyRepo repositories.APIKeyStore
+	switch cfg.Storage {
+	case "memory":
+		songs := repositories.NewMemorySongRepository(log)
+		repo = songs
+		artists := repositories.NewMemoryArtistRepository(songs, log)
+		artistRepo = artists
+		albumRepo = repositories.NewMemoryAlbumRepository(songs, artists, log)
+		tagRepo = repositories.NewMemoryTagRepository(songs, log)
+		playlistRepo = repositories.NewMemoryPlaylistRepository(songs, log)
+		roleRepo = repositories.NewMemoryRoleRepository(log)
+		apiKeyRepo = repositories.NewMemoryAPIKeyRepository(log)
+		log.Info("Using in-memory storage")
+	case "postgres":
+		db := openDatabase(cfg, log)
+		defer closeDatabase(db, log)
+
+		if cfg.MigrateOnStart {
+			migrator, err := migrations.NewMigrator(db, log)
+			if err != nil {
+				log.Fatal("Failed to load migrations:", err)
+			}
+			if err := migrator.Up(); err != nil {
+				log.Fatal("Failed to apply migrations:", err)
+			}
 		}
-	}(db)
 
-	if err := db.Ping(); err != nil {
-		log.Fatal("Failed to ping database:", err)
+		repo = repositories.NewSongRepository(db, log)
+		artistRepo = repositories.NewArtistRepository(db, log)
+		albumRepo = repositories.NewAlbumRepository(db, log)
+		tagRepo = repositories.NewTagRepository(db, log)
+		playlistRepo = repositories.NewPlaylistRepository(db, log)
+		roleRepo = repositories.NewRoleRepository(db, log)
+		apiKeyRepo = repositories.NewAPIKeyRepository(db, log)
+	default:
+		log.Fatalf("Unknown STORAGE %q, expected postgres or memory", cfg.Storage)
 	}
-	log.Info("Successfully connected to the database")
 
-	repo := repositories.NewSongRepository(db, log)
+	cachedRepo := newSongCache(cfg, repo, log)
+	if cachedRepo != nil {
+		repo = cachedRepo
+	}
 
-	service := services.NewSongService(repo, cfg.ApiUrl)
+	info := services.NewInfoClient(cfg.ApiUrl, cfg.ApiTimeout, cfg.ApiRetries, log)
 
-	handler := handlers.NewSongHandler(service, log)
+	service := services.NewSongService(repo, artistRepo, tagRepo, info)
+	artistService := services.NewArtistService(artistRepo)
+	albumService := services.NewAlbumService(albumRepo, artistRepo)
+	tagService := services.NewTagService(tagRepo)
+	playlistService := services.NewPlaylistService(playlistRepo)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo)
+
+	// A TRASH_RETENTION of 0 keeps trashed songs forever.
+	if cfg.TrashRetention > 0 {
+		if cfg.TrashPurgeInterval <= 0 {
+			log.Fatal("TRASH_PURGE_INTERVAL must be positive")
+		}
+		go service.RunTrashPurge(context.Background(), cfg.TrashRetention, cfg.TrashPurgeInterval, log)
+	}
+
+	var authz *services.AuthzService
+	if tokens != nil {
+		authz = services.NewAuthzService(roleRepo, cfg.DefaultRole)
+	}
+
+	routes := handlers.Routes{
+		Songs:     handlers.NewSongHandler(service, cfg.RequireIfMatch, log),
+		Artists:   handlers.NewArtistHandler(artistService, log),
+		Albums:    handlers.NewAlbumHandler(albumService, log),
+		Tags:      handlers.NewTagHandler(tagService, log),
+		Playlists: handlers.NewPlaylistHandler(playlistService, log),
+		APIKeys:   handlers.NewAPIKeyHandler(apiKeyService, log),
+
+		Tokens: tokens,
+		Keys:   apiKeyService,
+		Authz:  authz,
+		Cache:  cachedRepo,
+
+		CacheControlSongs:  cfg.CacheControlSongs,
+		CacheControlLyrics: cfg.CacheControlLyrics,
+	}
 
 	r := gin.Default()
 
@@ -55,13 +164,290 @@ func main() {
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Наши маршруты
-	r.GET("/songs", handler.GetSongs)
-	r.GET("/songs/:id/lyrics", handler.GetSongLyrics)
-	r.DELETE("/songs/:id", handler.DeleteSong)
-	r.PUT("/songs/:id", handler.UpdateSong)
-	r.POST("/songs", handler.AddSong)
+	routes.Register(r, log)
 
 	if err := r.Run(":8080"); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
 }
+
+func openDatabase(cfg *config.Config, log *logrus.Logger) *sql.DB {
+	db, err := sql.Open("postgres", cfg.DatabaseURL)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	if err := db.Ping(); err != nil {
+		log.Fatal("Failed to ping database:", err)
+	}
+	log.Info("Successfully connected to the database")
+
+	return db
+}
+
+func closeDatabase(db *sql.DB, log *logrus.Logger) {
+	err := db.Close()
+	if err != nil {
+		log.Fatal("Failed to close database connection:", err)
+	}
+}
+
+// newSongCache wraps songs with the cache selected by CACHE, or returns nil
+// when caching is off.
+func newSongCache(cfg *config.Config, songs repositories.SongStore, log *logrus.Logger) *repositories.CachedSongStore {
+	var c cache.Cache
+	switch cfg.Cache {
+	case "none":
+		return nil
+	case "lru":
+		c = cache.NewLRU(cfg.CacheSize)
+	case "redis":
+		redis := cache.NewRedis(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisTimeout, 10)
+		if err := redis.Ping(); err != nil {
+			// Lookups fall back to the store until the server is back.
+			log.Warn("Cache server is unavailable: ", err)
+		}
+		c = redis
+	default:
+		log.Fatalf("Unknown CACHE %q, expected none, lru or redis", cfg.Cache)
+	}
+
+	log.Infof("Caching lyrics in %s for %s", cfg.Cache, cfg.CacheTTL)
+	return repositories.NewCachedSongStore(songs, c, cfg.Cache, cfg.CacheTTL, log)
+}
+
+// runCacheServer implements "cache-server [addr]", which serves an
+// in-memory stand-in for Redis, on 127.0.0.1:6379 by default. It keeps CACHE_SIZE
+// keys and requires REDIS_PASSWORD if that is set.
+func runCacheServer(cfg *config.Config, log *logrus.Logger, args []string) {
+	if len(args) > 1 {
+		log.Fatal("Usage: cache-server [addr]")
+	}
+	addr := "127.0.0.1:6379"
+	if len(args) == 1 {
+		addr = args[0]
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatal("Failed to listen: ", err)
+	}
+	log.Infof("Serving cache on %s", listener.Addr())
+	if err := cache.NewServer(cfg.CacheSize, cfg.RedisPassword, log).Serve(listener); err != nil {
+		log.Fatal("Cache server failed: ", err)
+	}
+}
+
+// newTokenService builds the JWT signer/verifier from the config, or returns
+// nil when AUTH_ENABLED is false.
+func newTokenService(cfg *config.Config, log *logrus.Logger) *services.TokenService {
+	if !cfg.AuthEnabled {
+		log.Warn("Authentication is disabled, mutating endpoints are open to everyone")
+		return nil
+	}
+
+	var tokens *services.TokenService
+	var err error
+	switch cfg.JWTAlgorithm {
+	case services.AlgHS256:
+		if cfg.JWTSecret == "" {
+			log.Fatal("JWT_SECRET is not set; generate one with `openssl rand -base64 48` or set AUTH_ENABLED=false")
+		}
+		tokens, err = services.NewHS256TokenService([]byte(cfg.JWTSecret), cfg.JWTIssuer, cfg.JWTTTL)
+	case services.AlgRS256:
+		tokens, err = services.NewRS256TokenService(cfg.JWTPrivateKeyFile, cfg.JWTPublicKeyFile, cfg.JWTIssuer, cfg.JWTTTL)
+	default:
+		log.Fatalf("Unknown JWT_ALGORITHM %q, expected HS256 or RS256", cfg.JWTAlgorithm)
+	}
+	if err != nil {
+		log.Fatal("Failed to set up JWT authentication: ", err)
+	}
+	return tokens
+}
+
+// runToken implements "token <subject>", which prints a signed JWT for the
+// subject.
+func runToken(tokens *services.TokenService, log *logrus.Logger, args []string) {
+	if len(args) != 1 {
+		log.Fatal("Usage: token <subject>")
+	}
+	if tokens == nil {
+		log.Fatal("Authentication is disabled, set AUTH_ENABLED=true to issue tokens")
+	}
+
+	token, expires, err := tokens.Issue(args[0])
+	if err != nil {
+		log.Fatal("Failed to issue token: ", err)
+	}
+	fmt.Println(token)
+	fmt.Fprintf(os.Stderr, "expires %s\n", expires.Format(time.RFC3339))
+}
+
+// runRoles implements "roles list <subject>", "roles grant <subject> <role>"
+// and "roles revoke <subject> <role>" against the database.
+func runRoles(cfg *config.Config, log *logrus.Logger, args []string) {
+	if len(args) < 2 || (args[0] != "list" && len(args) != 3) {
+		log.Fatal("Usage: roles list <subject> | roles grant|revoke <subject> <role>")
+	}
+
+	db := openDatabase(cfg, log)
+	defer closeDatabase(db, log)
+
+	authz := services.NewAuthzService(repositories.NewRoleRepository(db, log), cfg.DefaultRole)
+	subject := args[1]
+
+	switch args[0] {
+	case "list":
+	case "grant":
+		if err := authz.GrantRole(subject, args[2]); err != nil {
+			log.Fatal("Failed to grant role: ", err)
+		}
+	case "revoke":
+		if err := authz.RevokeRole(subject, args[2]); err != nil {
+			log.Fatal("Failed to revoke role: ", err)
+		}
+	default:
+		log.Fatalf("Unknown roles command %q, expected list, grant or revoke", args[0])
+	}
+
+	roles, err := authz.Roles(subject)
+	if err != nil {
+		log.Fatal("Failed to get roles: ", err)
+	}
+	fmt.Printf("%s\t%s\n", subject, strings.Join(roles, ","))
+}
+
+// runImport implements "import [flags] <file>", which imports songs from a
+// CSV or NDJSON file, or from stdin for "-", like POST /songs/import and
+// prints the rows that were not created.
+func runImport(cfg *config.Config, log *logrus.Logger, args []string) {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	format := flags.String("format", "", "input format, csv or ndjson; defaults to the file extension")
+	var mappings stringList
+	flags.Var(&mappings, "map", "column mapping as column:field; repeat for several columns")
+	delimiter := flags.String("delimiter", ",", "CSV field delimiter")
+	batchSize := flags.Int("batch", 500, "rows per transaction")
+	allowDuplicates := flags.Bool("allow-duplicates", false, "import songs whose group and title already exist")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		log.Fatal("Usage: import [-format csv|ndjson] [-map column:field]... [-delimiter ,] [-batch 500] [-allow-duplicates] <file|->")
+	}
+	if cfg.Storage != "postgres" {
+		log.Fatal("import needs STORAGE=postgres")
+	}
+	if *batchSize < 1 {
+		log.Fatal("-batch must be positive")
+	}
+
+	path := flags.Arg(0)
+	if *format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			*format = models.ImportCSV
+		case ".ndjson", ".jsonl":
+			*format = models.ImportNDJSON
+		default:
+			log.Fatal("Cannot tell the format from the file name, pass -format")
+		}
+	}
+	comma, err := models.ParseImportDelimiter(*delimiter)
+	if err != nil {
+		log.Fatal("Invalid -delimiter: ", err)
+	}
+
+	mapping, err := models.ParseImportMapping(mappings)
+	if err != nil {
+		log.Fatal("Invalid mapping: ", err)
+	}
+
+	input := os.Stdin
+	if path != "-" {
+		input, err = os.Open(path)
+		if err != nil {
+			log.Fatal("Failed to open input: ", err)
+		}
+		defer input.Close()
+	}
+
+	reader, err := models.NewSongReader(input, *format, mapping, comma)
+	if err != nil {
+		log.Fatal("Invalid input: ", err)
+	}
+
+	db := openDatabase(cfg, log)
+	defer closeDatabase(db, log)
+
+	service := services.NewSongService(repositories.NewSongRepository(db, log), repositories.NewArtistRepository(db, log),
+		repositories.NewTagRepository(db, log), nil)
+	report, err := service.ImportSongs(reader, *batchSize, *allowDuplicates)
+
+	for _, row := range report.Rows {
+		if row.Status != models.ImportCreated {
+			fmt.Printf("line %d\t%s\t%s\n", row.Line, row.Status, row.Error)
+		}
+	}
+	fmt.Printf("created %d, skipped %d, failed %d\n", report.Created, report.Skipped, report.Failed)
+	if err != nil {
+		log.Fatal("Import stopped: ", err)
+	}
+}
+
+// stringList is a flag that can be repeated.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// runMigrate implements "migrate up", "migrate down [steps]" and "migrate status".
+func runMigrate(cfg *config.Config, log *logrus.Logger, args []string) {
+	if len(args) == 0 {
+		log.Fatal("Usage: migrate up|down [steps]|status")
+	}
+
+	db := openDatabase(cfg, log)
+	defer closeDatabase(db, log)
+
+	migrator, err := migrations.NewMigrator(db, log)
+	if err != nil {
+		log.Fatal("Failed to load migrations:", err)
+	}
+
+	switch args[0] {
+	case "up":
+		if err := migrator.Up(); err != nil {
+			log.Fatal("Failed to apply migrations:", err)
+		}
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				log.Fatalf("Invalid number of steps %q", args[1])
+			}
+		}
+		if err := migrator.Down(steps); err != nil {
+			log.Fatal("Failed to roll back migrations:", err)
+		}
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			log.Fatal("Failed to get migration status:", err)
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = "applied " + status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%06d_%s\t%s\n", status.Version, status.Name, applied)
+		}
+	default:
+		log.Fatalf("Unknown migrate command %q, expected up, down or status", args[0])
+	}
+}
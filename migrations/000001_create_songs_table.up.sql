@@ -1,4 +1,4 @@
-CREATE TABLE songs (
+CREATE TABLE IF NOT EXISTS songs (
     id SERIAL PRIMARY KEY,
     "group" TEXT NOT NULL,
     song TEXT NOT NULL,
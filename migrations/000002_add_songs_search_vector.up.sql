@@ -0,0 +1,8 @@
+ALTER TABLE songs ADD COLUMN search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', "group"), 'A') ||
+        setweight(to_tsvector('simple', song), 'A') ||
+        setweight(to_tsvector('simple', text), 'B')
+    ) STORED;
+
+CREATE INDEX songs_search_vector_idx ON songs USING GIN (search_vector);
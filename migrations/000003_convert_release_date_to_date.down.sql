@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS songs_release_date_idx;
+
+ALTER TABLE songs ALTER COLUMN release_date TYPE TEXT USING COALESCE(to_char(release_date, 'DD.MM.YYYY'), '');
+
+ALTER TABLE songs ALTER COLUMN release_date SET NOT NULL;
@@ -0,0 +1,44 @@
+CREATE FUNCTION pg_temp.parse_release_date(value TEXT) RETURNS DATE AS $$
+BEGIN
+    IF value !~ '^\d{2}\.\d{2}\.\d{4}$' THEN
+        RETURN NULL;
+    END IF;
+    RETURN to_date(value, 'DD.MM.YYYY');
+EXCEPTION WHEN others THEN
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Only empty dates become NULL. Any other value that is not a valid
+-- DD.MM.YYYY date stops the migration instead of being lost; the error lists
+-- the first offending songs so they can be fixed before migrating again.
+DO $$
+DECLARE
+    total INTEGER;
+    sample TEXT;
+BEGIN
+    SELECT COUNT(*) INTO total
+    FROM songs
+    WHERE btrim(release_date) <> '' AND pg_temp.parse_release_date(release_date) IS NULL;
+
+    IF total > 0 THEN
+        SELECT string_agg(format('%s (%L)', id, release_date), ', ' ORDER BY id) INTO sample
+        FROM (
+            SELECT id, release_date
+            FROM songs
+            WHERE btrim(release_date) <> '' AND pg_temp.parse_release_date(release_date) IS NULL
+            ORDER BY id
+            LIMIT 50
+        ) bad;
+
+        RAISE EXCEPTION '% songs have a release_date that is not a valid DD.MM.YYYY date: %', total, sample
+            USING HINT = 'Correct or clear these release dates and run the migrations again.';
+    END IF;
+END;
+$$;
+
+ALTER TABLE songs ALTER COLUMN release_date DROP NOT NULL;
+
+ALTER TABLE songs ALTER COLUMN release_date TYPE DATE USING pg_temp.parse_release_date(release_date);
+
+CREATE INDEX songs_release_date_idx ON songs (release_date, id);
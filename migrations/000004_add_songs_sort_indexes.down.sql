@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS songs_song_id_idx;
+
+DROP INDEX IF EXISTS songs_group_id_idx;
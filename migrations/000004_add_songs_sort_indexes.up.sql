@@ -0,0 +1,3 @@
+CREATE INDEX songs_group_id_idx ON songs ("group", id);
+
+CREATE INDEX songs_song_id_idx ON songs (song, id);
@@ -0,0 +1,9 @@
+CREATE TABLE song_lyric_lines (
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    line_no INTEGER NOT NULL,
+    time_ms INTEGER NOT NULL CHECK (time_ms >= 0),
+    text TEXT NOT NULL,
+    PRIMARY KEY (song_id, line_no)
+);
+
+CREATE INDEX song_lyric_lines_song_time_idx ON song_lyric_lines (song_id, time_ms);
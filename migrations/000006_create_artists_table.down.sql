@@ -0,0 +1,5 @@
+ALTER TABLE songs DROP COLUMN IF EXISTS artist_id;
+
+DROP TABLE IF EXISTS artists;
+
+DROP FUNCTION IF EXISTS normalize_artist_name(TEXT);
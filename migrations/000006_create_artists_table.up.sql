@@ -0,0 +1,36 @@
+CREATE FUNCTION normalize_artist_name(name TEXT) RETURNS TEXT AS $$
+    SELECT regexp_replace(btrim(regexp_replace(lower(name), '\s+', ' ', 'g')), '^the ', '')
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE TABLE artists (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    normalized_name TEXT NOT NULL UNIQUE,
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    country TEXT NOT NULL DEFAULT '',
+    formed_year INTEGER
+);
+
+-- One artist per normalized group name: the most common spelling becomes the
+-- name and the other spellings become aliases.
+INSERT INTO artists (name, normalized_name, aliases)
+SELECT (array_agg(spelling ORDER BY uses DESC, spelling))[1],
+       key,
+       COALESCE((array_agg(spelling ORDER BY uses DESC, spelling))[2:], '{}')
+FROM (
+    SELECT normalize_artist_name("group") AS key, "group" AS spelling, count(*) AS uses
+    FROM songs
+    GROUP BY 1, 2
+) spellings
+GROUP BY key;
+
+ALTER TABLE songs ADD COLUMN artist_id INTEGER REFERENCES artists (id);
+
+UPDATE songs
+SET artist_id = artists.id, "group" = artists.name
+FROM artists
+WHERE artists.normalized_name = normalize_artist_name(songs."group");
+
+ALTER TABLE songs ALTER COLUMN artist_id SET NOT NULL;
+
+CREATE INDEX songs_artist_id_idx ON songs (artist_id);
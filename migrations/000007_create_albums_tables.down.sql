@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS album_tracks;
+
+DROP TABLE IF EXISTS albums;
@@ -0,0 +1,21 @@
+CREATE TABLE albums (
+    id SERIAL PRIMARY KEY,
+    title TEXT NOT NULL,
+    artist_id INTEGER NOT NULL REFERENCES artists (id),
+    release_date DATE,
+    cover_url TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX albums_artist_id_idx ON albums (artist_id);
+
+CREATE TABLE album_tracks (
+    album_id INTEGER NOT NULL REFERENCES albums (id) ON DELETE CASCADE,
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    disc_number INTEGER NOT NULL DEFAULT 1 CHECK (disc_number > 0),
+    track_number INTEGER NOT NULL CHECK (track_number > 0),
+    PRIMARY KEY (album_id, song_id),
+    -- Deferred so tracks can be renumbered in any order within a transaction.
+    UNIQUE (album_id, disc_number, track_number) DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE INDEX album_tracks_song_id_idx ON album_tracks (song_id);
@@ -0,0 +1,14 @@
+CREATE TABLE tags (
+    id SERIAL PRIMARY KEY,
+    -- Stored normalized (lower case, single spaces) so ?tag= lookups are exact.
+    name TEXT NOT NULL UNIQUE,
+    kind TEXT NOT NULL DEFAULT 'tag' CHECK (kind IN ('genre', 'mood', 'tag'))
+);
+
+CREATE TABLE song_tags (
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (song_id, tag_id)
+);
+
+CREATE INDEX song_tags_tag_id_idx ON song_tags (tag_id, song_id);
@@ -0,0 +1,25 @@
+-- owner is the "sub" claim of the JWT that created the playlist; only the
+-- owner sees a private playlist. Playlists created with authentication
+-- disabled have no owner ('') and are open to everyone.
+CREATE TABLE playlists (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    public BOOLEAN NOT NULL DEFAULT FALSE,
+    owner TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX playlists_owner_idx ON playlists (owner);
+
+-- Entries have their own id so a song can appear several times and edits can
+-- refer to an entry instead of its index, which shifts under concurrent edits.
+-- position is a sparse sort key: new entries take a value between their
+-- neighbours and the playlist is renumbered only when the gap runs out.
+CREATE TABLE playlist_entries (
+    id SERIAL PRIMARY KEY,
+    playlist_id INTEGER NOT NULL REFERENCES playlists (id) ON DELETE CASCADE,
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    position BIGINT NOT NULL,
+    UNIQUE (playlist_id, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE INDEX playlist_entries_song_id_idx ON playlist_entries (song_id);
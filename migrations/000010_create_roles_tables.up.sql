@@ -0,0 +1,44 @@
+CREATE TABLE roles (
+    name TEXT PRIMARY KEY
+);
+
+CREATE TABLE permissions (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE role_permissions (
+    role TEXT NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    permission TEXT NOT NULL REFERENCES permissions (name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+-- subject is the "sub" claim of the JWT. Subjects without a row get the
+-- default role from the DEFAULT_ROLE setting.
+CREATE TABLE user_roles (
+    subject TEXT NOT NULL,
+    role TEXT NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    PRIMARY KEY (subject, role)
+);
+
+INSERT INTO roles (name) VALUES ('reader'), ('editor'), ('moderator');
+
+INSERT INTO permissions (name, description) VALUES
+    ('playlists:write', 'Create, edit and delete playlists'),
+    ('songs:write', 'Add and update songs, lyrics and song tags'),
+    ('songs:delete', 'Delete songs'),
+    ('catalog:write', 'Add and update artists, albums and tags'),
+    ('catalog:delete', 'Delete artists, albums and tags');
+
+-- playlists:write only lets a subject change the playlists it owns, so
+-- readers keep their own playlists without touching anyone else's.
+INSERT INTO role_permissions (role, permission) VALUES
+    ('reader', 'playlists:write'),
+    ('editor', 'playlists:write'),
+    ('editor', 'songs:write'),
+    ('editor', 'catalog:write'),
+    ('moderator', 'playlists:write'),
+    ('moderator', 'songs:write'),
+    ('moderator', 'catalog:write'),
+    ('moderator', 'songs:delete'),
+    ('moderator', 'catalog:delete');
@@ -0,0 +1,4 @@
+DELETE FROM roles WHERE name = 'admin';
+DELETE FROM permissions WHERE name = 'apikeys:manage';
+
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,21 @@
+CREATE TABLE api_keys (
+    id SERIAL PRIMARY KEY,
+    name TEXT NOT NULL,
+    -- prefix is the public part of the key used to look it up; only the
+    -- SHA-256 of the whole key is stored.
+    prefix TEXT NOT NULL UNIQUE,
+    key_hash TEXT NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+INSERT INTO roles (name) VALUES ('admin');
+
+INSERT INTO permissions (name, description) VALUES
+    ('apikeys:manage', 'Create, rotate and revoke API keys');
+
+INSERT INTO role_permissions (role, permission)
+SELECT 'admin', name FROM permissions;
@@ -0,0 +1,19 @@
+-- Every write to a song appends a full snapshot of the new state, so any
+-- earlier version can be compared with or restored. Rows are never updated.
+-- artist_id has no foreign key: history must not keep artists from being
+-- deleted.
+CREATE TABLE song_revisions (
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    revision INTEGER NOT NULL,
+    "group" TEXT NOT NULL,
+    song TEXT NOT NULL,
+    release_date DATE,
+    text TEXT NOT NULL,
+    link TEXT NOT NULL,
+    artist_id INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (song_id, revision)
+);
+
+INSERT INTO song_revisions (song_id, revision, "group", song, release_date, text, link, artist_id)
+SELECT id, 1, "group", song, release_date, text, link, artist_id FROM songs;
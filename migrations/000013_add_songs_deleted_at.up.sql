@@ -0,0 +1,5 @@
+-- Deleted songs stay in the table until the purge job removes them, so they
+-- can be restored together with their lyrics, tags and history.
+ALTER TABLE songs ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX songs_deleted_at_idx ON songs (deleted_at) WHERE deleted_at IS NOT NULL;
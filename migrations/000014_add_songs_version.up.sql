@@ -0,0 +1,2 @@
+-- version is incremented by every write to a song and exposed as its ETag.
+ALTER TABLE songs ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS songs_updated_at_idx;
+
+ALTER TABLE songs DROP COLUMN IF EXISTS updated_at;
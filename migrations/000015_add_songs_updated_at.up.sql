@@ -0,0 +1,5 @@
+-- updated_at is set by every write to a song, including deletes and tag or
+-- synced lyrics changes, and backs the Last-Modified header.
+ALTER TABLE songs ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE INDEX songs_updated_at_idx ON songs (updated_at);
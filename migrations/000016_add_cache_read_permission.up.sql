@@ -0,0 +1,5 @@
+INSERT INTO permissions (name, description) VALUES
+    ('cache:read', 'Read cache statistics');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'cache:read');
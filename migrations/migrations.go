@@ -0,0 +1,265 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// lockKey identifies the advisory lock held while migrating, so replicas
+// starting at the same time apply migrations one after another.
+const lockKey int64 = 0x736f6e6773
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	log        *logrus.Logger
+	migrations []Migration
+}
+
+func NewMigrator(db *sql.DB, log *logrus.Logger) (*Migrator, error) {
+	migrations, err := load()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, log: log, migrations: migrations}, nil
+}
+
+// load reads the embedded NNNNNN_name.up.sql / NNNNNN_name.down.sql pairs.
+func load() ([]Migration, error) {
+	entries, err := files.ReadDir(".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		prefix, title, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %q", name)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q: %w", name, err)
+		}
+
+		body, err := files.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		}
+		if direction == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %06d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Up applies every pending migration in version order.
+func (m *Migrator) Up() error {
+	return m.withLock(func(ctx context.Context, conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			m.log.WithFields(logrus.Fields{
+				"version": migration.Version,
+				"name":    migration.Name,
+			}).Info("Applying migration")
+
+			err := m.inTx(ctx, conn, migration.Up,
+				`INSERT INTO schema_versions (version, name) VALUES ($1, $2)`, migration.Version, migration.Name)
+			if err != nil {
+				return fmt.Errorf("migration %06d_%s: %w", migration.Version, migration.Name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Down rolls back the last steps applied migrations.
+func (m *Migrator) Down(steps int) error {
+	return m.withLock(func(ctx context.Context, conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %06d_%s has no down script", migration.Version, migration.Name)
+			}
+
+			m.log.WithFields(logrus.Fields{
+				"version": migration.Version,
+				"name":    migration.Name,
+			}).Info("Rolling back migration")
+
+			err := m.inTx(ctx, conn, migration.Down,
+				`DELETE FROM schema_versions WHERE version = $1`, migration.Version)
+			if err != nil {
+				return fmt.Errorf("migration %06d_%s: %w", migration.Version, migration.Name, err)
+			}
+			steps--
+		}
+		return nil
+	})
+}
+
+// Status reports every known migration together with the time it was applied.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withLock(func(ctx context.Context, conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Migration: migration}
+			if at, ok := applied[migration.Version]; ok {
+				status.AppliedAt = &at
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+func (m *Migrator) withLock(fn func(ctx context.Context, conn *sql.Conn) error) error {
+	ctx := context.Background()
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func(conn *sql.Conn) {
+		err := conn.Close()
+		if err != nil {
+			m.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error closing migration connection")
+		}
+	}(conn)
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, lockKey); err != nil {
+			m.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error releasing migration lock")
+		}
+	}()
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_versions (
+    version BIGINT PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`)
+	if err != nil {
+		return fmt.Errorf("create schema_versions: %w", err)
+	}
+
+	return fn(ctx, conn)
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT version, applied_at FROM schema_versions`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+
+	return applied, rows.Err()
+}
+
+func (m *Migrator) inTx(ctx context.Context, conn *sql.Conn, script, bookkeeping string, args ...interface{}) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,72 @@
+package models
+
+type Album struct {
+	ID          int    `json:"id"`
+	Title       string `json:"title"`
+	ArtistID    int    `json:"artist_id"`
+	ReleaseDate Date   `json:"release_date" swaggertype:"string" example:"26.09.1969"`
+	CoverURL    string `json:"cover_url"`
+}
+
+// AlbumTrack places a song on an album. Track numbers start at 1 on every disc.
+type AlbumTrack struct {
+	DiscNumber  int
+	TrackNumber int
+	Song        Song
+}
+
+// TrackPosition is one entry of a new album track order.
+type TrackPosition struct {
+	SongID     int `json:"song_id"`
+	DiscNumber int `json:"disc_number"`
+}
+
+type AddTrackRequest struct {
+	SongID      int `json:"song_id"`
+	DiscNumber  int `json:"disc_number"`
+	TrackNumber int `json:"track_number"`
+}
+
+type ReorderTracksRequest struct {
+	Tracks []TrackPosition `json:"tracks"`
+}
+
+type AlbumTrackResponse struct {
+	DiscNumber  int          `json:"disc_number"`
+	TrackNumber int          `json:"track_number"`
+	Song        SongResponse `json:"song"`
+}
+
+type AlbumResponse struct {
+	ID          int                  `json:"id"`
+	Title       string               `json:"title"`
+	ArtistID    int                  `json:"artist_id"`
+	ReleaseDate string               `json:"release_date"`
+	CoverURL    string               `json:"cover_url"`
+	Tracks      []AlbumTrackResponse `json:"tracks,omitempty"`
+}
+
+func ToAlbumResponse(album Album, tracks []AlbumTrack, dateLayout string) AlbumResponse {
+	response := AlbumResponse{
+		ID:          album.ID,
+		Title:       album.Title,
+		ArtistID:    album.ArtistID,
+		ReleaseDate: album.ReleaseDate.Format(dateLayout),
+		CoverURL:    album.CoverURL,
+	}
+
+	for _, track := range tracks {
+		response.Tracks = append(response.Tracks, AlbumTrackResponse{
+			DiscNumber:  track.DiscNumber,
+			TrackNumber: track.TrackNumber,
+			Song:        ToSongResponse(track.Song, dateLayout),
+		})
+	}
+
+	return response
+}
+
+type AlbumListResponse struct {
+	Albums []AlbumResponse `json:"albums"`
+	Pagination
+}
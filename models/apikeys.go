@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// APIKey is a credential for non-interactive clients. The key itself is only
+// shown when it is created or rotated; Hash is the SHA-256 of it.
+type APIKey struct {
+	ID         int
+	Name       string
+	Prefix     string
+	Hash       string
+	Scopes     []string
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" example:"nightly-import"`
+	Scopes    []string   `json:"scopes" example:"songs:write"`
+	ExpiresAt *time.Time `json:"expires_at" example:"2030-01-01T00:00:00Z"`
+}
+
+type APIKeyResponse struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// APIKeySecretResponse is returned once when a key is created or rotated.
+type APIKeySecretResponse struct {
+	APIKeyResponse
+	Key string `json:"key" example:"sk_3f9a1c2b_N2Q0ZTQ5..."`
+}
+
+type APIKeyListResponse struct {
+	APIKeys []APIKeyResponse `json:"api_keys"`
+}
+
+func ToAPIKeyResponse(key APIKey) APIKeyResponse {
+	scopes := key.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     scopes,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
@@ -0,0 +1,71 @@
+package models
+
+import "strings"
+
+type Artist struct {
+	ID         int      `json:"id"`
+	Name       string   `json:"name"`
+	Aliases    []string `json:"aliases"`
+	Country    string   `json:"country"`
+	FormedYear *int     `json:"formed_year"`
+}
+
+type ArtistResponse struct {
+	ID         int      `json:"id"`
+	Name       string   `json:"name"`
+	Aliases    []string `json:"aliases"`
+	Country    string   `json:"country"`
+	FormedYear *int     `json:"formed_year"`
+}
+
+func ToArtistResponse(artist Artist) ArtistResponse {
+	aliases := artist.Aliases
+	if aliases == nil {
+		aliases = []string{}
+	}
+	return ArtistResponse{
+		ID:         artist.ID,
+		Name:       artist.Name,
+		Aliases:    aliases,
+		Country:    artist.Country,
+		FormedYear: artist.FormedYear,
+	}
+}
+
+func ToArtistResponseList(artists []Artist) []ArtistResponse {
+	response := []ArtistResponse{}
+
+	for _, artist := range artists {
+		response = append(response, ToArtistResponse(artist))
+	}
+
+	return response
+}
+
+type ArtistListResponse struct {
+	Artists []ArtistResponse `json:"artists"`
+	Pagination
+}
+
+// NormalizeArtistName folds spelling variants such as "The Beatles" and
+// "beatles" into one key. It must stay in sync with the
+// normalize_artist_name SQL function.
+func NormalizeArtistName(name string) string {
+	name = strings.Join(strings.Fields(strings.ToLower(name)), " ")
+	return strings.TrimPrefix(name, "the ")
+}
+
+// HasName reports whether name is the artist's name or one of its aliases
+// up to NormalizeArtistName.
+func (a Artist) HasName(name string) bool {
+	key := NormalizeArtistName(name)
+	if NormalizeArtistName(a.Name) == key {
+		return true
+	}
+	for _, alias := range a.Aliases {
+		if NormalizeArtistName(alias) == key {
+			return true
+		}
+	}
+	return false
+}
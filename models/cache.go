@@ -0,0 +1,11 @@
+package models
+
+// CacheStats counts the lookups of the song cache since startup.
+type CacheStats struct {
+	Backend  string  `json:"backend" example:"lru"`
+	Hits     int64   `json:"hits"`
+	Misses   int64   `json:"misses"`
+	HitRatio float64 `json:"hit_ratio" example:"0.75"`
+	// Errors counts failed cache calls, which fall back to the store.
+	Errors int64 `json:"errors"`
+}
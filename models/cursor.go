@@ -0,0 +1,85 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// SongCursor marks the last song of a keyset page: its sort key and id, plus
+// the ordering the page was produced with. Key is nil when the sort key is
+// NULL (an unknown release date).
+type SongCursor struct {
+	Sort string  `json:"s"`
+	Desc bool    `json:"d,omitempty"`
+	Key  *string `json:"k,omitempty"`
+	ID   int     `json:"i"`
+}
+
+func NewSongCursor(song Song, sort SongSort) SongCursor {
+	cursor := SongCursor{Sort: sort.Field, Desc: sort.Desc, ID: song.ID}
+
+	var key string
+	switch sort.Field {
+	case "id":
+		key = strconv.Itoa(song.ID)
+	case "group":
+		key = song.Group
+	case "song":
+		key = song.Song
+	case "release_date":
+		if song.ReleaseDate.IsZero() {
+			return cursor
+		}
+		key = song.ReleaseDate.Format(ISODateLayout)
+	}
+	cursor.Key = &key
+
+	return cursor
+}
+
+func (c SongCursor) SongSort() SongSort {
+	return SongSort{Field: c.Sort, Desc: c.Desc}
+}
+
+// Encode renders the cursor as an opaque URL-safe token.
+func (c SongCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeSongCursor(token string) (SongCursor, error) {
+	var cursor SongCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, ErrInvalidCursor
+	}
+
+	known := false
+	for _, field := range SortFields {
+		if field == cursor.Sort {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return cursor, ErrInvalidCursor
+	}
+	if cursor.Key == nil && cursor.Sort != "release_date" {
+		return cursor, ErrInvalidCursor
+	}
+	if cursor.Sort == "release_date" && cursor.Key != nil {
+		if _, err := ParseDate(*cursor.Key); err != nil {
+			return cursor, ErrInvalidCursor
+		}
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ISODateLayout is the ISO 8601 calendar date format.
+const ISODateLayout = "2006-01-02"
+
+var ErrInvalidDate = errors.New("date must be in DD.MM.YYYY or YYYY-MM-DD format")
+
+// Date is a calendar date without time of day. It is written as DD.MM.YYYY,
+// accepts ISO 8601 as well, and its zero value stands for an unknown date.
+type Date struct {
+	time.Time
+}
+
+func NewDate(t time.Time) Date {
+	return Date{time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)}
+}
+
+// ParseDate accepts DD.MM.YYYY and YYYY-MM-DD.
+func ParseDate(value string) (Date, error) {
+	for _, layout := range []string{DateLayout, ISODateLayout} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return NewDate(t), nil
+		}
+	}
+	return Date{}, fmt.Errorf("%w: got %q", ErrInvalidDate, value)
+}
+
+// Format renders the date with layout, or an empty string if it is unknown.
+func (d Date) Format(layout string) string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Time.Format(layout)
+}
+
+func (d Date) String() string {
+	return d.Format(DateLayout)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.Format(DateLayout))
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*d = Date{}
+		return nil
+	}
+
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidDate, err)
+	}
+	if value == "" {
+		*d = Date{}
+		return nil
+	}
+
+	parsed, err := ParseDate(value)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func (d *Date) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Date{}
+	case time.Time:
+		*d = NewDate(v)
+	case string:
+		return d.scanString(v)
+	case []byte:
+		return d.scanString(string(v))
+	default:
+		return fmt.Errorf("cannot scan %T into Date", src)
+	}
+	return nil
+}
+
+func (d *Date) scanString(value string) error {
+	t, err := time.Parse(ISODateLayout, value)
+	if err != nil {
+		return err
+	}
+	*d = NewDate(t)
+	return nil
+}
+
+func (d Date) Value() (driver.Value, error) {
+	if d.IsZero() {
+		return nil, nil
+	}
+	return d.Format(ISODateLayout), nil
+}
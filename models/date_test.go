@@ -0,0 +1,75 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"16.07.2009", time.Date(2009, 7, 16, 0, 0, 0, 0, time.UTC), false},
+		{"2009-07-16", time.Date(2009, 7, 16, 0, 0, 0, 0, time.UTC), false},
+		{"29.02.2024", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), false},
+		{"29.02.2023", time.Time{}, true},
+		{"31.04.2020", time.Time{}, true},
+		{"16.7.2009", time.Time{}, true},
+		{"2009/07/16", time.Time{}, true},
+		{"16.07.2009 10:00", time.Time{}, true},
+		{"", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseDate(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidDate) {
+					t.Fatalf("ParseDate(%q) error = %v; want ErrInvalidDate", tt.value, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDate(%q): %v", tt.value, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseDate(%q) = %v; want %v", tt.value, got.Time, tt.want)
+			}
+		})
+	}
+}
+
+func TestDateJSON(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`"16.07.2009"`, `"16.07.2009"`},
+		{`"2009-07-16"`, `"16.07.2009"`},
+		{`""`, `null`},
+		{`null`, `null`},
+	}
+
+	for _, tt := range tests {
+		var date Date
+		if err := json.Unmarshal([]byte(tt.input), &date); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", tt.input, err)
+		}
+		got, err := json.Marshal(date)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("round trip of %s = %s; want %s", tt.input, got, tt.want)
+		}
+	}
+
+	var date Date
+	if err := json.Unmarshal([]byte(`"July 16"`), &date); !errors.Is(err, ErrInvalidDate) {
+		t.Errorf("Unmarshal of an invalid date: %v; want ErrInvalidDate", err)
+	}
+}
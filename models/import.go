@@ -0,0 +1,294 @@
+package models
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	ImportCSV    = "csv"
+	ImportNDJSON = "ndjson"
+)
+
+// ImportStatus is the outcome of one imported row.
+type ImportStatus string
+
+const (
+	ImportCreated ImportStatus = "created"
+	ImportSkipped ImportStatus = "skipped"
+	ImportFailed  ImportStatus = "failed"
+)
+
+// importFields are the song fields an import column can map to.
+var importFields = []string{"group", "song", "release_date", "text", "link", "artist_id"}
+
+// ImportRow reports what happened to the row starting on Line.
+type ImportRow struct {
+	Line   int          `json:"line" example:"2"`
+	Status ImportStatus `json:"status" example:"created"`
+	SongID int          `json:"song_id,omitempty" example:"17"`
+	Song   string       `json:"song,omitempty" example:"Supermassive Black Hole"`
+	Error  string       `json:"error,omitempty"`
+}
+
+type ImportReport struct {
+	Created int         `json:"created"`
+	Skipped int         `json:"skipped"`
+	Failed  int         `json:"failed"`
+	Rows    []ImportRow `json:"rows"`
+	// Error is set when the import stopped early; rows after the last
+	// reported one were not read.
+	Error string `json:"error,omitempty"`
+}
+
+// SongKey identifies a song by group and title, ignoring case, to detect
+// duplicates.
+type SongKey struct {
+	Group string
+	Song  string
+}
+
+func (s Song) Key() SongKey {
+	return SongKey{
+		Group: strings.ToLower(strings.TrimSpace(s.Group)),
+		Song:  strings.ToLower(strings.TrimSpace(s.Song)),
+	}
+}
+
+// ImportRecord is one row of an import stream. Err is set instead of Song
+// when the row cannot be read as a song.
+type ImportRecord struct {
+	Line int
+	Song Song
+	Err  error
+}
+
+// SongReader yields the rows of an import stream; Next returns io.EOF after
+// the last one and other errors only when the stream cannot be read further.
+type SongReader interface {
+	Next() (ImportRecord, error)
+}
+
+// ParseImportMapping parses "column:field" pairs mapping input columns, or
+// NDJSON keys, to song fields. Columns named like a field map to it unless
+// mapped elsewhere; other columns are ignored.
+func ParseImportMapping(specs []string) (map[string]string, error) {
+	mapping := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		i := strings.LastIndex(spec, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("mapping must be column:field. Got %q", spec)
+		}
+		column, field := normalizeColumn(spec[:i]), strings.TrimSpace(spec[i+1:])
+		if !isImportField(field) {
+			return nil, fmt.Errorf("unknown field %q, expected one of %s", field, strings.Join(importFields, ", "))
+		}
+		mapping[column] = field
+	}
+	return mapping, nil
+}
+
+// ParseImportDelimiter parses the CSV field delimiter: a single character
+// that encoding/csv accepts, so not a quote or a line break.
+func ParseImportDelimiter(value string) (rune, error) {
+	delimiter, size := utf8.DecodeRuneInString(value)
+	if size == 0 || size != len(value) || delimiter == utf8.RuneError ||
+		delimiter == '"' || delimiter == '\r' || delimiter == '\n' {
+		return 0, fmt.Errorf("delimiter must be a single character other than a quote or line break. Got %q", value)
+	}
+	return delimiter, nil
+}
+
+// NewSongReader reads songs from r in format, csv or ndjson. CSV input needs
+// a header row; delimiter separates its fields.
+func NewSongReader(r io.Reader, format string, mapping map[string]string, delimiter rune) (SongReader, error) {
+	switch format {
+	case ImportCSV:
+		return newCSVSongReader(r, mapping, delimiter)
+	case ImportNDJSON:
+		return newNDJSONSongReader(r, mapping), nil
+	default:
+		return nil, fmt.Errorf("format must be csv or ndjson. Got %q", format)
+	}
+}
+
+type csvSongReader struct {
+	r *csv.Reader
+	// fields holds the song field of every column, "" for ignored ones.
+	fields []string
+}
+
+func newCSVSongReader(r io.Reader, mapping map[string]string, delimiter rune) (*csvSongReader, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("csv input is empty, expected a header row")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv header: %w", err)
+	}
+
+	fields := make([]string, len(header))
+	seen := make(map[string]string)
+	for i, column := range header {
+		field := columnField(normalizeColumn(column), mapping)
+		if field == "" {
+			continue
+		}
+		if other, ok := seen[field]; ok {
+			return nil, fmt.Errorf("columns %q and %q both map to %s", other, column, field)
+		}
+		seen[field] = column
+		fields[i] = field
+	}
+	if _, ok := seen["song"]; !ok {
+		return nil, errors.New("no column maps to song")
+	}
+
+	return &csvSongReader{r: reader, fields: fields}, nil
+}
+
+func (r *csvSongReader) Next() (ImportRecord, error) {
+	for {
+		row, err := r.r.Read()
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return ImportRecord{Line: parseErr.StartLine, Err: parseErr.Err}, nil
+		}
+		if err != nil {
+			return ImportRecord{}, err
+		}
+
+		line, _ := r.r.FieldPos(0)
+		if len(row) == 1 && strings.TrimSpace(row[0]) == "" {
+			continue
+		}
+		if len(row) != len(r.fields) {
+			return ImportRecord{Line: line, Err: fmt.Errorf("row has %d fields, header has %d", len(row), len(r.fields))}, nil
+		}
+
+		values := make(map[string]string, len(row))
+		for i, value := range row {
+			if r.fields[i] != "" {
+				values[r.fields[i]] = value
+			}
+		}
+		song, err := songFromFields(values)
+		return ImportRecord{Line: line, Song: song, Err: err}, nil
+	}
+}
+
+// maxNDJSONLine bounds a single NDJSON row, lyrics included.
+const maxNDJSONLine = 16 << 20
+
+type ndjsonSongReader struct {
+	scanner *bufio.Scanner
+	mapping map[string]string
+	line    int
+}
+
+func newNDJSONSongReader(r io.Reader, mapping map[string]string) *ndjsonSongReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), maxNDJSONLine)
+	return &ndjsonSongReader{scanner: scanner, mapping: mapping}
+}
+
+func (r *ndjsonSongReader) Next() (ImportRecord, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := strings.TrimSpace(r.scanner.Text())
+		if data == "" {
+			continue
+		}
+
+		var object map[string]interface{}
+		if err := json.Unmarshal([]byte(data), &object); err != nil {
+			return ImportRecord{Line: r.line, Err: fmt.Errorf("invalid JSON: %w", err)}, nil
+		}
+
+		values := make(map[string]string, len(object))
+		for key, value := range object {
+			field := columnField(normalizeColumn(key), r.mapping)
+			if field == "" {
+				continue
+			}
+			switch v := value.(type) {
+			case nil:
+			case string:
+				values[field] = v
+			case float64:
+				values[field] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				return ImportRecord{Line: r.line, Err: fmt.Errorf("%s must be a string or a number", key)}, nil
+			}
+		}
+		song, err := songFromFields(values)
+		return ImportRecord{Line: r.line, Song: song, Err: err}, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return ImportRecord{}, err
+	}
+	return ImportRecord{}, io.EOF
+}
+
+func songFromFields(values map[string]string) (Song, error) {
+	song := Song{
+		Group: strings.TrimSpace(values["group"]),
+		Song:  strings.TrimSpace(values["song"]),
+		Text:  values["text"],
+		Link:  strings.TrimSpace(values["link"]),
+	}
+
+	if value := strings.TrimSpace(values["release_date"]); value != "" {
+		date, err := ParseDate(value)
+		if err != nil {
+			return song, errors.New("release_date " + strings.TrimPrefix(err.Error(), "date "))
+		}
+		song.ReleaseDate = date
+	}
+
+	if value := strings.TrimSpace(values["artist_id"]); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil || id < 1 {
+			return song, fmt.Errorf("artist_id must be a positive integer. Got %q", value)
+		}
+		song.ArtistID = id
+	}
+
+	return song, nil
+}
+
+// columnField returns the song field column maps to, "" if none.
+func columnField(column string, mapping map[string]string) string {
+	if field, ok := mapping[column]; ok {
+		return field
+	}
+	if isImportField(column) {
+		return column
+	}
+	return ""
+}
+
+func normalizeColumn(column string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+}
+
+func isImportField(field string) bool {
+	for _, f := range importFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidLRC = errors.New("invalid LRC")
+
+// SyncedLine is a lyrics line shown from TimeMs milliseconds into the song.
+// Index is 1-based in playback order.
+type SyncedLine struct {
+	Index     int    `json:"index"`
+	TimeMs    int    `json:"time_ms"`
+	Timestamp string `json:"timestamp"`
+	Text      string `json:"text"`
+}
+
+type SyncedLyricsResponse struct {
+	Lines []SyncedLine `json:"lines"`
+}
+
+var (
+	lrcTimeTag = regexp.MustCompile(`^\[(\d{1,3}):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+	lrcMetaTag = regexp.MustCompile(`^\[([a-zA-Z#]+):(.*)\]$`)
+)
+
+// ParseLRC reads the lines of an .lrc file. A line may carry several time
+// tags, in which case it is repeated at each of them; the [offset:] tag
+// shifts every timestamp. Lines must appear in non-decreasing time order.
+func ParseLRC(data string) ([]SyncedLine, error) {
+	var lines []SyncedLine
+	offset := 0
+	last := -1
+
+	for n, raw := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		var times []int
+		rest := raw
+		for {
+			m := lrcTimeTag.FindStringSubmatch(rest)
+			if m == nil {
+				break
+			}
+			ms, err := lrcTagMs(m[1], m[2], m[3])
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidLRC, n+1, err)
+			}
+			times = append(times, ms)
+			rest = rest[len(m[0]):]
+		}
+
+		if len(times) == 0 {
+			if m := lrcMetaTag.FindStringSubmatch(raw); m != nil {
+				if strings.EqualFold(m[1], "offset") {
+					value, err := strconv.Atoi(strings.TrimSpace(m[2]))
+					if err != nil {
+						return nil, fmt.Errorf("%w: line %d: invalid offset %q", ErrInvalidLRC, n+1, m[2])
+					}
+					offset = value
+				}
+				continue
+			}
+			return nil, fmt.Errorf("%w: line %d has no timestamp", ErrInvalidLRC, n+1)
+		}
+
+		if times[0] < last {
+			return nil, fmt.Errorf("%w: line %d: timestamp %s goes back before %s",
+				ErrInvalidLRC, n+1, FormatLRCTime(times[0]), FormatLRCTime(last))
+		}
+		for i := 1; i < len(times); i++ {
+			if times[i] <= times[i-1] {
+				return nil, fmt.Errorf("%w: line %d: repeated timestamps must increase", ErrInvalidLRC, n+1)
+			}
+		}
+		last = times[0]
+
+		text := strings.TrimSpace(rest)
+		for _, ms := range times {
+			lines = append(lines, SyncedLine{TimeMs: ms, Text: text})
+		}
+	}
+
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%w: no timed lines", ErrInvalidLRC)
+	}
+
+	// Repeated lines are expanded in place, so restore playback order.
+	sortSyncedLines(lines)
+	for i := range lines {
+		// A positive offset makes lyrics appear sooner.
+		lines[i].TimeMs = max(lines[i].TimeMs-offset, 0)
+		lines[i].Index = i + 1
+		lines[i].Timestamp = FormatLRCTime(lines[i].TimeMs)
+	}
+
+	return lines, nil
+}
+
+func lrcTagMs(minutes, seconds, fraction string) (int, error) {
+	m, _ := strconv.Atoi(minutes)
+	s, _ := strconv.Atoi(seconds)
+	if s >= 60 {
+		return 0, fmt.Errorf("seconds out of range in [%s:%s]", minutes, seconds)
+	}
+
+	ms := 0
+	if fraction != "" {
+		f, _ := strconv.Atoi(fraction)
+		switch len(fraction) {
+		case 1:
+			ms = f * 100
+		case 2:
+			ms = f * 10
+		default:
+			ms = f
+		}
+	}
+
+	return (m*60+s)*1000 + ms, nil
+}
+
+func sortSyncedLines(lines []SyncedLine) {
+	// Insertion sort keeps equal timestamps in file order.
+	for i := 1; i < len(lines); i++ {
+		for j := i; j > 0 && lines[j].TimeMs < lines[j-1].TimeMs; j-- {
+			lines[j], lines[j-1] = lines[j-1], lines[j]
+		}
+	}
+}
+
+// FormatLRCTime renders milliseconds as mm:ss.xx.
+func FormatLRCTime(ms int) string {
+	return fmt.Sprintf("%02d:%02d.%02d", ms/60000, ms/1000%60, ms%1000/10)
+}
+
+// maxLRCTimeMs is one past the latest time an LRC tag can hold, 999:59.999.
+const maxLRCTimeMs = 1000 * 60 * 1000
+
+// ParsePlaybackOffset accepts seconds ("83.5") or an LRC timestamp ("01:23.50").
+// Offsets past the range of an LRC timestamp are rejected.
+func ParsePlaybackOffset(value string) (int, error) {
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+		if math.IsNaN(seconds) || seconds < 0 || seconds*1000 >= maxLRCTimeMs {
+			return 0, fmt.Errorf("offset must be between 0 and %d seconds. Got %q", maxLRCTimeMs/1000, value)
+		}
+		return int(seconds * 1000), nil
+	}
+	if m := lrcTimeTag.FindStringSubmatch("[" + value + "]"); m != nil && len(m[0]) == len(value)+2 {
+		return lrcTagMs(m[1], m[2], m[3])
+	}
+	return 0, fmt.Errorf("offset must be seconds or mm:ss.xx. Got %q", value)
+}
+
+// lrcTagValue keeps a tag value on its line and inside its brackets.
+var lrcTagValue = strings.NewReplacer("[", "(", "]", ")", "\r\n", " ", "\r", " ", "\n", " ")
+
+// FormatLRC renders synced lines as an .lrc file tagged with the song's
+// artist and title.
+func FormatLRC(song Song, lines []SyncedLine) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[ar:%s]\n[ti:%s]\n", lrcTagValue.Replace(song.Group), lrcTagValue.Replace(song.Song))
+	for _, line := range lines {
+		fmt.Fprintf(&b, "[%s]%s\n", FormatLRCTime(line.TimeMs), line.Text)
+	}
+	return b.String()
+}
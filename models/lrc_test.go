@@ -0,0 +1,131 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLRC(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []SyncedLine
+		wantErr string
+	}{
+		{
+			name: "time tags and metadata",
+			data: "[ar:Muse]\n[ti:Uprising]\n[00:01.50]Paranoia\r\n\n[00:03.5]is in bloom\n[01:02.123]",
+			want: []SyncedLine{
+				{Index: 1, TimeMs: 1500, Timestamp: "00:01.50", Text: "Paranoia"},
+				{Index: 2, TimeMs: 3500, Timestamp: "00:03.50", Text: "is in bloom"},
+				{Index: 3, TimeMs: 62123, Timestamp: "01:02.12", Text: ""},
+			},
+		},
+		{
+			name: "repeated line is expanded in playback order",
+			data: "[00:01.00][00:05.00]Chorus\n[00:02.00]Verse",
+			want: []SyncedLine{
+				{Index: 1, TimeMs: 1000, Timestamp: "00:01.00", Text: "Chorus"},
+				{Index: 2, TimeMs: 2000, Timestamp: "00:02.00", Text: "Verse"},
+				{Index: 3, TimeMs: 5000, Timestamp: "00:05.00", Text: "Chorus"},
+			},
+		},
+		{
+			name: "offset shifts lines sooner and clamps at zero",
+			data: "[offset:500]\n[00:00.20]Early\n[00:01.00]Late",
+			want: []SyncedLine{
+				{Index: 1, TimeMs: 0, Timestamp: "00:00.00", Text: "Early"},
+				{Index: 2, TimeMs: 500, Timestamp: "00:00.50", Text: "Late"},
+			},
+		},
+		{
+			name: "negative offset delays lines",
+			data: "[offset:-1000]\n[00:01.00]Line",
+			want: []SyncedLine{
+				{Index: 1, TimeMs: 2000, Timestamp: "00:02.00", Text: "Line"},
+			},
+		},
+		{name: "no timed lines", data: "[ar:Muse]\n\n", wantErr: "no timed lines"},
+		{name: "line without a timestamp", data: "[00:01.00]One\nTwo", wantErr: "line 2 has no timestamp"},
+		{name: "seconds out of range", data: "[00:60.00]One", wantErr: "seconds out of range"},
+		{name: "invalid offset", data: "[offset:soon]\n[00:01.00]One", wantErr: `invalid offset "soon"`},
+		{name: "time goes back", data: "[00:05.00]One\n[00:04.00]Two", wantErr: "goes back before 00:05.00"},
+		{name: "repeated tags must increase", data: "[00:05.00][00:05.00]One", wantErr: "repeated timestamps must increase"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLRC(tt.data)
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrInvalidLRC) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseLRC error = %v; want ErrInvalidLRC containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLRC: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLRC =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePlaybackOffset(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{"83.5", 83500, false},
+		{"0", 0, false},
+		{"01:23.50", 83500, false},
+		{"1:05", 65000, false},
+		{"59999.999", 59999999, false},
+		{"60000", 0, true},
+		{"1e300", 0, true},
+		{"Inf", 0, true},
+		{"NaN", 0, true},
+		{"-1", 0, true},
+		{"01:60", 0, true},
+		{"01:23.50x", 0, true},
+		{"soon", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParsePlaybackOffset(tt.value)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParsePlaybackOffset(%q) = %d; want an error", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePlaybackOffset(%q): %v", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePlaybackOffset(%q) = %d; want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatLRCSanitisesTags(t *testing.T) {
+	song := Song{Group: "AC/DC]\n[ti:Fake", Song: "Back [In]\r\nBlack"}
+	lines := []SyncedLine{{TimeMs: 1500, Text: "Back in black"}}
+
+	got := FormatLRC(song, lines)
+	want := "[ar:AC/DC) (ti:Fake]\n[ti:Back (In) Black]\n[00:01.50]Back in black\n"
+	if got != want {
+		t.Errorf("FormatLRC = %q; want %q", got, want)
+	}
+
+	parsed, err := ParseLRC(got)
+	if err != nil || len(parsed) != 1 {
+		t.Errorf("ParseLRC(FormatLRC) = %+v, %v; want the one line back", parsed, err)
+	}
+}
@@ -0,0 +1,164 @@
+package models
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type StanzaType string
+
+const (
+	StanzaVerse     StanzaType = "verse"
+	StanzaChorus    StanzaType = "chorus"
+	StanzaPreChorus StanzaType = "pre-chorus"
+	StanzaBridge    StanzaType = "bridge"
+	StanzaIntro     StanzaType = "intro"
+	StanzaOutro     StanzaType = "outro"
+)
+
+// Stanza is a block of lyrics separated from its neighbours by a blank line.
+// Index is 1-based within the whole song.
+type Stanza struct {
+	Index int        `json:"index"`
+	Type  StanzaType `json:"type"`
+	Label string     `json:"label,omitempty"`
+	Lines []string   `json:"lines"`
+}
+
+// Lyrics are stanzas of a song together with the time of the song's last
+// write, which the lyrics endpoint sends as Last-Modified.
+type Lyrics struct {
+	Stanzas   []Stanza  `json:"stanzas"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+var (
+	blankLine  = regexp.MustCompile(`\n[ \t]*\n+`)
+	stanzaMark = regexp.MustCompile(`^\s*(?:\[([^\]]+)\]|\(([^)]+)\)|([A-Za-z][A-Za-z -]*\d*):)\s*$`)
+	labelTypes = []struct {
+		prefix string
+		kind   StanzaType
+	}{
+		{"pre-chorus", StanzaPreChorus},
+		{"prechorus", StanzaPreChorus},
+		{"chorus", StanzaChorus},
+		{"refrain", StanzaChorus},
+		{"hook", StanzaChorus},
+		{"bridge", StanzaBridge},
+		{"intro", StanzaIntro},
+		{"outro", StanzaOutro},
+		{"verse", StanzaVerse},
+	}
+)
+
+// ParseLyrics splits text into stanzas. A leading label such as "[Chorus]",
+// "(Bridge)" or "Verse 2:" sets the stanza type; a label with no lines of its
+// own repeats the last stanza with that label. Unlabelled stanzas that occur
+// more than once are treated as the chorus, the rest as verses.
+func ParseLyrics(text string) []Stanza {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if text == "" {
+		return []Stanza{}
+	}
+
+	blocks := blankLine.Split(text, -1)
+	stanzas := make([]Stanza, 0, len(blocks))
+	seen := make(map[string]int)
+	byLabel := make(map[string][]string)
+
+	for i, block := range blocks {
+		lines := strings.Split(block, "\n")
+		for j := range lines {
+			lines[j] = strings.TrimRight(lines[j], " \t")
+		}
+
+		stanza := Stanza{Index: i + 1}
+		if label, ok := stanzaLabel(lines[0]); ok {
+			stanza.Label = label
+			stanza.Type = labelType(label)
+			lines = lines[1:]
+			if len(lines) == 0 {
+				lines = byLabel[strings.ToLower(label)]
+			} else {
+				byLabel[strings.ToLower(label)] = lines
+			}
+		}
+		stanza.Lines = append([]string{}, lines...)
+		seen[normalizeStanza(lines)]++
+		stanzas = append(stanzas, stanza)
+	}
+
+	for i := range stanzas {
+		if stanzas[i].Type != "" {
+			continue
+		}
+		if seen[normalizeStanza(stanzas[i].Lines)] > 1 {
+			stanzas[i].Type = StanzaChorus
+		} else {
+			stanzas[i].Type = StanzaVerse
+		}
+	}
+
+	return stanzas
+}
+
+func stanzaLabel(line string) (string, bool) {
+	m := stanzaMark.FindStringSubmatch(line)
+	if m == nil {
+		return "", false
+	}
+
+	label := strings.TrimSpace(m[1] + m[2] + m[3])
+	if labelType(label) == "" {
+		return "", false
+	}
+	return label, true
+}
+
+func labelType(label string) StanzaType {
+	lower := strings.ToLower(label)
+	for _, t := range labelTypes {
+		if strings.HasPrefix(lower, t.prefix) {
+			return t.kind
+		}
+	}
+	return ""
+}
+
+func normalizeStanza(lines []string) string {
+	return strings.Join(Tokenize(strings.Join(lines, " ")), " ")
+}
+
+// LyricsPlain renders stanzas back as text separated by blank lines.
+func LyricsPlain(stanzas []Stanza) string {
+	blocks := make([]string, len(stanzas))
+	for i, stanza := range stanzas {
+		blocks[i] = strings.Join(stanza.Lines, "\n")
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// LyricsHTML renders stanzas as an HTML fragment, one section per stanza.
+func LyricsHTML(stanzas []Stanza) string {
+	var b strings.Builder
+	b.WriteString(`<div class="lyrics">`)
+	for _, stanza := range stanzas {
+		b.WriteString(`<section class="stanza ` + string(stanza.Type) + `" data-index="` + strconv.Itoa(stanza.Index) + `">`)
+		if stanza.Label != "" {
+			b.WriteString(`<h4>` + html.EscapeString(stanza.Label) + `</h4>`)
+		}
+		b.WriteString(`<p>`)
+		for i, line := range stanza.Lines {
+			if i > 0 {
+				b.WriteString(`<br>`)
+			}
+			b.WriteString(html.EscapeString(line))
+		}
+		b.WriteString(`</p></section>`)
+	}
+	b.WriteString(`</div>`)
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLyrics(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Stanza
+	}{
+		{
+			name: "empty",
+			text: "  \n\n ",
+			want: []Stanza{},
+		},
+		{
+			name: "unlabelled verses",
+			text: "One\nTwo\n\nThree",
+			want: []Stanza{
+				{Index: 1, Type: StanzaVerse, Lines: []string{"One", "Two"}},
+				{Index: 2, Type: StanzaVerse, Lines: []string{"Three"}},
+			},
+		},
+		{
+			name: "repeated stanza is the chorus",
+			text: "Verse one\n\nLa la la\n\nVerse two\n\nla la, LA",
+			want: []Stanza{
+				{Index: 1, Type: StanzaVerse, Lines: []string{"Verse one"}},
+				{Index: 2, Type: StanzaChorus, Lines: []string{"La la la"}},
+				{Index: 3, Type: StanzaVerse, Lines: []string{"Verse two"}},
+				{Index: 4, Type: StanzaChorus, Lines: []string{"la la, LA"}},
+			},
+		},
+		{
+			name: "labels in brackets, parentheses and with a colon",
+			text: "[Intro]\nHey\n\n(Pre-Chorus)\nWait\n\nBridge:\nFall",
+			want: []Stanza{
+				{Index: 1, Type: StanzaIntro, Label: "Intro", Lines: []string{"Hey"}},
+				{Index: 2, Type: StanzaPreChorus, Label: "Pre-Chorus", Lines: []string{"Wait"}},
+				{Index: 3, Type: StanzaBridge, Label: "Bridge", Lines: []string{"Fall"}},
+			},
+		},
+		{
+			name: "bare label repeats the stanza",
+			text: "[Chorus]\nSing it\n\n[Verse 2]\nMore\n\n[Chorus]",
+			want: []Stanza{
+				{Index: 1, Type: StanzaChorus, Label: "Chorus", Lines: []string{"Sing it"}},
+				{Index: 2, Type: StanzaVerse, Label: "Verse 2", Lines: []string{"More"}},
+				{Index: 3, Type: StanzaChorus, Label: "Chorus", Lines: []string{"Sing it"}},
+			},
+		},
+		{
+			name: "unknown bracket text is a line",
+			text: "[Guitar solo]\nNa na",
+			want: []Stanza{
+				{Index: 1, Type: StanzaVerse, Lines: []string{"[Guitar solo]", "Na na"}},
+			},
+		},
+		{
+			name: "CRLF and whitespace-only separators",
+			text: "One  \r\n \t \r\nTwo",
+			want: []Stanza{
+				{Index: 1, Type: StanzaVerse, Lines: []string{"One"}},
+				{Index: 2, Type: StanzaVerse, Lines: []string{"Two"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseLyrics(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLyrics(%q) =\n%+v\nwant\n%+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLyricsHTMLEscapes(t *testing.T) {
+	stanzas := []Stanza{{Index: 1, Type: StanzaChorus, Label: "<b>", Lines: []string{"a & b", "<script>"}}}
+
+	got := LyricsHTML(stanzas)
+	want := `<div class="lyrics"><section class="stanza chorus" data-index="1"><h4>&lt;b&gt;</h4>` +
+		`<p>a &amp; b<br>&lt;script&gt;</p></section></div>`
+	if got != want {
+		t.Errorf("LyricsHTML = %s; want %s", got, want)
+	}
+}
@@ -1,16 +1,26 @@
 package models
 
+import "time"
+
 type Song struct {
 	ID          int    `db:"id" json:"id"`
 	Group       string `db:"group" json:"group"`
 	Song        string `db:"song" json:"song"`
-	ReleaseDate string `db:"release_date" json:"release_date"`
+	ReleaseDate Date   `db:"release_date" json:"release_date" swaggertype:"string" example:"16.07.2006"`
 	Text        string `db:"text" json:"text"`
 	Link        string `db:"link" json:"link"`
+	ArtistID    int    `db:"artist_id" json:"artist_id"`
+	// Version counts the writes to the song; it is sent as the ETag and
+	// cannot be set through the request body.
+	Version int `db:"version" json:"-"`
+	// UpdatedAt is the time of the last write to the song, sent as
+	// Last-Modified.
+	UpdatedAt time.Time `db:"updated_at" json:"-"`
 }
 
 type SongResponse struct {
 	ID          int    `json:"id"`
+	ArtistID    int    `json:"artist_id"`
 	Group       string `json:"group"`
 	Song        string `json:"song"`
 	ReleaseDate string `json:"release_date"`
@@ -18,35 +28,104 @@ type SongResponse struct {
 	Link        string `json:"link"`
 }
 
-func ToSongResponse(song Song) SongResponse {
+// ToSongResponse renders the song with its release date in dateLayout.
+func ToSongResponse(song Song, dateLayout string) SongResponse {
 	return SongResponse{
 		ID:          song.ID,
+		ArtistID:    song.ArtistID,
 		Group:       song.Group,
 		Song:        song.Song,
-		ReleaseDate: song.ReleaseDate,
+		ReleaseDate: song.ReleaseDate.Format(dateLayout),
 		Text:        song.Text,
 		Link:        song.Link,
 	}
 }
 
-func ToSongResponseList(songs []Song) []SongResponse {
+func ToSongResponseList(songs []Song, dateLayout string) []SongResponse {
 	var response []SongResponse
 
 	for _, song := range songs {
-		response = append(response, ToSongResponse(song))
+		response = append(response, ToSongResponse(song, dateLayout))
 	}
 
 	return response
 }
 
+// Pagination describes the page returned in a list response. Next and Prev
+// are relative links to the neighbouring pages, omitted at the ends.
+type Pagination struct {
+	Total int    `json:"total"`
+	Page  int    `json:"page"`
+	Limit int    `json:"limit"`
+	Pages int    `json:"pages"`
+	Next  string `json:"next,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+}
+
+// SongListResponse carries page metadata in page mode and NextCursor in
+// cursor mode. Facets are only included on request.
 type SongListResponse struct {
 	Songs []SongResponse `json:"songs"`
+	*Pagination
+	NextCursor string     `json:"next_cursor,omitempty"`
+	Facets     []TagFacet `json:"facets,omitempty"`
+}
+
+type SongLyricsResponse struct {
+	Stanzas []Stanza `json:"stanzas"`
+	Pagination
 }
 
 type ErrorResponse struct {
 	Error string `json:"error"`
+	// Reason is a stable code for clients to act on, set on 401 and 403.
+	Reason string `json:"reason,omitempty" example:"missing_permission"`
 }
 
 type MessageResponse struct {
 	Message string `json:"message"`
 }
+
+// DateLayout is the DD.MM.YYYY format used for release dates.
+const DateLayout = "02.01.2006"
+
+type MatchType string
+
+const (
+	MatchExact    MatchType = "exact"
+	MatchContains MatchType = "contains"
+	MatchPrefix   MatchType = "prefix"
+)
+
+// FilterFields lists the song fields that can be matched by SongFilter.
+var FilterFields = []string{"group", "song", "link", "text"}
+
+type FieldMatch struct {
+	Field string
+	Match MatchType
+	Value string
+}
+
+// SongFilter combines field matches, a release date range and tags; every
+// condition must hold for a song to be selected.
+type SongFilter struct {
+	ArtistID       *int
+	Matches        []FieldMatch
+	ReleasedAfter  *Date
+	ReleasedBefore *Date
+	// Tags are tag names as given by the client. Stores only look at TagIDs,
+	// which the service resolves from Tags; unknown names resolve to 0.
+	Tags    []string
+	TagIDs  []int
+	TagMode TagMode
+}
+
+// SortFields lists the song fields GET /songs can be ordered by.
+var SortFields = []string{"id", "group", "song", "release_date"}
+
+// SongSort orders songs by Field, breaking ties by id. Unknown release
+// dates always come last.
+type SongSort struct {
+	Field string
+	Desc  bool
+}
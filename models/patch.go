@@ -0,0 +1,298 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidPatch = errors.New("invalid patch")
+	// ErrPatchTestFailed is returned when a JSON Patch "test" operation does
+	// not match the document.
+	ErrPatchTestFailed = errors.New("patch test failed")
+)
+
+// PatchFunc applies patch to the JSON document doc and returns the result.
+type PatchFunc func(doc, patch []byte) ([]byte, error)
+
+// MergePatch applies an RFC 7386 JSON Merge Patch: members of the patch
+// replace those of the document, null removes them and nested objects are
+// merged recursively.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var target, changes interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return json.Marshal(mergeValue(target, changes))
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	changes, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	object, ok := target.(map[string]interface{})
+	if !ok {
+		object = map[string]interface{}{}
+	}
+	for key, value := range changes {
+		if value == nil {
+			delete(object, key)
+		} else {
+			object[key] = mergeValue(object[key], value)
+		}
+	}
+	return object
+}
+
+type jsonPatchOp struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// JSONPatch applies an RFC 6902 JSON Patch. Operations are applied in order
+// and the whole patch fails if any of them does.
+func JSONPatch(doc, patch []byte) ([]byte, error) {
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	var ops []jsonPatchOp
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	for i, op := range ops {
+		var err error
+		target, err = applyPatchOp(target, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+func applyPatchOp(doc interface{}, op jsonPatchOp) (interface{}, error) {
+	if op.Path == nil {
+		return nil, fmt.Errorf("%w: %q needs a path", ErrInvalidPatch, op.Op)
+	}
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: %q needs a value", ErrInvalidPatch, op.Op)
+		}
+		var value interface{}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		switch op.Op {
+		case "add":
+			return addAt(doc, path, value)
+		case "replace":
+			if len(path) == 0 {
+				// The empty pointer is the whole document.
+				return value, nil
+			}
+			if doc, err = removeAt(doc, path); err != nil {
+				return nil, err
+			}
+			return addAt(doc, path, value)
+		default:
+			current, err := valueAt(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, fmt.Errorf("%w: %s", ErrPatchTestFailed, *op.Path)
+			}
+			return doc, nil
+		}
+	case "remove":
+		return removeAt(doc, path)
+	case "move", "copy":
+		if op.From == nil {
+			return nil, fmt.Errorf("%w: %q needs from", ErrInvalidPatch, op.Op)
+		}
+		from, err := parsePointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := valueAt(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if doc, err = removeAt(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			value = deepCopy(value)
+		}
+		return addAt(doc, path, value)
+	default:
+		return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+	}
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: path %q must start with /", ErrInvalidPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func valueAt(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: %q does not exist", ErrInvalidPatch, token)
+			}
+			doc = value
+		case []interface{}:
+			index, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[index]
+		default:
+			return nil, fmt.Errorf("%w: %q does not exist", ErrInvalidPatch, token)
+		}
+	}
+	return doc, nil
+}
+
+// addAt returns doc with value added at path, replacing object members and
+// inserting into arrays.
+func addAt(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := valueAt(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+		return doc, nil
+	case []interface{}:
+		index := len(node)
+		if last != "-" {
+			if index, err = arrayIndex(last, len(node)); err != nil {
+				return nil, err
+			}
+		}
+		node = append(node, nil)
+		copy(node[index+1:], node[index:])
+		node[index] = value
+		return replaceAt(doc, path[:len(path)-1], node)
+	default:
+		return nil, fmt.Errorf("%w: cannot add to %q", ErrInvalidPatch, last)
+	}
+}
+
+func removeAt(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: cannot remove the whole document", ErrInvalidPatch)
+	}
+	parent, err := valueAt(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := node[last]; !ok {
+			return nil, fmt.Errorf("%w: %q does not exist", ErrInvalidPatch, last)
+		}
+		delete(node, last)
+		return doc, nil
+	case []interface{}:
+		index, err := arrayIndex(last, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		node = append(node[:index], node[index+1:]...)
+		return replaceAt(doc, path[:len(path)-1], node)
+	default:
+		return nil, fmt.Errorf("%w: %q does not exist", ErrInvalidPatch, last)
+	}
+}
+
+// replaceAt stores an array that was resized back into its parent.
+func replaceAt(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := valueAt(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[last] = value
+	case []interface{}:
+		index, err := arrayIndex(last, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = value
+	}
+	return doc, nil
+}
+
+func arrayIndex(token string, maxIndex int) (int, error) {
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || index > maxIndex || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	return index, nil
+}
+
+func deepCopy(value interface{}) interface{} {
+	switch node := value.(type) {
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(node))
+		for key, member := range node {
+			object[key] = deepCopy(member)
+		}
+		return object
+	case []interface{}:
+		array := make([]interface{}, len(node))
+		for i, member := range node {
+			array[i] = deepCopy(member)
+		}
+		return array
+	default:
+		return value
+	}
+}
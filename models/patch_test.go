@@ -0,0 +1,145 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// jsonEqual reports whether two JSON documents hold the same value.
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+
+	var x, y interface{}
+	if err := json.Unmarshal(a, &x); err != nil {
+		t.Fatalf("Unmarshal(%s): %v", a, err)
+	}
+	if err := json.Unmarshal(b, &y); err != nil {
+		t.Fatalf("Unmarshal(%s): %v", b, err)
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+func TestMergePatch(t *testing.T) {
+	// Cases from RFC 7386, appendix A.
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.patch, func(t *testing.T) {
+			got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("MergePatch: %v", err)
+			}
+			if !jsonEqual(t, got, []byte(tt.want)) {
+				t.Errorf("MergePatch(%s, %s) = %s; want %s", tt.doc, tt.patch, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := MergePatch([]byte(`{}`), []byte(`{`)); !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("MergePatch of malformed JSON: %v; want ErrInvalidPatch", err)
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	const doc = `{"song":"Uprising","tags":["rock","alt"],"meta":{"year":2009}}`
+
+	tests := []struct {
+		name    string
+		patch   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "replace",
+			patch: `[{"op":"replace","path":"/song","value":"Resistance"}]`,
+			want:  `{"song":"Resistance","tags":["rock","alt"],"meta":{"year":2009}}`,
+		},
+		{
+			name:  "replace the document",
+			patch: `[{"op":"replace","path":"","value":{"song":"Resistance"}},{"op":"add","path":"/tags","value":[]}]`,
+			want:  `{"song":"Resistance","tags":[]}`,
+		},
+		{
+			name:  "add to object and array",
+			patch: `[{"op":"add","path":"/meta/label","value":"Warner"},{"op":"add","path":"/tags/1","value":"live"}]`,
+			want:  `{"song":"Uprising","tags":["rock","live","alt"],"meta":{"year":2009,"label":"Warner"}}`,
+		},
+		{
+			name:  "append with dash",
+			patch: `[{"op":"add","path":"/tags/-","value":"live"}]`,
+			want:  `{"song":"Uprising","tags":["rock","alt","live"],"meta":{"year":2009}}`,
+		},
+		{
+			name:  "remove",
+			patch: `[{"op":"remove","path":"/tags/0"},{"op":"remove","path":"/meta"}]`,
+			want:  `{"song":"Uprising","tags":["alt"]}`,
+		},
+		{
+			name:  "move and copy",
+			patch: `[{"op":"copy","from":"/meta/year","path":"/year"},{"op":"move","from":"/tags","path":"/genres"}]`,
+			want:  `{"song":"Uprising","genres":["rock","alt"],"meta":{"year":2009},"year":2009}`,
+		},
+		{
+			name:  "escaped pointer",
+			patch: `[{"op":"add","path":"/a~1b~0c","value":1}]`,
+			want:  `{"song":"Uprising","tags":["rock","alt"],"meta":{"year":2009},"a/b~c":1}`,
+		},
+		{
+			name:  "passing test",
+			patch: `[{"op":"test","path":"/meta","value":{"year":2009}},{"op":"replace","path":"/song","value":"x"}]`,
+			want:  `{"song":"x","tags":["rock","alt"],"meta":{"year":2009}}`,
+		},
+		{
+			name:    "failing test stops the patch",
+			patch:   `[{"op":"replace","path":"/song","value":"x"},{"op":"test","path":"/song","value":"Uprising"}]`,
+			wantErr: ErrPatchTestFailed,
+		},
+		{name: "unknown op", patch: `[{"op":"merge","path":"/song"}]`, wantErr: ErrInvalidPatch},
+		{name: "missing path", patch: `[{"op":"remove"}]`, wantErr: ErrInvalidPatch},
+		{name: "missing value", patch: `[{"op":"add","path":"/x"}]`, wantErr: ErrInvalidPatch},
+		{name: "relative path", patch: `[{"op":"remove","path":"song"}]`, wantErr: ErrInvalidPatch},
+		{name: "remove a missing member", patch: `[{"op":"remove","path":"/nope"}]`, wantErr: ErrInvalidPatch},
+		{name: "index out of range", patch: `[{"op":"add","path":"/tags/3","value":"x"}]`, wantErr: ErrInvalidPatch},
+		{name: "leading zero index", patch: `[{"op":"remove","path":"/tags/01"}]`, wantErr: ErrInvalidPatch},
+		{name: "remove the document", patch: `[{"op":"remove","path":""}]`, wantErr: ErrInvalidPatch},
+		{name: "not an array", patch: `{"op":"remove","path":"/song"}`, wantErr: ErrInvalidPatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := JSONPatch([]byte(doc), []byte(tt.patch))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("JSONPatch error = %v; want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("JSONPatch: %v", err)
+			}
+			if !jsonEqual(t, got, []byte(tt.want)) {
+				t.Errorf("JSONPatch = %s; want %s", got, tt.want)
+			}
+		})
+	}
+}
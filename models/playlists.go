@@ -0,0 +1,75 @@
+package models
+
+// Playlist is owned by the subject that created it. Owner is set from the
+// token, never from the request body.
+type Playlist struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name" example:"Road trip"`
+	Public bool   `json:"public"`
+	Owner  string `json:"-"`
+}
+
+// CanRead reports whether subject may see the playlist: it is public or
+// subject owns it.
+func (p Playlist) CanRead(subject string) bool {
+	return p.Public || p.Owner == subject
+}
+
+// PlaylistEntry is one occurrence of a song in a playlist. Position is the
+// 1-based place of the entry in the playlist at the time it was read.
+type PlaylistEntry struct {
+	ID       int
+	Position int
+	Song     Song
+}
+
+// AddPlaylistEntryRequest inserts a song after the entry AfterEntryID; 0
+// inserts at the start and null or a missing field appends to the end.
+type AddPlaylistEntryRequest struct {
+	SongID       int  `json:"song_id"`
+	AfterEntryID *int `json:"after_entry_id"`
+}
+
+// MovePlaylistEntryRequest moves an entry after the entry AfterEntryID; 0
+// moves it to the start and null or a missing field to the end.
+type MovePlaylistEntryRequest struct {
+	AfterEntryID *int `json:"after_entry_id"`
+}
+
+type PlaylistEntryResponse struct {
+	EntryID  int          `json:"entry_id"`
+	Position int          `json:"position"`
+	Song     SongResponse `json:"song"`
+}
+
+type PlaylistResponse struct {
+	ID      int                     `json:"id"`
+	Name    string                  `json:"name"`
+	Public  bool                    `json:"public"`
+	Owner   string                  `json:"owner,omitempty"`
+	Entries []PlaylistEntryResponse `json:"entries,omitempty"`
+}
+
+func ToPlaylistResponse(playlist Playlist, entries []PlaylistEntry, dateLayout string) PlaylistResponse {
+	response := PlaylistResponse{
+		ID:     playlist.ID,
+		Name:   playlist.Name,
+		Public: playlist.Public,
+		Owner:  playlist.Owner,
+	}
+
+	for _, entry := range entries {
+		response.Entries = append(response.Entries, PlaylistEntryResponse{
+			EntryID:  entry.ID,
+			Position: entry.Position,
+			Song:     ToSongResponse(entry.Song, dateLayout),
+		})
+	}
+
+	return response
+}
+
+type PlaylistListResponse struct {
+	Playlists []PlaylistResponse `json:"playlists"`
+	Pagination
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SongRevision is a snapshot of a song as stored by one write. Revision
+// numbers start at 1 for every song and only grow.
+type SongRevision struct {
+	Revision  int
+	CreatedAt time.Time
+	Song      Song
+}
+
+type SongRevisionResponse struct {
+	Revision  int          `json:"revision"`
+	CreatedAt time.Time    `json:"created_at"`
+	Song      SongResponse `json:"song"`
+}
+
+func ToSongRevisionResponse(revision SongRevision, dateLayout string) SongRevisionResponse {
+	return SongRevisionResponse{
+		Revision:  revision.Revision,
+		CreatedAt: revision.CreatedAt,
+		Song:      ToSongResponse(revision.Song, dateLayout),
+	}
+}
+
+type SongRevisionListResponse struct {
+	Revisions []SongRevisionResponse `json:"revisions"`
+	Pagination
+}
+
+type DiffOp string
+
+const (
+	DiffEqual  DiffOp = "equal"
+	DiffInsert DiffOp = "insert"
+	DiffDelete DiffOp = "delete"
+)
+
+// DiffLine is one line of a line-level diff. OldLine and NewLine are 1-based
+// line numbers in the two texts, omitted for lines missing from that side.
+type DiffLine struct {
+	Op      DiffOp `json:"op"`
+	OldLine int    `json:"old_line,omitempty"`
+	NewLine int    `json:"new_line,omitempty"`
+	Text    string `json:"text"`
+}
+
+type SongRevisionDiffResponse struct {
+	SongID int        `json:"song_id"`
+	From   int        `json:"from"`
+	To     int        `json:"to"`
+	Lines  []DiffLine `json:"lines"`
+}
+
+// MaxDiffLines caps the lines DiffLines compares on each side once the
+// lines both texts start and end with are set aside. Its table grows with
+// the product of the two counts.
+const MaxDiffLines = 1000
+
+var ErrDiffTooLarge = fmt.Errorf("texts differ in more than %d lines", MaxDiffLines)
+
+// DiffLines compares two texts line by line using their longest common
+// subsequence, listing deletions before insertions within each change. It
+// returns ErrDiffTooLarge rather than compare more than MaxDiffLines
+// differing lines on either side.
+func DiffLines(oldText, newText string) ([]DiffLine, error) {
+	a, b := splitLines(oldText), splitLines(newText)
+
+	// Lines both texts start or end with are equal without a table.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(midA) > MaxDiffLines || len(midB) > MaxDiffLines {
+		return nil, ErrDiffTooLarge
+	}
+
+	// common[i][j] is the length of the LCS of midA[i:] and midB[j:].
+	common := make([][]int, len(midA)+1)
+	for i := range common {
+		common[i] = make([]int, len(midB)+1)
+	}
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	lines := []DiffLine{}
+	for k := 0; k < prefix; k++ {
+		lines = append(lines, DiffLine{Op: DiffEqual, OldLine: k + 1, NewLine: k + 1, Text: a[k]})
+	}
+	i, j := 0, 0
+	for i < len(midA) || j < len(midB) {
+		switch {
+		case i < len(midA) && j < len(midB) && midA[i] == midB[j]:
+			lines = append(lines, DiffLine{Op: DiffEqual, OldLine: prefix + i + 1, NewLine: prefix + j + 1, Text: midA[i]})
+			i++
+			j++
+		case j == len(midB) || (i < len(midA) && common[i+1][j] >= common[i][j+1]):
+			lines = append(lines, DiffLine{Op: DiffDelete, OldLine: prefix + i + 1, Text: midA[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{Op: DiffInsert, NewLine: prefix + j + 1, Text: midB[j]})
+			j++
+		}
+	}
+	for k := suffix; k > 0; k-- {
+		lines = append(lines, DiffLine{Op: DiffEqual, OldLine: len(a) - k + 1, NewLine: len(b) - k + 1, Text: a[len(a)-k]})
+	}
+	return lines, nil
+}
+
+func splitLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []DiffLine
+	}{
+		{
+			name: "both empty",
+			want: []DiffLine{},
+		},
+		{
+			name: "identical",
+			old:  "a\nb\n",
+			new:  "a\r\nb",
+			want: []DiffLine{
+				{Op: DiffEqual, OldLine: 1, NewLine: 1, Text: "a"},
+				{Op: DiffEqual, OldLine: 2, NewLine: 2, Text: "b"},
+			},
+		},
+		{
+			name: "insert into empty",
+			new:  "a\nb",
+			want: []DiffLine{
+				{Op: DiffInsert, NewLine: 1, Text: "a"},
+				{Op: DiffInsert, NewLine: 2, Text: "b"},
+			},
+		},
+		{
+			name: "delete everything",
+			old:  "a",
+			want: []DiffLine{
+				{Op: DiffDelete, OldLine: 1, Text: "a"},
+			},
+		},
+		{
+			name: "changed line deletes before it inserts",
+			old:  "a\nb\nc",
+			new:  "a\nB\nc",
+			want: []DiffLine{
+				{Op: DiffEqual, OldLine: 1, NewLine: 1, Text: "a"},
+				{Op: DiffDelete, OldLine: 2, Text: "b"},
+				{Op: DiffInsert, NewLine: 2, Text: "B"},
+				{Op: DiffEqual, OldLine: 3, NewLine: 3, Text: "c"},
+			},
+		},
+		{
+			name: "insert and delete at different places",
+			old:  "a\nb\nc",
+			new:  "x\na\nc",
+			want: []DiffLine{
+				{Op: DiffInsert, NewLine: 1, Text: "x"},
+				{Op: DiffEqual, OldLine: 1, NewLine: 2, Text: "a"},
+				{Op: DiffDelete, OldLine: 2, Text: "b"},
+				{Op: DiffEqual, OldLine: 3, NewLine: 3, Text: "c"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DiffLines(tt.old, tt.new)
+			if err != nil {
+				t.Fatalf("DiffLines(%q, %q): %v", tt.old, tt.new, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffLines(%q, %q) =\n%+v\nwant\n%+v", tt.old, tt.new, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffLinesTooLarge(t *testing.T) {
+	numbered := func(prefix string, n int) string {
+		var b strings.Builder
+		for i := 0; i < n; i++ {
+			fmt.Fprintf(&b, "%s %d\n", prefix, i)
+		}
+		return b.String()
+	}
+	shared := numbered("same", 5000)
+
+	tests := []struct {
+		name     string
+		old, new string
+		wantErr  error
+	}{
+		{"long texts with a small change", shared + "old\n" + shared, shared + "new\n" + shared, nil},
+		{"at the limit", numbered("old", MaxDiffLines), numbered("new", MaxDiffLines), nil},
+		{"over the limit", numbered("old", MaxDiffLines+1), numbered("new", 1), ErrDiffTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DiffLines(tt.old, tt.new); !errors.Is(err, tt.wantErr) {
+				t.Errorf("DiffLines error = %v; want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package models
+
+const (
+	RoleReader    = "reader"
+	RoleEditor    = "editor"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Permissions checked by the routes in main.go. The roles that hold them are
+// stored in the role_permissions table.
+const (
+	PermPlaylistsWrite = "playlists:write"
+	PermSongsWrite     = "songs:write"
+	PermSongsDelete    = "songs:delete"
+	PermCatalogWrite   = "catalog:write"
+	PermCatalogDelete  = "catalog:delete"
+	PermAPIKeysManage  = "apikeys:manage"
+	PermCacheRead      = "cache:read"
+)
+
+// Permissions lists every permission; API key scopes must be among them.
+var Permissions = []string{
+	PermPlaylistsWrite, PermSongsWrite, PermSongsDelete,
+	PermCatalogWrite, PermCatalogDelete, PermAPIKeysManage,
+	PermCacheRead,
+}
+
+// Machine-readable values of ErrorResponse.Reason for 401 and 403 responses.
+const (
+	ReasonMissingToken      = "missing_token"
+	ReasonInvalidToken      = "invalid_token"
+	ReasonTokenExpired      = "token_expired"
+	ReasonInvalidAPIKey     = "invalid_api_key"
+	ReasonAPIKeyExpired     = "api_key_expired"
+	ReasonMissingPermission = "missing_permission"
+	ReasonNotOwner          = "not_owner"
+)
+
+type SubjectRolesResponse struct {
+	Subject     string   `json:"subject"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// SearchTerm is a single word or a quoted phrase. Prefix marks a trailing
+// "*" that matches any word starting with the last one.
+type SearchTerm struct {
+	Words  []string
+	Prefix bool
+}
+
+// SearchQuery is a parsed full-text query; all of its terms must match.
+type SearchQuery struct {
+	Terms []SearchTerm
+}
+
+type SongSearchResult struct {
+	ID          int
+	Group       string
+	Song        string
+	ReleaseDate Date
+	Link        string
+	Rank        float64
+	Verse       *int
+	// Snippet is an HTML fragment: the escaped verse with matches in <mark>.
+	Snippet string
+}
+
+type SongSearchResultResponse struct {
+	ID          int     `json:"id"`
+	Group       string  `json:"group"`
+	Song        string  `json:"song"`
+	ReleaseDate string  `json:"release_date"`
+	Link        string  `json:"link"`
+	Rank        float64 `json:"rank"`
+	Verse       *int    `json:"verse"`
+	Snippet     string  `json:"snippet"`
+}
+
+func ToSongSearchResponse(results []SongSearchResult, dateLayout string) SongSearchResponse {
+	response := SongSearchResponse{Results: []SongSearchResultResponse{}}
+
+	for _, result := range results {
+		response.Results = append(response.Results, SongSearchResultResponse{
+			ID:          result.ID,
+			Group:       result.Group,
+			Song:        result.Song,
+			ReleaseDate: result.ReleaseDate.Format(dateLayout),
+			Link:        result.Link,
+			Rank:        result.Rank,
+			Verse:       result.Verse,
+			Snippet:     result.Snippet,
+		})
+	}
+
+	return response
+}
+
+type SongSearchResponse struct {
+	Results []SongSearchResultResponse `json:"results"`
+}
+
+// ParseSearchQuery turns `love "yellow submarine" sub*` into terms: plain
+// words, quoted phrases and prefix words.
+func ParseSearchQuery(q string) (SearchQuery, error) {
+	var query SearchQuery
+
+	rest := q
+	for {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if rest == "" {
+			break
+		}
+
+		var chunk string
+		if rest[0] == '"' {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				chunk, rest = rest[1:], ""
+			} else {
+				chunk, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				chunk, rest = rest, ""
+			} else {
+				chunk, rest = rest[:end], rest[end:]
+			}
+		}
+
+		prefix := strings.HasSuffix(chunk, "*")
+		words := Tokenize(chunk)
+		if len(words) == 0 {
+			continue
+		}
+		query.Terms = append(query.Terms, SearchTerm{Words: words, Prefix: prefix})
+	}
+
+	if len(query.Terms) == 0 {
+		return query, errors.New("search query must contain at least one word")
+	}
+	return query, nil
+}
+
+// Tokenize lowercases text and splits it into words of letters and digits.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
@@ -0,0 +1,65 @@
+package models
+
+import "strings"
+
+type TagKind string
+
+const (
+	TagGenre TagKind = "genre"
+	TagMood  TagKind = "mood"
+	TagOther TagKind = "tag"
+)
+
+// TagKinds lists the kinds a tag can have.
+var TagKinds = []TagKind{TagGenre, TagMood, TagOther}
+
+func (k TagKind) Valid() bool {
+	for _, kind := range TagKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// TagMode decides whether a song must carry all or any of the requested tags.
+type TagMode string
+
+const (
+	TagMatchAll TagMode = "all"
+	TagMatchAny TagMode = "any"
+)
+
+type Tag struct {
+	ID   int     `json:"id"`
+	Name string  `json:"name" example:"rock"`
+	Kind TagKind `json:"kind" example:"genre" enums:"genre,mood,tag"`
+}
+
+type TagListResponse struct {
+	Tags []Tag `json:"tags"`
+	Pagination
+}
+
+// TagFacet is the number of songs matching a list query that carry the tag.
+type TagFacet struct {
+	ID    int     `json:"id"`
+	Name  string  `json:"name" example:"rock"`
+	Kind  TagKind `json:"kind" example:"genre"`
+	Count int     `json:"count" example:"1204"`
+}
+
+type SongTagsRequest struct {
+	Tags []string `json:"tags" example:"rock,90s"`
+}
+
+type SongTagsResponse struct {
+	SongID int   `json:"song_id"`
+	Tags   []Tag `json:"tags"`
+}
+
+// NormalizeTagName lower-cases a tag name and collapses its whitespace, so
+// "Hip  Hop" and "hip hop" are the same tag.
+func NormalizeTagName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
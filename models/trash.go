@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// TrashedSong is a deleted song kept until the trash is purged.
+type TrashedSong struct {
+	Song      Song
+	DeletedAt time.Time
+}
+
+type TrashedSongResponse struct {
+	SongResponse
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+type TrashListResponse struct {
+	Songs []TrashedSongResponse `json:"songs"`
+	Pagination
+}
+
+func ToTrashedSongResponse(song TrashedSong, dateLayout string) TrashedSongResponse {
+	return TrashedSongResponse{
+		SongResponse: ToSongResponse(song.Song, dateLayout),
+		DeletedAt:    song.DeletedAt,
+	}
+}
@@ -0,0 +1,380 @@
+package repositories
+
+import (
+	"case/models"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrAlbumNotFound      = errors.New("album not found")
+	ErrTrackExists        = errors.New("song is already on the album")
+	ErrTrackNotFound      = errors.New("song is not on the album")
+	ErrTrackListMismatch  = errors.New("track order must list every song of the album exactly once")
+	ErrInvalidTrackNumber = errors.New("disc and track numbers must be positive")
+)
+
+// AlbumStore is the storage contract used by services.AlbumService.
+type AlbumStore interface {
+	GetAlbums(artistID *int, page, limit int) ([]models.Album, int, error)
+	GetAlbum(id int) (models.Album, error)
+	// GetAlbumTracks returns the album's songs ordered by disc and track number.
+	GetAlbumTracks(id int) ([]models.AlbumTrack, error)
+	AddAlbum(album *models.Album) error
+	UpdateAlbum(album *models.Album) error
+	DeleteAlbum(id int) error
+	// AddTrack inserts a song at trackNumber on disc, shifting the following
+	// tracks down; trackNumber 0 appends it to the end of the disc.
+	AddTrack(albumID, songID, disc, trackNumber int) error
+	// RemoveTrack takes a song off the album and closes the gap it leaves.
+	RemoveTrack(albumID, songID int) error
+	// ReorderTracks renumbers the album in the given order, disc by disc.
+	ReorderTracks(albumID int, order []models.TrackPosition) error
+}
+
+type AlbumRepository struct {
+	db  *sql.DB
+	log *logrus.Logger
+}
+
+func NewAlbumRepository(db *sql.DB, log *logrus.Logger) *AlbumRepository {
+	return &AlbumRepository{db: db, log: log}
+}
+
+const albumColumns = `id, title, artist_id, release_date, cover_url`
+
+func albumFields(album *models.Album) []interface{} {
+	return []interface{}{&album.ID, &album.Title, &album.ArtistID, &album.ReleaseDate, &album.CoverURL}
+}
+
+func (r *AlbumRepository) GetAlbums(artistID *int, page, limit int) ([]models.Album, int, error) {
+	var b queryBuilder
+	if artistID != nil {
+		b.where("artist_id = " + b.arg(*artistID))
+	}
+	where := b.whereClause()
+	filterArgs := b.args
+
+	query := `SELECT ` + albumColumns + `, COUNT(*) OVER() FROM albums` + where + ` ORDER BY release_date NULLS LAST, id`
+	query += " LIMIT " + b.arg(limit) + " OFFSET " + b.arg((page-1)*limit)
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	rows, err := r.db.Query(query, b.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error closing rows")
+		}
+	}(rows)
+
+	var albums []models.Album
+	total := 0
+	for rows.Next() {
+		var album models.Album
+		if err := rows.Scan(append(albumFields(&album), &total)...); err != nil {
+			return nil, 0, err
+		}
+		albums = append(albums, album)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if len(albums) == 0 && page > 1 {
+		if err := r.db.QueryRow(`SELECT COUNT(*) FROM albums`+where, filterArgs...).Scan(&total); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return albums, total, nil
+}
+
+func (r *AlbumRepository) GetAlbum(id int) (models.Album, error) {
+	var album models.Album
+
+	query := `SELECT ` + albumColumns + ` FROM albums WHERE id = $1`
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	err := r.db.QueryRow(query, id).Scan(albumFields(&album)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return album, ErrAlbumNotFound
+	}
+	return album, err
+}
+
+func (r *AlbumRepository) GetAlbumTracks(id int) ([]models.AlbumTrack, error) {
+	query := `
+        SELECT t.disc_number, t.track_number, s.id, s."group", s.song, s.release_date, s.text, s.link, s.artist_id
+        FROM album_tracks t
+        JOIN songs s ON s.id = t.song_id
+        WHERE t.album_id = $1 AND s.deleted_at IS NULL
+        ORDER BY t.disc_number, t.track_number
+    `
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	rows, err := r.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error closing rows")
+		}
+	}(rows)
+
+	var tracks []models.AlbumTrack
+	for rows.Next() {
+		var track models.AlbumTrack
+		if err := rows.Scan(append([]interface{}{&track.DiscNumber, &track.TrackNumber}, songFields(&track.Song)...)...); err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, track)
+	}
+
+	return tracks, rows.Err()
+}
+
+func (r *AlbumRepository) AddAlbum(album *models.Album) error {
+	query := `
+        INSERT INTO albums (title, artist_id, release_date, cover_url)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id
+    `
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	err := r.db.QueryRow(query, album.Title, album.ArtistID, album.ReleaseDate, album.CoverURL).Scan(&album.ID)
+	if isForeignKeyViolation(err) {
+		return ErrArtistNotFound
+	}
+	return err
+}
+
+func (r *AlbumRepository) UpdateAlbum(album *models.Album) error {
+	query := `UPDATE albums
+SET title = $1, artist_id = $2, release_date = $3, cover_url = $4
+WHERE id = $5
+`
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	result, err := r.db.Exec(query, album.Title, album.ArtistID, album.ReleaseDate, album.CoverURL, album.ID)
+	if isForeignKeyViolation(err) {
+		return ErrArtistNotFound
+	}
+	return affectedOrNotFound(result, err, ErrAlbumNotFound)
+}
+
+func (r *AlbumRepository) DeleteAlbum(id int) error {
+	query := "DELETE FROM albums WHERE id = $1"
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	result, err := r.db.Exec(query, id)
+	return affectedOrNotFound(result, err, ErrAlbumNotFound)
+}
+
+func (r *AlbumRepository) AddTrack(albumID, songID, disc, trackNumber int) error {
+	if disc < 1 || trackNumber < 0 {
+		return ErrInvalidTrackNumber
+	}
+
+	return r.inAlbumTx(albumID, func(tx *sql.Tx) error {
+		if err := lockLiveSong(tx, songID); err != nil {
+			return err
+		}
+		var last int
+		err := tx.QueryRow(`SELECT COALESCE(MAX(track_number), 0) FROM album_tracks WHERE album_id = $1 AND disc_number = $2`,
+			albumID, disc).Scan(&last)
+		if err != nil {
+			return err
+		}
+		if trackNumber == 0 || trackNumber > last {
+			trackNumber = last + 1
+		}
+
+		_, err = tx.Exec(`UPDATE album_tracks SET track_number = track_number + 1
+WHERE album_id = $1 AND disc_number = $2 AND track_number >= $3`, albumID, disc, trackNumber)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`INSERT INTO album_tracks (album_id, song_id, disc_number, track_number) VALUES ($1, $2, $3, $4)`,
+			albumID, songID, disc, trackNumber)
+		switch {
+		case isUniqueViolation(err):
+			return ErrTrackExists
+		case isForeignKeyViolation(err):
+			return ErrSongNotFound
+		}
+		return err
+	})
+}
+
+func (r *AlbumRepository) RemoveTrack(albumID, songID int) error {
+	return r.inAlbumTx(albumID, func(tx *sql.Tx) error {
+		var disc, number int
+		err := tx.QueryRow(`DELETE FROM album_tracks WHERE album_id = $1 AND song_id = $2 RETURNING disc_number, track_number`,
+			albumID, songID).Scan(&disc, &number)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrTrackNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`UPDATE album_tracks SET track_number = track_number - 1
+WHERE album_id = $1 AND disc_number = $2 AND track_number > $3`, albumID, disc, number)
+		return err
+	})
+}
+
+func (r *AlbumRepository) ReorderTracks(albumID int, order []models.TrackPosition) error {
+	return r.inAlbumTx(albumID, func(tx *sql.Tx) error {
+		rows, err := tx.Query(`SELECT song_id FROM album_tracks WHERE album_id = $1`, albumID)
+		if err != nil {
+			return err
+		}
+		current := make(map[int]bool)
+		for rows.Next() {
+			var songID int
+			if err := rows.Scan(&songID); err != nil {
+				_ = rows.Close()
+				return err
+			}
+			current[songID] = true
+		}
+		if err := rows.Close(); err != nil {
+			return err
+		}
+
+		numbered, err := numberTracks(current, order)
+		if err != nil {
+			return err
+		}
+
+		songIDs := make([]int64, len(numbered))
+		discs := make([]int64, len(numbered))
+		numbers := make([]int64, len(numbered))
+		for i, track := range numbered {
+			songIDs[i] = int64(track.Song.ID)
+			discs[i] = int64(track.DiscNumber)
+			numbers[i] = int64(track.TrackNumber)
+		}
+
+		_, err = tx.Exec(`UPDATE album_tracks t
+SET disc_number = o.disc_number, track_number = o.track_number
+FROM unnest($2::int[], $3::int[], $4::int[]) AS o(song_id, disc_number, track_number)
+WHERE t.album_id = $1 AND t.song_id = o.song_id`, albumID, pq.Array(songIDs), pq.Array(discs), pq.Array(numbers))
+		return err
+	})
+}
+
+// inAlbumTx runs fn in a transaction holding a lock on the album row, so
+// concurrent edits of one track list are applied one after another.
+func (r *AlbumRepository) inAlbumTx(albumID int, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error rolling back transaction")
+		}
+	}(tx)
+
+	var exists int
+	err = tx.QueryRow(`SELECT 1 FROM albums WHERE id = $1 FOR UPDATE`, albumID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrAlbumNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// numberTracks checks that order lists exactly the songs in current and
+// assigns track numbers per disc in the given order.
+func numberTracks(current map[int]bool, order []models.TrackPosition) ([]models.AlbumTrack, error) {
+	if len(order) != len(current) {
+		return nil, ErrTrackListMismatch
+	}
+
+	seen := make(map[int]bool, len(order))
+	next := make(map[int]int)
+	tracks := make([]models.AlbumTrack, 0, len(order))
+	for _, position := range order {
+		if !current[position.SongID] || seen[position.SongID] {
+			return nil, ErrTrackListMismatch
+		}
+		seen[position.SongID] = true
+
+		disc := position.DiscNumber
+		if disc == 0 {
+			disc = 1
+		}
+		if disc < 0 {
+			return nil, ErrInvalidTrackNumber
+		}
+		next[disc]++
+		tracks = append(tracks, models.AlbumTrack{
+			DiscNumber:  disc,
+			TrackNumber: next[disc],
+			Song:        models.Song{ID: position.SongID},
+		})
+	}
+
+	return tracks, nil
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+// affectedOrNotFound turns an UPDATE or DELETE that touched no rows into
+// notFound.
+func affectedOrNotFound(result sql.Result, err error, notFound error) error {
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return notFound
+	}
+	return nil
+}
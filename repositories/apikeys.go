@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"case/models"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKeyStore is the storage contract used by services.APIKeyService.
+type APIKeyStore interface {
+	GetAPIKeys() ([]models.APIKey, error)
+	GetAPIKey(id int) (models.APIKey, error)
+	FindAPIKeyByPrefix(prefix string) (models.APIKey, error)
+	AddAPIKey(key *models.APIKey) error
+	// RotateAPIKey replaces the prefix and hash of a key that is not revoked.
+	RotateAPIKey(id int, prefix, hash string) error
+	// RevokeAPIKey keeps the time of the first revocation.
+	RevokeAPIKey(id int, at time.Time) error
+	// TouchAPIKey records a use of the key. Stores may skip the write when
+	// the last recorded use is recent.
+	TouchAPIKey(id int, at time.Time) error
+}
+
+// touchInterval is how stale last_used_at may get before it is written again,
+// so that busy keys do not cost a write per request.
+const touchInterval = time.Minute
+
+type APIKeyRepository struct {
+	db  *sql.DB
+	log *logrus.Logger
+}
+
+func NewAPIKeyRepository(db *sql.DB, log *logrus.Logger) *APIKeyRepository {
+	return &APIKeyRepository{db: db, log: log}
+}
+
+const apiKeyColumns = `id, name, prefix, key_hash, scopes, created_at, expires_at, last_used_at, revoked_at`
+
+func apiKeyFields(key *models.APIKey) []interface{} {
+	return []interface{}{&key.ID, &key.Name, &key.Prefix, &key.Hash, pq.Array(&key.Scopes),
+		&key.CreatedAt, &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt}
+}
+
+func (r *APIKeyRepository) GetAPIKeys() ([]models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY id`
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error closing rows")
+		}
+	}(rows)
+
+	var keys []models.APIKey
+	for rows.Next() {
+		var key models.APIKey
+		if err := rows.Scan(apiKeyFields(&key)...); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (r *APIKeyRepository) GetAPIKey(id int) (models.APIKey, error) {
+	return r.queryAPIKey(`SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1`, id)
+}
+
+func (r *APIKeyRepository) FindAPIKeyByPrefix(prefix string) (models.APIKey, error) {
+	return r.queryAPIKey(`SELECT `+apiKeyColumns+` FROM api_keys WHERE prefix = $1`, prefix)
+}
+
+func (r *APIKeyRepository) queryAPIKey(query string, arg interface{}) (models.APIKey, error) {
+	var key models.APIKey
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	err := r.db.QueryRow(query, arg).Scan(apiKeyFields(&key)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return key, ErrAPIKeyNotFound
+	}
+	return key, err
+}
+
+func (r *APIKeyRepository) AddAPIKey(key *models.APIKey) error {
+	query := `
+        INSERT INTO api_keys (name, prefix, key_hash, scopes, expires_at)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at
+    `
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	return r.db.QueryRow(query, key.Name, key.Prefix, key.Hash, pq.Array(nonNilStrings(key.Scopes)),
+		key.ExpiresAt).Scan(&key.ID, &key.CreatedAt)
+}
+
+func (r *APIKeyRepository) RotateAPIKey(id int, prefix, hash string) error {
+	query := `UPDATE api_keys SET prefix = $1, key_hash = $2 WHERE id = $3 AND revoked_at IS NULL`
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	result, err := r.db.Exec(query, prefix, hash, id)
+	return affectedOrNotFound(result, err, ErrAPIKeyNotFound)
+}
+
+func (r *APIKeyRepository) RevokeAPIKey(id int, at time.Time) error {
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $1) WHERE id = $2`
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	result, err := r.db.Exec(query, at, id)
+	return affectedOrNotFound(result, err, ErrAPIKeyNotFound)
+}
+
+func (r *APIKeyRepository) TouchAPIKey(id int, at time.Time) error {
+	query := `UPDATE api_keys SET last_used_at = $1 WHERE id = $2 AND (last_used_at IS NULL OR last_used_at < $3)`
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	_, err := r.db.Exec(query, at, id, at.Add(-touchInterval))
+	return err
+}
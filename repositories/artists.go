@@ -0,0 +1,213 @@
+package repositories
+
+import (
+	"case/models"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrArtistNotFound = errors.New("artist not found")
+	ErrArtistExists   = errors.New("artist with this name already exists")
+	ErrArtistHasSongs = errors.New("artist still has songs or albums")
+)
+
+// ArtistStore is the storage contract used by services.ArtistService.
+type ArtistStore interface {
+	GetArtists(nameContains string, page, limit int) ([]models.Artist, int, error)
+	GetArtist(id int) (models.Artist, error)
+	// FindArtistByName looks an artist up by normalized name or alias.
+	FindArtistByName(name string) (models.Artist, error)
+	AddArtist(artist *models.Artist) error
+	// UpdateArtist also renames the group of every song of the artist.
+	UpdateArtist(artist *models.Artist) error
+	DeleteArtist(id int) error
+}
+
+type ArtistRepository struct {
+	db  *sql.DB
+	log *logrus.Logger
+}
+
+func NewArtistRepository(db *sql.DB, log *logrus.Logger) *ArtistRepository {
+	return &ArtistRepository{db: db, log: log}
+}
+
+const artistColumns = `id, name, aliases, country, formed_year`
+
+func artistFields(artist *models.Artist) []interface{} {
+	return []interface{}{&artist.ID, &artist.Name, pq.Array(&artist.Aliases), &artist.Country, &artist.FormedYear}
+}
+
+func (r *ArtistRepository) GetArtists(nameContains string, page, limit int) ([]models.Artist, int, error) {
+	var b queryBuilder
+	if nameContains != "" {
+		pattern := b.arg("%" + escapeLike(nameContains) + "%")
+		b.where("(name ILIKE " + pattern + " OR EXISTS (SELECT 1 FROM unnest(aliases) alias WHERE alias ILIKE " + pattern + "))")
+	}
+	where := b.whereClause()
+	filterArgs := b.args
+
+	query := `SELECT ` + artistColumns + `, COUNT(*) OVER() FROM artists` + where + ` ORDER BY name, id`
+	query += " LIMIT " + b.arg(limit) + " OFFSET " + b.arg((page-1)*limit)
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	rows, err := r.db.Query(query, b.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error closing rows")
+		}
+	}(rows)
+
+	var artists []models.Artist
+	total := 0
+	for rows.Next() {
+		var artist models.Artist
+		if err := rows.Scan(append(artistFields(&artist), &total)...); err != nil {
+			return nil, 0, err
+		}
+		artists = append(artists, artist)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if len(artists) == 0 && page > 1 {
+		if err := r.db.QueryRow(`SELECT COUNT(*) FROM artists`+where, filterArgs...).Scan(&total); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return artists, total, nil
+}
+
+func (r *ArtistRepository) GetArtist(id int) (models.Artist, error) {
+	var artist models.Artist
+
+	query := `SELECT ` + artistColumns + ` FROM artists WHERE id = $1`
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	err := r.db.QueryRow(query, id).Scan(artistFields(&artist)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return artist, ErrArtistNotFound
+	}
+	return artist, err
+}
+
+func (r *ArtistRepository) FindArtistByName(name string) (models.Artist, error) {
+	var artist models.Artist
+
+	query := `
+        SELECT ` + artistColumns + ` FROM artists
+        WHERE normalized_name = $1
+           OR EXISTS (SELECT 1 FROM unnest(aliases) alias WHERE normalize_artist_name(alias) = $1)
+        ORDER BY normalized_name = $1 DESC, id
+        LIMIT 1
+    `
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	err := r.db.QueryRow(query, models.NormalizeArtistName(name)).Scan(artistFields(&artist)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return artist, ErrArtistNotFound
+	}
+	return artist, err
+}
+
+func (r *ArtistRepository) AddArtist(artist *models.Artist) error {
+	query := `
+        INSERT INTO artists (name, normalized_name, aliases, country, formed_year)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id
+    `
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	err := r.db.QueryRow(query, artist.Name, models.NormalizeArtistName(artist.Name), pq.Array(nonNilStrings(artist.Aliases)),
+		artist.Country, artist.FormedYear).Scan(&artist.ID)
+	if isUniqueViolation(err) {
+		return ErrArtistExists
+	}
+	return err
+}
+
+func (r *ArtistRepository) UpdateArtist(artist *models.Artist) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error rolling back transaction")
+		}
+	}(tx)
+
+	query := `UPDATE artists
+SET name = $1, normalized_name = $2, aliases = $3, country = $4, formed_year = $5
+WHERE id = $6
+`
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	result, err := tx.Exec(query, artist.Name, models.NormalizeArtistName(artist.Name), pq.Array(nonNilStrings(artist.Aliases)),
+		artist.Country, artist.FormedYear, artist.ID)
+	if isUniqueViolation(err) {
+		return ErrArtistExists
+	}
+	if err := affectedOrNotFound(result, err, ErrArtistNotFound); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE songs SET "group" = $1, version = version + 1, updated_at = now() WHERE artist_id = $2`, artist.Name, artist.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *ArtistRepository) DeleteArtist(id int) error {
+	query := "DELETE FROM artists WHERE id = $1"
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	result, err := r.db.Exec(query, id)
+	if isForeignKeyViolation(err) {
+		return ErrArtistHasSongs
+	}
+	return affectedOrNotFound(result, err, ErrArtistNotFound)
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"case/cache"
+	"case/models"
+	"encoding/json"
+	"math"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CachedSongStore is a read-through cache in front of a SongStore. It caches
+// the parsed lyrics, with the song's last write time, and the synchronized
+// lyrics of songs, which are read far more often than written, and drops them
+// on every write to the song; a read racing a write may still cache the old
+// lyrics until the TTL runs out. Other methods go straight to the store: song
+// metadata carries the version checked by conditional writes and must not be
+// served stale.
+type CachedSongStore struct {
+	SongStore
+	cache   cache.Cache
+	backend string
+	ttl     time.Duration
+	hits    atomic.Int64
+	misses  atomic.Int64
+	errors  atomic.Int64
+	log     *logrus.Logger
+}
+
+// NewCachedSongStore wraps store with c, whose entries live for ttl. backend
+// names c in the stats.
+func NewCachedSongStore(store SongStore, c cache.Cache, backend string, ttl time.Duration, log *logrus.Logger) *CachedSongStore {
+	return &CachedSongStore{SongStore: store, cache: c, backend: backend, ttl: ttl, log: log}
+}
+
+// Stats returns the hit and miss counters.
+func (s *CachedSongStore) Stats() models.CacheStats {
+	stats := models.CacheStats{
+		Backend: s.backend,
+		Hits:    s.hits.Load(),
+		Misses:  s.misses.Load(),
+		Errors:  s.errors.Load(),
+	}
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(lookups)
+	}
+	return stats
+}
+
+func (s *CachedSongStore) GetSongLyrics(id, page, limit int) (models.Lyrics, int, error) {
+	var lyrics models.Lyrics
+	err := s.readThrough(lyricsKey(id), &lyrics, func() (interface{}, error) {
+		all, _, err := s.SongStore.GetSongLyrics(id, 1, math.MaxInt32)
+		return all, err
+	})
+	if err != nil {
+		return models.Lyrics{}, 0, err
+	}
+
+	var total int
+	lyrics.Stanzas, total = pageStanzas(lyrics.Stanzas, page, limit)
+	return lyrics, total, nil
+}
+
+func (s *CachedSongStore) GetSyncedLyrics(songID int) ([]models.SyncedLine, error) {
+	var lines []models.SyncedLine
+	err := s.readThrough(syncedKey(songID), &lines, func() (interface{}, error) {
+		return s.SongStore.GetSyncedLyrics(songID)
+	})
+	return lines, err
+}
+
+func (s *CachedSongStore) SetSyncedLyrics(songID, version int, lines []models.SyncedLine) error {
+	defer s.invalidate(songID)
+	return s.SongStore.SetSyncedLyrics(songID, version, lines)
+}
+
+func (s *CachedSongStore) AddSong(song *models.Song) error {
+	if err := s.SongStore.AddSong(song); err != nil {
+		return err
+	}
+	// A shared cache may still hold entries of a song that had this id in a
+	// database that was reset since.
+	s.invalidate(song.ID)
+	return nil
+}
+
+func (s *CachedSongStore) AddSongs(songs []models.Song) error {
+	if err := s.SongStore.AddSongs(songs); err != nil {
+		return err
+	}
+	for _, song := range songs {
+		s.invalidate(song.ID)
+	}
+	return nil
+}
+
+func (s *CachedSongStore) UpdateSong(song *models.Song) error {
+	defer s.invalidate(song.ID)
+	return s.SongStore.UpdateSong(song)
+}
+
+func (s *CachedSongStore) DeleteSong(id, version int) error {
+	defer s.invalidate(id)
+	return s.SongStore.DeleteSong(id, version)
+}
+
+func (s *CachedSongStore) RestoreSong(id int) error {
+	defer s.invalidate(id)
+	return s.SongStore.RestoreSong(id)
+}
+
+// readThrough decodes the cached value of key into dst, or stores the result
+// of load under key and decodes that. Cache failures are logged and counted
+// but never fail the read.
+func (s *CachedSongStore) readThrough(key string, dst interface{}, load func() (interface{}, error)) error {
+	data, ok, err := s.cache.Get(key)
+	if err != nil {
+		s.cacheFailed(err, key)
+	}
+	if ok && json.Unmarshal(data, dst) == nil {
+		s.hits.Add(1)
+		return nil
+	}
+	s.misses.Add(1)
+
+	value, err := load()
+	if err != nil {
+		return err
+	}
+	data, err = json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if err := s.cache.Set(key, data, s.ttl); err != nil {
+		s.cacheFailed(err, key)
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// invalidate drops the cached entries of a song. It runs after the write
+// whether or not that succeeded, as a failed write may have been applied.
+func (s *CachedSongStore) invalidate(songID int) {
+	if err := s.cache.Delete(lyricsKey(songID), syncedKey(songID)); err != nil {
+		s.cacheFailed(err, lyricsKey(songID))
+	}
+}
+
+func (s *CachedSongStore) cacheFailed(err error, key string) {
+	s.errors.Add(1)
+	s.log.WithFields(logrus.Fields{
+		"error": err,
+		"key":   key,
+	}).Warn("Song cache failed")
+}
+
+func lyricsKey(songID int) string {
+	return "songs:" + strconv.Itoa(songID) + ":lyrics"
+}
+
+func syncedKey(songID int) string {
+	return "songs:" + strconv.Itoa(songID) + ":synced"
+}
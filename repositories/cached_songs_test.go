@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"case/cache"
+	"case/models"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newTestLogger() *logrus.Logger {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	return log
+}
+
+// newCachedStore returns a CachedSongStore over an in-memory store holding
+// one song, together with the cache behind it.
+func newCachedStore(t *testing.T) (*CachedSongStore, *cache.LRU, models.Song) {
+	t.Helper()
+
+	log := newTestLogger()
+	lru := cache.NewLRU(0)
+	store := NewCachedSongStore(NewMemorySongRepository(log), lru, "lru", time.Minute, log)
+
+	song := models.Song{Group: "Muse", Song: "Uprising", Text: "Paranoia is in bloom\n\nThey will not force us"}
+	if err := store.AddSong(&song); err != nil {
+		t.Fatalf("AddSong: %v", err)
+	}
+	return store, lru, song
+}
+
+func TestCachedSongStoreStats(t *testing.T) {
+	store, _, song := newCachedStore(t)
+
+	for i := 0; i < 3; i++ {
+		// Every page is served from the one cached list of stanzas.
+		lyrics, total, err := store.GetSongLyrics(song.ID, i%2+1, 1)
+		if err != nil {
+			t.Fatalf("GetSongLyrics: %v", err)
+		}
+		if total != 2 || len(lyrics.Stanzas) != 1 {
+			t.Fatalf("GetSongLyrics page %d = %d stanzas of %d; want 1 of 2", i%2+1, len(lyrics.Stanzas), total)
+		}
+		if !lyrics.UpdatedAt.Equal(song.UpdatedAt) {
+			t.Fatalf("GetSongLyrics updated at %v; want %v", lyrics.UpdatedAt, song.UpdatedAt)
+		}
+	}
+	if _, err := store.GetSyncedLyrics(song.ID); err != nil {
+		t.Fatalf("GetSyncedLyrics: %v", err)
+	}
+	if _, _, err := store.GetSongLyrics(song.ID+1, 1, 10); !errors.Is(err, ErrSongNotFound) {
+		t.Fatalf("GetSongLyrics of a missing song: %v; want ErrSongNotFound", err)
+	}
+
+	stats := store.Stats()
+	want := models.CacheStats{Backend: "lru", Hits: 2, Misses: 3, HitRatio: 0.4}
+	if stats != want {
+		t.Errorf("Stats() = %+v; want %+v", stats, want)
+	}
+}
+
+func TestCachedSongStoreInvalidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(store *CachedSongStore, song models.Song) (int, error)
+	}{
+		{"UpdateSong", func(store *CachedSongStore, song models.Song) (int, error) {
+			song.Text = "Another verse"
+			return song.ID, store.UpdateSong(&song)
+		}},
+		{"SetSyncedLyrics", func(store *CachedSongStore, song models.Song) (int, error) {
+			return song.ID, store.SetSyncedLyrics(song.ID, 0, []models.SyncedLine{{Index: 1, TimeMs: 1000, Text: "Paranoia"}})
+		}},
+		{"DeleteSong", func(store *CachedSongStore, song models.Song) (int, error) {
+			return song.ID, store.DeleteSong(song.ID, 0)
+		}},
+		{"RestoreSong", func(store *CachedSongStore, song models.Song) (int, error) {
+			if err := store.SongStore.DeleteSong(song.ID, 0); err != nil {
+				return 0, err
+			}
+			return song.ID, store.RestoreSong(song.ID)
+		}},
+		{"AddSong", func(store *CachedSongStore, song models.Song) (int, error) {
+			// Entries left under the id of the next song, as after a
+			// database reset, must not leak into it.
+			next := models.Song{Group: "Muse", Song: "Resistance", Text: "Love is our resistance"}
+			err := store.AddSong(&next)
+			return next.ID, err
+		}},
+		{"AddSongs", func(store *CachedSongStore, song models.Song) (int, error) {
+			next := []models.Song{{Group: "Muse", Song: "Resistance", Text: "Love is our resistance"}}
+			err := store.AddSongs(next)
+			return next[0].ID, err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, lru, song := newCachedStore(t)
+
+			// Cache entries for this song and for the id the next one gets.
+			for _, id := range []int{song.ID, song.ID + 1} {
+				lru.Set(lyricsKey(id), []byte(`{"stanzas":[{"index":1,"type":"verse","lines":["stale"]}]}`), 0)
+				lru.Set(syncedKey(id), []byte(`[]`), 0)
+			}
+
+			id, err := tt.write(store, song)
+			if err != nil {
+				t.Fatalf("write: %v", err)
+			}
+
+			for _, key := range []string{lyricsKey(id), syncedKey(id)} {
+				if _, found, _ := lru.Get(key); found {
+					t.Errorf("%s still cached after %s", key, tt.name)
+				}
+			}
+		})
+	}
+}
+
+func TestCachedSongStoreServesFreshLyricsAfterUpdate(t *testing.T) {
+	store, _, song := newCachedStore(t)
+
+	if _, _, err := store.GetSongLyrics(song.ID, 1, 10); err != nil {
+		t.Fatalf("GetSongLyrics: %v", err)
+	}
+	song.Text = "Another verse"
+	if err := store.UpdateSong(&song); err != nil {
+		t.Fatalf("UpdateSong: %v", err)
+	}
+
+	lyrics, _, err := store.GetSongLyrics(song.ID, 1, 10)
+	if err != nil {
+		t.Fatalf("GetSongLyrics: %v", err)
+	}
+	if len(lyrics.Stanzas) != 1 || lyrics.Stanzas[0].Lines[0] != "Another verse" {
+		t.Errorf("GetSongLyrics = %+v; want the updated text", lyrics.Stanzas)
+	}
+	if !lyrics.UpdatedAt.Equal(song.UpdatedAt) {
+		t.Errorf("GetSongLyrics updated at %v; want the update's %v", lyrics.UpdatedAt, song.UpdatedAt)
+	}
+	if stats := store.Stats(); stats.Hits != 0 || stats.Misses != 2 {
+		t.Errorf("Stats() hits = %d, misses = %d; want 0 and 2", stats.Hits, stats.Misses)
+	}
+}
@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"case/models"
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+)
+
+// exportFetchSize is the number of rows fetched from the export cursor at a
+// time.
+const exportFetchSize = 1000
+
+// ExportSongs reads the songs through a server-side cursor in a read-only
+// repeatable read transaction, so the export is a consistent snapshot and
+// only one batch of rows is held at a time.
+func (r *SongRepository) ExportSongs(filter models.SongFilter, sort models.SongSort, fn func(models.Song) error) error {
+	var b queryBuilder
+	if err := b.applySongFilter(filter); err != nil {
+		return err
+	}
+	order, err := orderBy(sort)
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error rolling back transaction")
+		}
+	}(tx)
+
+	query := `DECLARE song_export NO SCROLL CURSOR FOR SELECT ` + songColumns + ` FROM songs` + b.whereClause() + order
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+		"args":  b.args,
+	}).Debug("Executing SQL query")
+
+	if _, err := tx.Exec(query, b.args...); err != nil {
+		return err
+	}
+
+	for {
+		fetched, err := r.fetchExport(tx, fn)
+		if err != nil {
+			return err
+		}
+		if fetched < exportFetchSize {
+			break
+		}
+	}
+
+	return tx.Commit()
+}
+
+// fetchExport passes the next batch of the export cursor to fn and returns
+// the number of rows in it.
+func (r *SongRepository) fetchExport(tx *sql.Tx, fn func(models.Song) error) (int, error) {
+	rows, err := tx.Query(`FETCH ` + strconv.Itoa(exportFetchSize) + ` FROM song_export`)
+	if err != nil {
+		return 0, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error closing rows")
+		}
+	}(rows)
+
+	fetched := 0
+	for rows.Next() {
+		var song models.Song
+		if err := rows.Scan(songFields(&song)...); err != nil {
+			return 0, err
+		}
+		fetched++
+		if err := fn(song); err != nil {
+			return 0, err
+		}
+	}
+
+	return fetched, rows.Err()
+}
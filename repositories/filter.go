@@ -0,0 +1,282 @@
+package repositories
+
+import (
+	"case/models"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+var filterColumns = map[string]string{
+	"group": `"group"`,
+	"song":  "song",
+	"link":  "link",
+	"text":  "text",
+}
+
+var sortColumns = map[string]string{
+	"id":           "id",
+	"group":        `"group"`,
+	"song":         "song",
+	"release_date": "release_date",
+}
+
+// queryBuilder accumulates WHERE conditions and their positional arguments.
+type queryBuilder struct {
+	conditions []string
+	args       []interface{}
+}
+
+func (b *queryBuilder) arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+func (b *queryBuilder) where(condition string) {
+	b.conditions = append(b.conditions, condition)
+}
+
+func (b *queryBuilder) whereClause() string {
+	if len(b.conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(b.conditions, " AND ")
+}
+
+// applySongFilter also excludes songs in the trash.
+func (b *queryBuilder) applySongFilter(filter models.SongFilter) error {
+	b.where("deleted_at IS NULL")
+
+	if filter.ArtistID != nil {
+		b.where("artist_id = " + b.arg(*filter.ArtistID))
+	}
+
+	for _, m := range filter.Matches {
+		column, ok := filterColumns[m.Field]
+		if !ok {
+			return fmt.Errorf("unknown filter field %q", m.Field)
+		}
+
+		switch m.Match {
+		case models.MatchExact:
+			b.where(column + " = " + b.arg(m.Value))
+		case models.MatchContains:
+			b.where(column + " ILIKE " + b.arg("%"+escapeLike(m.Value)+"%"))
+		case models.MatchPrefix:
+			b.where(column + " ILIKE " + b.arg(escapeLike(m.Value)+"%"))
+		default:
+			return fmt.Errorf("unknown match type %q", m.Match)
+		}
+	}
+
+	if filter.ReleasedAfter != nil {
+		b.where("release_date >= " + b.arg(*filter.ReleasedAfter))
+	}
+	if filter.ReleasedBefore != nil {
+		b.where("release_date <= " + b.arg(*filter.ReleasedBefore))
+	}
+
+	if tagIDs := uniqueIDs(filter.TagIDs); len(tagIDs) > 0 {
+		tagged := "SELECT song_id FROM song_tags WHERE tag_id = ANY(" + b.arg(pq.Array(tagIDs)) + ")"
+		if filter.TagMode == models.TagMatchAny {
+			b.where("id IN (" + tagged + ")")
+		} else {
+			b.where("id IN (" + tagged + " GROUP BY song_id HAVING COUNT(*) = " + b.arg(len(tagIDs)) + ")")
+		}
+	}
+
+	return nil
+}
+
+func uniqueIDs(ids []int) []int64 {
+	seen := make(map[int]bool, len(ids))
+	unique := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, int64(id))
+		}
+	}
+	return unique
+}
+
+// orderBy renders an ORDER BY clause for sort with id as the tie-breaker.
+func orderBy(sort models.SongSort) (string, error) {
+	column, ok := sortColumns[sort.Field]
+	if !ok {
+		return "", fmt.Errorf("unknown sort field %q", sort.Field)
+	}
+
+	direction := " ASC"
+	if sort.Desc {
+		direction = " DESC"
+	}
+	if column == "id" {
+		return " ORDER BY id" + direction, nil
+	}
+	return " ORDER BY " + column + direction + " NULLS LAST, id" + direction, nil
+}
+
+// applyCursor restricts the query to rows that come after cursor in the
+// cursor's ordering, keeping NULL release dates last in both directions.
+func (b *queryBuilder) applyCursor(cursor models.SongCursor) error {
+	column, ok := sortColumns[cursor.Sort]
+	if !ok {
+		return fmt.Errorf("unknown sort field %q", cursor.Sort)
+	}
+
+	op := " > "
+	if cursor.Desc {
+		op = " < "
+	}
+
+	switch {
+	case column == "id":
+		b.where("id" + op + b.arg(cursor.ID))
+	case cursor.Key == nil:
+		b.where(column + " IS NULL AND id" + op + b.arg(cursor.ID))
+	case column == "release_date":
+		key := b.arg(*cursor.Key) + "::date"
+		id := b.arg(cursor.ID)
+		b.where("(" + column + op + key + " OR (" + column + " = " + key + " AND id" + op + id + ") OR " + column + " IS NULL)")
+	default:
+		b.where("(" + column + ", id)" + op + "(" + b.arg(*cursor.Key) + ", " + b.arg(cursor.ID) + ")")
+	}
+
+	return nil
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// matchSong mirrors applySongFilter for stores that filter in Go; tagIDs
+// are the tags the song carries.
+func matchSong(song models.Song, tagIDs []int, filter models.SongFilter) (bool, error) {
+	if filter.ArtistID != nil && song.ArtistID != *filter.ArtistID {
+		return false, nil
+	}
+
+	if len(filter.TagIDs) > 0 && !matchTags(tagIDs, filter) {
+		return false, nil
+	}
+
+	for _, m := range filter.Matches {
+		var value string
+		switch m.Field {
+		case "group":
+			value = song.Group
+		case "song":
+			value = song.Song
+		case "link":
+			value = song.Link
+		case "text":
+			value = song.Text
+		default:
+			return false, fmt.Errorf("unknown filter field %q", m.Field)
+		}
+
+		switch m.Match {
+		case models.MatchExact:
+			if value != m.Value {
+				return false, nil
+			}
+		case models.MatchContains:
+			if !strings.Contains(strings.ToLower(value), strings.ToLower(m.Value)) {
+				return false, nil
+			}
+		case models.MatchPrefix:
+			if !strings.HasPrefix(strings.ToLower(value), strings.ToLower(m.Value)) {
+				return false, nil
+			}
+		default:
+			return false, fmt.Errorf("unknown match type %q", m.Match)
+		}
+	}
+
+	if filter.ReleasedAfter != nil || filter.ReleasedBefore != nil {
+		if song.ReleaseDate.IsZero() {
+			return false, nil
+		}
+		if filter.ReleasedAfter != nil && song.ReleaseDate.Before(filter.ReleasedAfter.Time) {
+			return false, nil
+		}
+		if filter.ReleasedBefore != nil && song.ReleaseDate.After(filter.ReleasedBefore.Time) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func matchTags(tagIDs []int, filter models.SongFilter) bool {
+	has := make(map[int]bool, len(tagIDs))
+	for _, id := range tagIDs {
+		has[id] = true
+	}
+
+	for _, id := range filter.TagIDs {
+		if has[id] && filter.TagMode == models.TagMatchAny {
+			return true
+		}
+		if !has[id] && filter.TagMode != models.TagMatchAny {
+			return false
+		}
+	}
+	return filter.TagMode != models.TagMatchAny
+}
+
+// lessSong mirrors orderBy for stores that sort in Go.
+func lessSong(a, b models.Song, sort models.SongSort) bool {
+	var cmp int
+	switch sort.Field {
+	case "group":
+		cmp = strings.Compare(a.Group, b.Group)
+	case "song":
+		cmp = strings.Compare(a.Song, b.Song)
+	case "release_date":
+		switch {
+		case a.ReleaseDate.IsZero() && b.ReleaseDate.IsZero():
+		case a.ReleaseDate.IsZero():
+			return false
+		case b.ReleaseDate.IsZero():
+			return true
+		default:
+			cmp = a.ReleaseDate.Compare(b.ReleaseDate.Time)
+		}
+	}
+
+	if cmp == 0 {
+		cmp = a.ID - b.ID
+	}
+	if sort.Desc {
+		return cmp > 0
+	}
+	return cmp < 0
+}
+
+// cursorSong builds a song carrying the cursor's sort key and id so it can be
+// compared with lessSong.
+func cursorSong(cursor models.SongCursor) (models.Song, error) {
+	song := models.Song{ID: cursor.ID}
+	if cursor.Key == nil {
+		return song, nil
+	}
+
+	switch cursor.Sort {
+	case "group":
+		song.Group = *cursor.Key
+	case "song":
+		song.Song = *cursor.Key
+	case "release_date":
+		date, err := models.ParseDate(*cursor.Key)
+		if err != nil {
+			return song, err
+		}
+		song.ReleaseDate = date
+	}
+
+	return song, nil
+}
@@ -0,0 +1,163 @@
+package repositories
+
+import (
+	"case/models"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// AddSongs streams songs into a temporary table with COPY and moves them to
+// songs from there. IDs are drawn from the sequence beforehand, so that each
+// can be matched to its input row.
+func (r *SongRepository) AddSongs(songs []models.Song) error {
+	if len(songs) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error rolling back transaction")
+		}
+	}(tx)
+
+	_, err = tx.Exec(`
+        CREATE TEMPORARY TABLE song_import (
+            ord INTEGER NOT NULL,
+            id INTEGER,
+            "group" TEXT NOT NULL,
+            song TEXT NOT NULL,
+            release_date DATE,
+            text TEXT NOT NULL,
+            link TEXT NOT NULL,
+            artist_id INTEGER NOT NULL
+        ) ON COMMIT DROP
+    `)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(pq.CopyIn("song_import", "ord", "group", "song", "release_date", "text", "link", "artist_id"))
+	if err != nil {
+		return err
+	}
+	for i, song := range songs {
+		if _, err := stmt.Exec(i, song.Group, song.Song, song.ReleaseDate, song.Text, song.Link, song.ArtistID); err != nil {
+			stmt.Close()
+			return err
+		}
+	}
+	if _, err := stmt.Exec(); err != nil {
+		stmt.Close()
+		return err
+	}
+	if err := stmt.Close(); err != nil {
+		return err
+	}
+
+	r.log.WithFields(logrus.Fields{
+		"songs": len(songs),
+	}).Debug("Copied songs to import")
+
+	queries := []string{
+		`UPDATE song_import SET id = nextval(pg_get_serial_sequence('songs', 'id'))`,
+		`INSERT INTO songs (id, "group", song, release_date, text, link, artist_id)
+        SELECT id, "group", song, release_date, text, link, artist_id FROM song_import`,
+		`INSERT INTO song_revisions (song_id, revision, "group", song, release_date, text, link, artist_id)
+        SELECT id, 1, "group", song, release_date, text, link, artist_id FROM song_import`,
+	}
+	for _, query := range queries {
+		r.log.WithFields(logrus.Fields{
+			"query": query,
+		}).Debug("Executing SQL query")
+
+		if _, err := tx.Exec(query); err != nil {
+			if isForeignKeyViolation(err) {
+				return ErrArtistNotFound
+			}
+			return err
+		}
+	}
+
+	rows, err := tx.Query(`SELECT i.ord, s.id, s.version, s.updated_at FROM song_import i JOIN songs s ON s.id = i.id`)
+	if err != nil {
+		return err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error closing rows")
+		}
+	}(rows)
+
+	for rows.Next() {
+		var i int
+		var song models.Song
+		if err := rows.Scan(&i, &song.ID, &song.Version, &song.UpdatedAt); err != nil {
+			return err
+		}
+		songs[i].ID, songs[i].Version, songs[i].UpdatedAt = song.ID, song.Version, song.UpdatedAt
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *SongRepository) GetExistingSongKeys(keys []models.SongKey) (map[models.SongKey]bool, error) {
+	existing := make(map[models.SongKey]bool)
+	if len(keys) == 0 {
+		return existing, nil
+	}
+
+	groups := make([]string, len(keys))
+	titles := make([]string, len(keys))
+	for i, key := range keys {
+		groups[i], titles[i] = key.Group, key.Song
+	}
+
+	query := `
+        SELECT DISTINCT k.g, k.s
+        FROM unnest($1::text[], $2::text[]) AS k (g, s)
+        JOIN songs ON lower(songs."group") = k.g AND lower(songs.song) = k.s
+        WHERE songs.deleted_at IS NULL
+    `
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	rows, err := r.db.Query(query, pq.Array(groups), pq.Array(titles))
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error closing rows")
+		}
+	}(rows)
+
+	for rows.Next() {
+		var key models.SongKey
+		if err := rows.Scan(&key.Group, &key.Song); err != nil {
+			return nil, err
+		}
+		existing[key] = true
+	}
+
+	return existing, rows.Err()
+}
@@ -0,0 +1,141 @@
+package repositories
+
+import (
+	"case/models"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+var ErrNoActiveLine = errors.New("no line is active at this offset")
+
+func (r *SongRepository) GetSyncedLyrics(songID int) ([]models.SyncedLine, error) {
+	if err := r.songExists(songID); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT line_no, time_ms, text FROM song_lyric_lines WHERE song_id = $1 ORDER BY line_no`
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	rows, err := r.db.Query(query, songID)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error closing rows")
+		}
+	}(rows)
+
+	lines := []models.SyncedLine{}
+	for rows.Next() {
+		var line models.SyncedLine
+		if err := rows.Scan(&line.Index, &line.TimeMs, &line.Text); err != nil {
+			return nil, err
+		}
+		line.Timestamp = models.FormatLRCTime(line.TimeMs)
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
+
+// SetSyncedLyrics replaces all time-stamped lines of a song.
+func (r *SongRepository) SetSyncedLyrics(songID, version int, lines []models.SyncedLine) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error rolling back transaction")
+		}
+	}(tx)
+
+	var exists int
+	err = tx.QueryRow(`UPDATE songs SET version = version + 1, updated_at = now()
+WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2) RETURNING 1`, songID, version).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return r.versionConflict(tx, songID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM song_lyric_lines WHERE song_id = $1`, songID); err != nil {
+		return err
+	}
+
+	// Insert in chunks to stay well below the 65535 bind parameter limit.
+	const chunk = 1000
+	for start := 0; start < len(lines); start += chunk {
+		end := min(start+chunk, len(lines))
+
+		var b queryBuilder
+		values := make([]string, 0, end-start)
+		for _, line := range lines[start:end] {
+			values = append(values, "("+b.arg(songID)+", "+b.arg(line.Index)+", "+b.arg(line.TimeMs)+", "+b.arg(line.Text)+")")
+		}
+
+		query := `INSERT INTO song_lyric_lines (song_id, line_no, time_ms, text) VALUES ` + strings.Join(values, ", ")
+		r.log.WithFields(logrus.Fields{
+			"lines": end - start,
+		}).Debug("Inserting synchronized lyrics")
+
+		if _, err := tx.Exec(query, b.args...); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetSyncedLineAt returns the line shown offsetMs milliseconds into the song.
+func (r *SongRepository) GetSyncedLineAt(songID, offsetMs int) (models.SyncedLine, error) {
+	var line models.SyncedLine
+	if err := r.songExists(songID); err != nil {
+		return line, err
+	}
+
+	query := `
+        SELECT line_no, time_ms, text FROM song_lyric_lines
+        WHERE song_id = $1 AND time_ms <= $2
+        ORDER BY time_ms DESC, line_no DESC
+        LIMIT 1
+    `
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	err := r.db.QueryRow(query, songID, offsetMs).Scan(&line.Index, &line.TimeMs, &line.Text)
+	if errors.Is(err, sql.ErrNoRows) {
+		return line, ErrNoActiveLine
+	}
+	if err != nil {
+		return line, err
+	}
+
+	line.Timestamp = models.FormatLRCTime(line.TimeMs)
+	return line, nil
+}
+
+func (r *SongRepository) songExists(id int) error {
+	var exists int
+	err := r.db.QueryRow(`SELECT 1 FROM songs WHERE id = $1 AND deleted_at IS NULL`, id).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrSongNotFound
+	}
+	return err
+}
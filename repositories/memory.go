@@ -0,0 +1,568 @@
+package repositories
+
+import (
+	"case/models"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MemorySongRepository is a thread-safe SongStore kept entirely in memory.
+type MemorySongRepository struct {
+	mu    sync.RWMutex
+	songs map[int]models.Song
+	// trash holds deleted songs until they are purged; they are absent from
+	// songs, so reads skip them.
+	trash  map[int]models.TrashedSong
+	synced map[int][]models.SyncedLine
+	tags   map[int][]int
+	// revisions holds every song's revisions, oldest first.
+	revisions map[int][]models.SongRevision
+	nextID    int
+	// lastModified is the time of the latest write to any song.
+	lastModified time.Time
+	// onDelete holds the afterDelete hooks.
+	onDelete []func(songID int)
+	log      *logrus.Logger
+}
+
+func NewMemorySongRepository(log *logrus.Logger) *MemorySongRepository {
+	return &MemorySongRepository{
+		songs:     make(map[int]models.Song),
+		trash:     make(map[int]models.TrashedSong),
+		synced:    make(map[int][]models.SyncedLine),
+		tags:      make(map[int][]int),
+		revisions: make(map[int][]models.SongRevision),
+		nextID:    1,
+		log:       log,
+	}
+}
+
+func (r *MemorySongRepository) GetSong(id int) (models.Song, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	song, ok := r.songs[id]
+	if !ok {
+		return song, ErrSongNotFound
+	}
+	return song, nil
+}
+
+func (r *MemorySongRepository) GetSongs(filter models.SongFilter, sort models.SongSort, page, limit int) ([]models.Song, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	r.log.WithFields(logrus.Fields{
+		"filter": filter,
+	}).Debug("Filtering songs in memory")
+
+	var songs []models.Song
+	for _, song := range r.sorted() {
+		ok, err := matchSong(song, r.tags[song.ID], filter)
+		if err != nil {
+			return nil, 0, err
+		}
+		if ok {
+			songs = append(songs, song)
+		}
+	}
+
+	sortSongs(songs, sort)
+
+	start := (page - 1) * limit
+	if start >= len(songs) {
+		return nil, len(songs), nil
+	}
+	end := start + limit
+	if end > len(songs) {
+		end = len(songs)
+	}
+
+	return songs[start:end], len(songs), nil
+}
+
+// ExportSongs copies the matching songs first so that fn runs without the
+// lock.
+func (r *MemorySongRepository) ExportSongs(filter models.SongFilter, sort models.SongSort, fn func(models.Song) error) error {
+	r.mu.RLock()
+	var songs []models.Song
+	for _, song := range r.sorted() {
+		ok, err := matchSong(song, r.tags[song.ID], filter)
+		if err != nil {
+			r.mu.RUnlock()
+			return err
+		}
+		if ok {
+			songs = append(songs, song)
+		}
+	}
+	r.mu.RUnlock()
+
+	sortSongs(songs, sort)
+	for _, song := range songs {
+		if err := fn(song); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *MemorySongRepository) GetSongsAfter(filter models.SongFilter, order models.SongSort, cursor *models.SongCursor, limit int) ([]models.Song, *models.SongCursor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var after models.Song
+	if cursor != nil {
+		order = cursor.SongSort()
+		song, err := cursorSong(*cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+		after = song
+	}
+
+	var songs []models.Song
+	for _, song := range r.songs {
+		ok, err := matchSong(song, r.tags[song.ID], filter)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok && (cursor == nil || lessSong(after, song, order)) {
+			songs = append(songs, song)
+		}
+	}
+	sortSongs(songs, order)
+
+	if len(songs) > limit+1 {
+		songs = songs[:limit+1]
+	}
+	songs, next := keysetPage(songs, order, limit)
+	return songs, next, nil
+}
+
+func (r *MemorySongRepository) GetSongLyrics(id, page, limit int) (models.Lyrics, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	song, ok := r.songs[id]
+	if !ok {
+		return models.Lyrics{}, 0, ErrSongNotFound
+	}
+
+	stanzas, total := paginateStanzas(song.Text, page, limit)
+	return models.Lyrics{Stanzas: stanzas, UpdatedAt: song.UpdatedAt}, total, nil
+}
+
+func (r *MemorySongRepository) DeleteSong(id, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	song, ok := r.songs[id]
+	if !ok {
+		return ErrSongNotFound
+	}
+	if version != 0 && song.Version != version {
+		return ErrVersionConflict
+	}
+	song.Version++
+	r.touch(&song)
+	delete(r.songs, id)
+	r.trash[id] = models.TrashedSong{Song: song, DeletedAt: song.UpdatedAt}
+	return nil
+}
+
+func (r *MemorySongRepository) GetTrashedSongs(page, limit int) ([]models.TrashedSong, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	songs := make([]models.TrashedSong, 0, len(r.trash))
+	for _, song := range r.trash {
+		songs = append(songs, song)
+	}
+	sort.Slice(songs, func(i, j int) bool {
+		if !songs[i].DeletedAt.Equal(songs[j].DeletedAt) {
+			return songs[i].DeletedAt.After(songs[j].DeletedAt)
+		}
+		return songs[i].Song.ID > songs[j].Song.ID
+	})
+
+	start := (page - 1) * limit
+	if start >= len(songs) {
+		return nil, len(songs), nil
+	}
+	end := min(start+limit, len(songs))
+
+	return songs[start:end], len(songs), nil
+}
+
+func (r *MemorySongRepository) RestoreSong(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	trashed, ok := r.trash[id]
+	if !ok {
+		return ErrSongNotFound
+	}
+	delete(r.trash, id)
+	trashed.Song.Version++
+	r.touch(&trashed.Song)
+	r.songs[id] = trashed.Song
+	return nil
+}
+
+func (r *MemorySongRepository) PurgeSongs(cutoff time.Time) (int, error) {
+	r.mu.Lock()
+	var purged []int
+	for id, trashed := range r.trash {
+		if trashed.DeletedAt.Before(cutoff) {
+			purged = append(purged, id)
+			delete(r.trash, id)
+			delete(r.synced, id)
+			delete(r.tags, id)
+			delete(r.revisions, id)
+		}
+	}
+	hooks := r.onDelete
+	r.mu.Unlock()
+
+	// Run outside the lock: hooks belong to stores that read songs while
+	// holding their own locks.
+	for _, id := range purged {
+		for _, hook := range hooks {
+			hook(id)
+		}
+	}
+	return len(purged), nil
+}
+
+func (r *MemorySongRepository) GetSongsLastModified() (time.Time, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.lastModified, nil
+}
+
+// touch stamps a write to song; the caller must hold the lock.
+func (r *MemorySongRepository) touch(song *models.Song) {
+	song.UpdatedAt = time.Now().UTC()
+	r.lastModified = song.UpdatedAt
+}
+
+// exists reports whether a song exists, in the trash or not.
+func (r *MemorySongRepository) exists(id int) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, live := r.songs[id]
+	_, trashed := r.trash[id]
+	return live || trashed
+}
+
+// afterDelete registers a hook run with the id of every purged song, the
+// in-memory counterpart of ON DELETE CASCADE.
+func (r *MemorySongRepository) afterDelete(hook func(songID int)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.onDelete = append(r.onDelete, hook)
+}
+
+func (r *MemorySongRepository) UpdateSong(song *models.Song) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.songs[song.ID]
+	if !ok {
+		return ErrSongNotFound
+	}
+	if song.Version != 0 && current.Version != song.Version {
+		return ErrVersionConflict
+	}
+	song.Version = current.Version + 1
+	r.touch(song)
+	r.songs[song.ID] = *song
+	r.addRevision(*song)
+	return nil
+}
+
+func (r *MemorySongRepository) AddSong(song *models.Song) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	song.ID = r.nextID
+	song.Version = 1
+	r.touch(song)
+	r.nextID++
+	r.songs[song.ID] = *song
+	r.addRevision(*song)
+	return nil
+}
+
+func (r *MemorySongRepository) AddSongs(songs []models.Song) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range songs {
+		songs[i].ID = r.nextID
+		songs[i].Version = 1
+		r.touch(&songs[i])
+		r.nextID++
+		r.songs[songs[i].ID] = songs[i]
+		r.addRevision(songs[i])
+	}
+	return nil
+}
+
+func (r *MemorySongRepository) GetExistingSongKeys(keys []models.SongKey) (map[models.SongKey]bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := make(map[models.SongKey]bool, len(keys))
+	for _, key := range keys {
+		wanted[key] = true
+	}
+
+	existing := make(map[models.SongKey]bool)
+	for _, song := range r.songs {
+		if key := song.Key(); wanted[key] {
+			existing[key] = true
+		}
+	}
+	return existing, nil
+}
+
+// addRevision snapshots song; the caller must hold the lock.
+func (r *MemorySongRepository) addRevision(song models.Song) {
+	revisions := r.revisions[song.ID]
+	r.revisions[song.ID] = append(revisions, models.SongRevision{
+		Revision:  len(revisions) + 1,
+		CreatedAt: time.Now().UTC(),
+		Song:      song,
+	})
+}
+
+func (r *MemorySongRepository) GetSongRevisions(songID, page, limit int) ([]models.SongRevision, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.songs[songID]; !ok {
+		return nil, 0, ErrSongNotFound
+	}
+
+	stored := r.revisions[songID]
+	start := (page - 1) * limit
+	if start >= len(stored) {
+		return nil, len(stored), nil
+	}
+	end := min(start+limit, len(stored))
+
+	revisions := make([]models.SongRevision, 0, end-start)
+	for i := start; i < end; i++ {
+		revisions = append(revisions, stored[len(stored)-1-i])
+	}
+	return revisions, len(stored), nil
+}
+
+func (r *MemorySongRepository) GetSongRevision(songID, revision int) (models.SongRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.songs[songID]; !ok {
+		return models.SongRevision{}, ErrSongNotFound
+	}
+
+	stored := r.revisions[songID]
+	if revision < 1 || revision > len(stored) {
+		return models.SongRevision{}, ErrRevisionNotFound
+	}
+	return stored[revision-1], nil
+}
+
+// sorted returns songs ordered by id; the caller must hold the lock.
+func (r *MemorySongRepository) sorted() []models.Song {
+	songs := make([]models.Song, 0, len(r.songs))
+	for _, song := range r.songs {
+		songs = append(songs, song)
+	}
+	sortSongs(songs, models.SongSort{Field: "id"})
+	return songs
+}
+
+func sortSongs(songs []models.Song, order models.SongSort) {
+	sort.SliceStable(songs, func(i, j int) bool { return lessSong(songs[i], songs[j], order) })
+}
+
+func (r *MemorySongRepository) SearchSongs(query models.SearchQuery, page, limit int) ([]models.SongSearchResult, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var results []models.SongSearchResult
+	for _, song := range r.songs {
+		if result, ok := searchSong(song, query); ok {
+			results = append(results, result)
+		}
+	}
+	sortSearchResults(results)
+
+	start := (page - 1) * limit
+	if start >= len(results) {
+		return nil, nil
+	}
+	end := start + limit
+	if end > len(results) {
+		end = len(results)
+	}
+
+	return results[start:end], nil
+}
+
+func (r *MemorySongRepository) GetSyncedLyrics(songID int) ([]models.SyncedLine, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.songs[songID]; !ok {
+		return nil, ErrSongNotFound
+	}
+	return append([]models.SyncedLine{}, r.synced[songID]...), nil
+}
+
+func (r *MemorySongRepository) SetSyncedLyrics(songID, version int, lines []models.SyncedLine) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	song, ok := r.songs[songID]
+	if !ok {
+		return ErrSongNotFound
+	}
+	if version != 0 && song.Version != version {
+		return ErrVersionConflict
+	}
+	song.Version++
+	r.touch(&song)
+	r.songs[songID] = song
+	r.synced[songID] = append([]models.SyncedLine{}, lines...)
+	return nil
+}
+
+func (r *MemorySongRepository) GetSyncedLineAt(songID, offsetMs int) (models.SyncedLine, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.songs[songID]; !ok {
+		return models.SyncedLine{}, ErrSongNotFound
+	}
+
+	lines := r.synced[songID]
+	i := sort.Search(len(lines), func(i int) bool { return lines[i].TimeMs > offsetMs })
+	if i == 0 {
+		return models.SyncedLine{}, ErrNoActiveLine
+	}
+	return lines[i-1], nil
+}
+
+func (r *MemorySongRepository) renameArtist(artistID int, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, song := range r.songs {
+		if song.ArtistID == artistID {
+			song.Group = name
+			song.Version++
+			r.touch(&song)
+			r.songs[id] = song
+		}
+	}
+	for id, trashed := range r.trash {
+		if trashed.Song.ArtistID == artistID {
+			trashed.Song.Group = name
+			trashed.Song.Version++
+			r.touch(&trashed.Song)
+			r.trash[id] = trashed
+		}
+	}
+}
+
+func (r *MemorySongRepository) hasArtist(artistID int) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, song := range r.songs {
+		if song.ArtistID == artistID {
+			return true
+		}
+	}
+	for _, trashed := range r.trash {
+		if trashed.Song.ArtistID == artistID {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemorySongRepository) songTags(songID int) ([]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.songs[songID]; !ok {
+		return nil, ErrSongNotFound
+	}
+	return append([]int(nil), r.tags[songID]...), nil
+}
+
+func (r *MemorySongRepository) setSongTags(songID int, tagIDs []int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	song, ok := r.songs[songID]
+	if !ok {
+		return ErrSongNotFound
+	}
+	r.touch(&song)
+	r.songs[songID] = song
+	ids := make([]int, 0, len(tagIDs))
+	for _, id := range uniqueIDs(tagIDs) {
+		ids = append(ids, int(id))
+	}
+	r.tags[songID] = ids
+	return nil
+}
+
+// untag removes a deleted tag from every song.
+func (r *MemorySongRepository) untag(tagID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for songID, ids := range r.tags {
+		kept := ids[:0]
+		for _, id := range ids {
+			if id != tagID {
+				kept = append(kept, id)
+			}
+		}
+		r.tags[songID] = kept
+	}
+}
+
+// tagCounts counts the songs matching filter per tag id.
+func (r *MemorySongRepository) tagCounts(filter models.SongFilter) (map[int]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := make(map[int]int)
+	for _, song := range r.songs {
+		ok, err := matchSong(song, r.tags[song.ID], filter)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		for _, id := range r.tags[song.ID] {
+			counts[id]++
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,268 @@
+package repositories
+
+import (
+	"case/models"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MemoryAlbumRepository is a thread-safe AlbumStore kept in memory. Tracks
+// reference songs of a MemorySongRepository by id; tracks whose song has
+// been deleted are dropped the next time the album is touched, like the
+// ON DELETE CASCADE of album_tracks.
+type MemoryAlbumRepository struct {
+	mu     sync.RWMutex
+	albums map[int]models.Album
+	tracks map[int][]memoryTrack
+	nextID int
+	songs  *MemorySongRepository
+	log    *logrus.Logger
+}
+
+type memoryTrack struct {
+	songID, disc, number int
+}
+
+func NewMemoryAlbumRepository(songs *MemorySongRepository, artists *MemoryArtistRepository, log *logrus.Logger) *MemoryAlbumRepository {
+	r := &MemoryAlbumRepository{
+		albums: make(map[int]models.Album),
+		tracks: make(map[int][]memoryTrack),
+		nextID: 1,
+		songs:  songs,
+		log:    log,
+	}
+
+	artists.mu.Lock()
+	artists.inUse = append(artists.inUse, r.hasArtist)
+	artists.mu.Unlock()
+
+	return r
+}
+
+func (r *MemoryAlbumRepository) GetAlbums(artistID *int, page, limit int) ([]models.Album, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var albums []models.Album
+	for _, album := range r.albums {
+		if artistID == nil || album.ArtistID == *artistID {
+			albums = append(albums, album)
+		}
+	}
+	sort.Slice(albums, func(i, j int) bool {
+		a, b := albums[i].ReleaseDate, albums[j].ReleaseDate
+		if a.IsZero() != b.IsZero() {
+			return b.IsZero()
+		}
+		if !a.Equal(b.Time) {
+			return a.Before(b.Time)
+		}
+		return albums[i].ID < albums[j].ID
+	})
+
+	start := (page - 1) * limit
+	if start >= len(albums) {
+		return nil, len(albums), nil
+	}
+	end := min(start+limit, len(albums))
+
+	return albums[start:end], len(albums), nil
+}
+
+func (r *MemoryAlbumRepository) GetAlbum(id int) (models.Album, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	album, ok := r.albums[id]
+	if !ok {
+		return album, ErrAlbumNotFound
+	}
+	return album, nil
+}
+
+func (r *MemoryAlbumRepository) GetAlbumTracks(id int) ([]models.AlbumTrack, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.albums[id]; !ok {
+		return nil, ErrAlbumNotFound
+	}
+
+	var tracks []models.AlbumTrack
+	for _, track := range r.tracks[id] {
+		song, err := r.songs.GetSong(track.songID)
+		if err != nil {
+			continue
+		}
+		tracks = append(tracks, models.AlbumTrack{
+			DiscNumber:  track.disc,
+			TrackNumber: track.number,
+			Song:        song,
+		})
+	}
+	return tracks, nil
+}
+
+func (r *MemoryAlbumRepository) AddAlbum(album *models.Album) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	album.ID = r.nextID
+	r.nextID++
+	r.albums[album.ID] = *album
+	return nil
+}
+
+func (r *MemoryAlbumRepository) UpdateAlbum(album *models.Album) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.albums[album.ID]; !ok {
+		return ErrAlbumNotFound
+	}
+	r.albums[album.ID] = *album
+	return nil
+}
+
+func (r *MemoryAlbumRepository) DeleteAlbum(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.albums[id]; !ok {
+		return ErrAlbumNotFound
+	}
+	delete(r.albums, id)
+	delete(r.tracks, id)
+	return nil
+}
+
+func (r *MemoryAlbumRepository) AddTrack(albumID, songID, disc, trackNumber int) error {
+	if disc < 1 || trackNumber < 0 {
+		return ErrInvalidTrackNumber
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tracks, err := r.liveTracks(albumID)
+	if err != nil {
+		return err
+	}
+	if _, err := r.songs.GetSong(songID); err != nil {
+		return err
+	}
+
+	last := 0
+	for _, track := range tracks {
+		if track.songID == songID {
+			return ErrTrackExists
+		}
+		if track.disc == disc {
+			last = max(last, track.number)
+		}
+	}
+	if trackNumber == 0 || trackNumber > last {
+		trackNumber = last + 1
+	}
+
+	for i := range tracks {
+		if tracks[i].disc == disc && tracks[i].number >= trackNumber {
+			tracks[i].number++
+		}
+	}
+	r.setTracks(albumID, append(tracks, memoryTrack{songID: songID, disc: disc, number: trackNumber}))
+	return nil
+}
+
+func (r *MemoryAlbumRepository) RemoveTrack(albumID, songID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tracks, err := r.liveTracks(albumID)
+	if err != nil {
+		return err
+	}
+
+	for i, removed := range tracks {
+		if removed.songID != songID {
+			continue
+		}
+		tracks = append(tracks[:i], tracks[i+1:]...)
+		for j := range tracks {
+			if tracks[j].disc == removed.disc && tracks[j].number > removed.number {
+				tracks[j].number--
+			}
+		}
+		r.setTracks(albumID, tracks)
+		return nil
+	}
+	return ErrTrackNotFound
+}
+
+func (r *MemoryAlbumRepository) ReorderTracks(albumID int, order []models.TrackPosition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tracks, err := r.liveTracks(albumID)
+	if err != nil {
+		return err
+	}
+
+	current := make(map[int]bool, len(tracks))
+	for _, track := range tracks {
+		current[track.songID] = true
+	}
+	numbered, err := numberTracks(current, order)
+	if err != nil {
+		return err
+	}
+
+	tracks = tracks[:0]
+	for _, track := range numbered {
+		tracks = append(tracks, memoryTrack{songID: track.Song.ID, disc: track.DiscNumber, number: track.TrackNumber})
+	}
+	r.setTracks(albumID, tracks)
+	return nil
+}
+
+// liveTracks returns a copy of the album's tracks without those whose song
+// was purged; the caller must hold the write lock.
+func (r *MemoryAlbumRepository) liveTracks(albumID int) ([]memoryTrack, error) {
+	if _, ok := r.albums[albumID]; !ok {
+		return nil, ErrAlbumNotFound
+	}
+
+	var tracks []memoryTrack
+	for _, track := range r.tracks[albumID] {
+		if r.songs.exists(track.songID) {
+			tracks = append(tracks, track)
+		}
+	}
+	return tracks, nil
+}
+
+// setTracks stores tracks ordered by disc and track number; the caller must
+// hold the write lock.
+func (r *MemoryAlbumRepository) setTracks(albumID int, tracks []memoryTrack) {
+	sort.Slice(tracks, func(i, j int) bool {
+		if tracks[i].disc != tracks[j].disc {
+			return tracks[i].disc < tracks[j].disc
+		}
+		return tracks[i].number < tracks[j].number
+	})
+	r.tracks[albumID] = tracks
+}
+
+func (r *MemoryAlbumRepository) hasArtist(artistID int) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, album := range r.albums {
+		if album.ArtistID == artistID {
+			return true
+		}
+	}
+	return false
+}
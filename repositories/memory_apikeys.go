@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"case/models"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MemoryAPIKeyRepository is a thread-safe APIKeyStore kept in memory.
+type MemoryAPIKeyRepository struct {
+	mu     sync.RWMutex
+	keys   map[int]models.APIKey
+	nextID int
+	log    *logrus.Logger
+}
+
+func NewMemoryAPIKeyRepository(log *logrus.Logger) *MemoryAPIKeyRepository {
+	return &MemoryAPIKeyRepository{
+		keys:   make(map[int]models.APIKey),
+		nextID: 1,
+		log:    log,
+	}
+}
+
+func (r *MemoryAPIKeyRepository) GetAPIKeys() ([]models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var keys []models.APIKey
+	for _, key := range r.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+	return keys, nil
+}
+
+func (r *MemoryAPIKeyRepository) GetAPIKey(id int) (models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.keys[id]
+	if !ok {
+		return key, ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+func (r *MemoryAPIKeyRepository) FindAPIKeyByPrefix(prefix string) (models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.Prefix == prefix {
+			return key, nil
+		}
+	}
+	return models.APIKey{}, ErrAPIKeyNotFound
+}
+
+func (r *MemoryAPIKeyRepository) AddAPIKey(key *models.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key.ID = r.nextID
+	key.CreatedAt = time.Now().UTC()
+	r.nextID++
+	r.keys[key.ID] = *key
+	return nil
+}
+
+func (r *MemoryAPIKeyRepository) RotateAPIKey(id int, prefix, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[id]
+	if !ok || key.RevokedAt != nil {
+		return ErrAPIKeyNotFound
+	}
+	key.Prefix = prefix
+	key.Hash = hash
+	r.keys[id] = key
+	return nil
+}
+
+func (r *MemoryAPIKeyRepository) RevokeAPIKey(id int, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[id]
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	if key.RevokedAt == nil {
+		key.RevokedAt = &at
+		r.keys[id] = key
+	}
+	return nil
+}
+
+func (r *MemoryAPIKeyRepository) TouchAPIKey(id int, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.keys[id]
+	if !ok {
+		return nil
+	}
+	if key.LastUsedAt == nil || key.LastUsedAt.Before(at.Add(-touchInterval)) {
+		key.LastUsedAt = &at
+		r.keys[id] = key
+	}
+	return nil
+}
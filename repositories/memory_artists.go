@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"case/models"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MemoryArtistRepository is a thread-safe ArtistStore kept in memory. It
+// shares songs with a MemorySongRepository to rename and guard them.
+type MemoryArtistRepository struct {
+	mu      sync.RWMutex
+	artists map[int]models.Artist
+	nextID  int
+	songs   *MemorySongRepository
+	// inUse stands in for the foreign keys that keep an artist from being
+	// deleted while songs or albums still reference it.
+	inUse []func(artistID int) bool
+	log   *logrus.Logger
+}
+
+func NewMemoryArtistRepository(songs *MemorySongRepository, log *logrus.Logger) *MemoryArtistRepository {
+	return &MemoryArtistRepository{
+		artists: make(map[int]models.Artist),
+		nextID:  1,
+		songs:   songs,
+		inUse:   []func(int) bool{songs.hasArtist},
+		log:     log,
+	}
+}
+
+func (r *MemoryArtistRepository) GetArtists(nameContains string, page, limit int) ([]models.Artist, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	needle := strings.ToLower(nameContains)
+	var artists []models.Artist
+	for _, artist := range r.artists {
+		if needle == "" || artistContains(artist, needle) {
+			artists = append(artists, artist)
+		}
+	}
+	sort.Slice(artists, func(i, j int) bool {
+		if artists[i].Name != artists[j].Name {
+			return artists[i].Name < artists[j].Name
+		}
+		return artists[i].ID < artists[j].ID
+	})
+
+	start := (page - 1) * limit
+	if start >= len(artists) {
+		return nil, len(artists), nil
+	}
+	end := min(start+limit, len(artists))
+
+	return artists[start:end], len(artists), nil
+}
+
+func artistContains(artist models.Artist, needle string) bool {
+	if strings.Contains(strings.ToLower(artist.Name), needle) {
+		return true
+	}
+	for _, alias := range artist.Aliases {
+		if strings.Contains(strings.ToLower(alias), needle) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryArtistRepository) GetArtist(id int) (models.Artist, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	artist, ok := r.artists[id]
+	if !ok {
+		return artist, ErrArtistNotFound
+	}
+	return artist, nil
+}
+
+func (r *MemoryArtistRepository) FindArtistByName(name string) (models.Artist, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key := models.NormalizeArtistName(name)
+	var byAlias *models.Artist
+	for _, artist := range r.artists {
+		if models.NormalizeArtistName(artist.Name) == key {
+			return artist, nil
+		}
+		for _, alias := range artist.Aliases {
+			if models.NormalizeArtistName(alias) == key && (byAlias == nil || artist.ID < byAlias.ID) {
+				found := artist
+				byAlias = &found
+			}
+		}
+	}
+	if byAlias != nil {
+		return *byAlias, nil
+	}
+	return models.Artist{}, ErrArtistNotFound
+}
+
+func (r *MemoryArtistRepository) AddArtist(artist *models.Artist) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.nameTaken(artist.Name, 0) {
+		return ErrArtistExists
+	}
+
+	artist.ID = r.nextID
+	r.nextID++
+	artist.Aliases = nonNilStrings(artist.Aliases)
+	r.artists[artist.ID] = *artist
+	return nil
+}
+
+func (r *MemoryArtistRepository) UpdateArtist(artist *models.Artist) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.artists[artist.ID]; !ok {
+		return ErrArtistNotFound
+	}
+	if r.nameTaken(artist.Name, artist.ID) {
+		return ErrArtistExists
+	}
+
+	artist.Aliases = nonNilStrings(artist.Aliases)
+	r.artists[artist.ID] = *artist
+	r.songs.renameArtist(artist.ID, artist.Name)
+	return nil
+}
+
+func (r *MemoryArtistRepository) DeleteArtist(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.artists[id]; !ok {
+		return ErrArtistNotFound
+	}
+	for _, referenced := range r.inUse {
+		if referenced(id) {
+			return ErrArtistHasSongs
+		}
+	}
+
+	delete(r.artists, id)
+	return nil
+}
+
+// nameTaken mirrors the unique normalized_name constraint; the caller must
+// hold the lock.
+func (r *MemoryArtistRepository) nameTaken(name string, except int) bool {
+	key := models.NormalizeArtistName(name)
+	for _, artist := range r.artists {
+		if artist.ID != except && models.NormalizeArtistName(artist.Name) == key {
+			return true
+		}
+	}
+	return false
+}
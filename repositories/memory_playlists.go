@@ -0,0 +1,241 @@
+package repositories
+
+import (
+	"case/models"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MemoryPlaylistRepository is a thread-safe PlaylistStore kept in memory.
+// Entries are kept in playlist order and removed when their song is deleted
+// from the shared MemorySongRepository.
+type MemoryPlaylistRepository struct {
+	mu          sync.RWMutex
+	playlists   map[int]models.Playlist
+	entries     map[int][]memoryEntry
+	nextID      int
+	nextEntryID int
+	songs       *MemorySongRepository
+	log         *logrus.Logger
+}
+
+type memoryEntry struct {
+	id, songID int
+}
+
+func NewMemoryPlaylistRepository(songs *MemorySongRepository, log *logrus.Logger) *MemoryPlaylistRepository {
+	r := &MemoryPlaylistRepository{
+		playlists:   make(map[int]models.Playlist),
+		entries:     make(map[int][]memoryEntry),
+		nextID:      1,
+		nextEntryID: 1,
+		songs:       songs,
+		log:         log,
+	}
+	songs.afterDelete(r.removeSong)
+	return r
+}
+
+func (r *MemoryPlaylistRepository) GetPlaylists(public *bool, viewer string, page, limit int) ([]models.Playlist, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var playlists []models.Playlist
+	for id := 1; id < r.nextID; id++ {
+		playlist, ok := r.playlists[id]
+		if ok && playlist.CanRead(viewer) && (public == nil || playlist.Public == *public) {
+			playlists = append(playlists, playlist)
+		}
+	}
+
+	start := (page - 1) * limit
+	if start >= len(playlists) {
+		return nil, len(playlists), nil
+	}
+	end := min(start+limit, len(playlists))
+
+	return playlists[start:end], len(playlists), nil
+}
+
+func (r *MemoryPlaylistRepository) GetPlaylist(id int) (models.Playlist, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	playlist, ok := r.playlists[id]
+	if !ok {
+		return playlist, ErrPlaylistNotFound
+	}
+	return playlist, nil
+}
+
+func (r *MemoryPlaylistRepository) GetPlaylistEntries(id int) ([]models.PlaylistEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, ok := r.playlists[id]; !ok {
+		return nil, ErrPlaylistNotFound
+	}
+
+	var entries []models.PlaylistEntry
+	for _, entry := range r.entries[id] {
+		song, err := r.songs.GetSong(entry.songID)
+		if err != nil {
+			// In the trash, or purged concurrently and removeSong is
+			// about to drop the entry.
+			continue
+		}
+		entries = append(entries, models.PlaylistEntry{ID: entry.id, Position: len(entries) + 1, Song: song})
+	}
+	return entries, nil
+}
+
+func (r *MemoryPlaylistRepository) AddPlaylist(playlist *models.Playlist) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	playlist.ID = r.nextID
+	r.nextID++
+	r.playlists[playlist.ID] = *playlist
+	return nil
+}
+
+func (r *MemoryPlaylistRepository) UpdatePlaylist(playlist *models.Playlist) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.playlists[playlist.ID]
+	if !ok {
+		return ErrPlaylistNotFound
+	}
+	playlist.Owner = existing.Owner
+	r.playlists[playlist.ID] = *playlist
+	return nil
+}
+
+func (r *MemoryPlaylistRepository) DeletePlaylist(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.playlists[id]; !ok {
+		return ErrPlaylistNotFound
+	}
+	delete(r.playlists, id)
+	delete(r.entries, id)
+	return nil
+}
+
+func (r *MemoryPlaylistRepository) AddEntry(playlistID, songID int, afterEntryID *int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.playlists[playlistID]; !ok {
+		return 0, ErrPlaylistNotFound
+	}
+	if _, err := r.songs.GetSong(songID); err != nil {
+		return 0, err
+	}
+
+	entries := r.entries[playlistID]
+	at, err := insertIndex(entries, afterEntryID)
+	if err != nil {
+		return 0, err
+	}
+
+	entry := memoryEntry{id: r.nextEntryID, songID: songID}
+	r.nextEntryID++
+	r.entries[playlistID] = insertEntry(entries, at, entry)
+	return entry.id, nil
+}
+
+func (r *MemoryPlaylistRepository) RemoveEntry(playlistID, entryID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.playlists[playlistID]; !ok {
+		return ErrPlaylistNotFound
+	}
+
+	entries := r.entries[playlistID]
+	i := entryIndex(entries, entryID)
+	if i < 0 {
+		return ErrEntryNotFound
+	}
+	r.entries[playlistID] = append(entries[:i:i], entries[i+1:]...)
+	return nil
+}
+
+func (r *MemoryPlaylistRepository) MoveEntry(playlistID, entryID int, afterEntryID *int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.playlists[playlistID]; !ok {
+		return ErrPlaylistNotFound
+	}
+
+	entries := r.entries[playlistID]
+	i := entryIndex(entries, entryID)
+	if i < 0 {
+		return ErrEntryNotFound
+	}
+	if afterEntryID != nil && *afterEntryID == entryID {
+		return nil
+	}
+
+	entry := entries[i]
+	entries = append(entries[:i:i], entries[i+1:]...)
+	at, err := insertIndex(entries, afterEntryID)
+	if err != nil {
+		return err
+	}
+	r.entries[playlistID] = insertEntry(entries, at, entry)
+	return nil
+}
+
+// removeSong drops every entry of a deleted song.
+func (r *MemoryPlaylistRepository) removeSong(songID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for playlistID, entries := range r.entries {
+		kept := entries[:0]
+		for _, entry := range entries {
+			if entry.songID != songID {
+				kept = append(kept, entry)
+			}
+		}
+		r.entries[playlistID] = kept
+	}
+}
+
+func entryIndex(entries []memoryEntry, entryID int) int {
+	for i, entry := range entries {
+		if entry.id == entryID {
+			return i
+		}
+	}
+	return -1
+}
+
+// insertIndex returns where an entry placed after afterEntryID goes.
+func insertIndex(entries []memoryEntry, afterEntryID *int) (int, error) {
+	switch {
+	case afterEntryID == nil:
+		return len(entries), nil
+	case *afterEntryID == 0:
+		return 0, nil
+	}
+
+	i := entryIndex(entries, *afterEntryID)
+	if i < 0 {
+		return 0, ErrEntryNotFound
+	}
+	return i + 1, nil
+}
+
+func insertEntry(entries []memoryEntry, at int, entry memoryEntry) []memoryEntry {
+	entries = append(entries, memoryEntry{})
+	copy(entries[at+1:], entries[at:])
+	entries[at] = entry
+	return entries
+}
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"case/models"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultRolePermissions mirrors the rows seeded by the roles migration.
+var defaultRolePermissions = map[string][]string{
+	models.RoleReader: {models.PermPlaylistsWrite},
+	models.RoleEditor: {models.PermPlaylistsWrite, models.PermSongsWrite, models.PermCatalogWrite},
+	models.RoleModerator: {
+		models.PermPlaylistsWrite, models.PermSongsWrite, models.PermCatalogWrite,
+		models.PermSongsDelete, models.PermCatalogDelete,
+	},
+	models.RoleAdmin: models.Permissions,
+}
+
+// MemoryRoleRepository is a thread-safe RoleStore kept in memory, seeded with
+// the default roles.
+type MemoryRoleRepository struct {
+	mu           sync.RWMutex
+	permissions  map[string][]string
+	subjectRoles map[string]map[string]bool
+	log          *logrus.Logger
+}
+
+func NewMemoryRoleRepository(log *logrus.Logger) *MemoryRoleRepository {
+	return &MemoryRoleRepository{
+		permissions:  defaultRolePermissions,
+		subjectRoles: make(map[string]map[string]bool),
+		log:          log,
+	}
+}
+
+func (r *MemoryRoleRepository) GetSubjectRoles(subject string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	roles := []string{}
+	for role := range r.subjectRoles[subject] {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles, nil
+}
+
+func (r *MemoryRoleRepository) GetRolePermissions(roles []string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	permissions := []string{}
+	for _, role := range roles {
+		for _, permission := range r.permissions[role] {
+			if !seen[permission] {
+				seen[permission] = true
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	sort.Strings(permissions)
+	return permissions, nil
+}
+
+func (r *MemoryRoleRepository) GrantRole(subject, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.permissions[role]; !ok {
+		return ErrRoleNotFound
+	}
+	if r.subjectRoles[subject] == nil {
+		r.subjectRoles[subject] = make(map[string]bool)
+	}
+	r.subjectRoles[subject][role] = true
+	return nil
+}
+
+func (r *MemoryRoleRepository) RevokeRole(subject, role string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.subjectRoles[subject][role] {
+		return ErrRoleNotFound
+	}
+	delete(r.subjectRoles[subject], role)
+	return nil
+}
@@ -0,0 +1,192 @@
+package repositories
+
+import (
+	"case/models"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MemoryTagRepository is a thread-safe TagStore kept in memory. Which songs
+// carry which tags is kept by the shared MemorySongRepository so it can
+// filter on them.
+type MemoryTagRepository struct {
+	mu     sync.RWMutex
+	tags   map[int]models.Tag
+	nextID int
+	songs  *MemorySongRepository
+	log    *logrus.Logger
+}
+
+func NewMemoryTagRepository(songs *MemorySongRepository, log *logrus.Logger) *MemoryTagRepository {
+	return &MemoryTagRepository{
+		tags:   make(map[int]models.Tag),
+		nextID: 1,
+		songs:  songs,
+		log:    log,
+	}
+}
+
+func (r *MemoryTagRepository) GetTags(kind models.TagKind, page, limit int) ([]models.Tag, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tags []models.Tag
+	for _, tag := range r.tags {
+		if kind == "" || tag.Kind == kind {
+			tags = append(tags, tag)
+		}
+	}
+	sortTags(tags)
+
+	start := (page - 1) * limit
+	if start >= len(tags) {
+		return nil, len(tags), nil
+	}
+	end := min(start+limit, len(tags))
+
+	return tags[start:end], len(tags), nil
+}
+
+func (r *MemoryTagRepository) GetTag(id int) (models.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tag, ok := r.tags[id]
+	if !ok {
+		return tag, ErrTagNotFound
+	}
+	return tag, nil
+}
+
+func (r *MemoryTagRepository) FindTags(names []string) ([]models.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	tags := []models.Tag{}
+	for _, tag := range r.tags {
+		if wanted[tag.Name] {
+			tags = append(tags, tag)
+		}
+	}
+	sortTags(tags)
+	return tags, nil
+}
+
+func (r *MemoryTagRepository) AddTag(tag *models.Tag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.nameTaken(tag.Name, 0) {
+		return ErrTagExists
+	}
+
+	tag.ID = r.nextID
+	r.nextID++
+	r.tags[tag.ID] = *tag
+	return nil
+}
+
+func (r *MemoryTagRepository) UpdateTag(tag *models.Tag) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tags[tag.ID]; !ok {
+		return ErrTagNotFound
+	}
+	if r.nameTaken(tag.Name, tag.ID) {
+		return ErrTagExists
+	}
+
+	r.tags[tag.ID] = *tag
+	return nil
+}
+
+func (r *MemoryTagRepository) DeleteTag(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tags[id]; !ok {
+		return ErrTagNotFound
+	}
+
+	delete(r.tags, id)
+	r.songs.untag(id)
+	return nil
+}
+
+func (r *MemoryTagRepository) GetSongTags(songID int) ([]models.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids, err := r.songs.songTags(songID)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []models.Tag{}
+	for _, id := range ids {
+		if tag, ok := r.tags[id]; ok {
+			tags = append(tags, tag)
+		}
+	}
+	sortTags(tags)
+	return tags, nil
+}
+
+func (r *MemoryTagRepository) SetSongTags(songID int, tagIDs []int) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, id := range tagIDs {
+		if _, ok := r.tags[id]; !ok {
+			return ErrTagNotFound
+		}
+	}
+	return r.songs.setSongTags(songID, tagIDs)
+}
+
+func (r *MemoryTagRepository) GetTagFacets(filter models.SongFilter) ([]models.TagFacet, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts, err := r.songs.tagCounts(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	facets := []models.TagFacet{}
+	for id, count := range counts {
+		if tag, ok := r.tags[id]; ok {
+			facets = append(facets, models.TagFacet{ID: tag.ID, Name: tag.Name, Kind: tag.Kind, Count: count})
+		}
+	}
+	sort.Slice(facets, func(i, j int) bool {
+		if facets[i].Count != facets[j].Count {
+			return facets[i].Count > facets[j].Count
+		}
+		return facets[i].Name < facets[j].Name
+	})
+	return facets, nil
+}
+
+// nameTaken mirrors the unique name constraint; the caller must hold the
+// lock.
+func (r *MemoryTagRepository) nameTaken(name string, except int) bool {
+	for _, tag := range r.tags {
+		if tag.ID != except && tag.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func sortTags(tags []models.Tag) {
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+}
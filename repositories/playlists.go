@@ -0,0 +1,347 @@
+package repositories
+
+import (
+	"case/models"
+	"database/sql"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrPlaylistNotFound = errors.New("playlist not found")
+	ErrEntryNotFound    = errors.New("playlist entry not found")
+)
+
+// PlaylistStore is the storage contract used by services.PlaylistService.
+// Entries are addressed by id rather than by index, so an edit lands where
+// the client meant it even if others changed the playlist in the meantime.
+type PlaylistStore interface {
+	// GetPlaylists lists the public playlists and the private ones owned by
+	// viewer by id, optionally only public or private ones.
+	GetPlaylists(public *bool, viewer string, page, limit int) ([]models.Playlist, int, error)
+	GetPlaylist(id int) (models.Playlist, error)
+	GetPlaylistEntries(id int) ([]models.PlaylistEntry, error)
+	AddPlaylist(playlist *models.Playlist) error
+	UpdatePlaylist(playlist *models.Playlist) error
+	DeletePlaylist(id int) error
+	// AddEntry inserts songID after the entry afterEntryID (0 for the start,
+	// nil for the end) and returns the new entry id.
+	AddEntry(playlistID, songID int, afterEntryID *int) (int, error)
+	RemoveEntry(playlistID, entryID int) error
+	// MoveEntry moves an entry after afterEntryID like AddEntry places new ones.
+	MoveEntry(playlistID, entryID int, afterEntryID *int) error
+}
+
+// positionGap is the distance between neighbouring entry positions after a
+// renumbering; it allows 16 inserts at the same spot before the next one.
+const positionGap = 1 << 16
+
+type PlaylistRepository struct {
+	db  *sql.DB
+	log *logrus.Logger
+}
+
+func NewPlaylistRepository(db *sql.DB, log *logrus.Logger) *PlaylistRepository {
+	return &PlaylistRepository{db: db, log: log}
+}
+
+func (r *PlaylistRepository) GetPlaylists(public *bool, viewer string, page, limit int) ([]models.Playlist, int, error) {
+	var b queryBuilder
+	b.where("(public OR owner = " + b.arg(viewer) + ")")
+	if public != nil {
+		b.where("public = " + b.arg(*public))
+	}
+	where := b.whereClause()
+	filterArgs := b.args
+
+	query := `SELECT id, name, public, owner, COUNT(*) OVER() FROM playlists` + where + ` ORDER BY id`
+	query += " LIMIT " + b.arg(limit) + " OFFSET " + b.arg((page-1)*limit)
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	rows, err := r.db.Query(query, b.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error closing rows")
+		}
+	}(rows)
+
+	var playlists []models.Playlist
+	total := 0
+	for rows.Next() {
+		var playlist models.Playlist
+		if err := rows.Scan(&playlist.ID, &playlist.Name, &playlist.Public, &playlist.Owner, &total); err != nil {
+			return nil, 0, err
+		}
+		playlists = append(playlists, playlist)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if len(playlists) == 0 && page > 1 {
+		if err := r.db.QueryRow(`SELECT COUNT(*) FROM playlists`+where, filterArgs...).Scan(&total); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return playlists, total, nil
+}
+
+func (r *PlaylistRepository) GetPlaylist(id int) (models.Playlist, error) {
+	var playlist models.Playlist
+
+	query := `SELECT id, name, public, owner FROM playlists WHERE id = $1`
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	err := r.db.QueryRow(query, id).Scan(&playlist.ID, &playlist.Name, &playlist.Public, &playlist.Owner)
+	if errors.Is(err, sql.ErrNoRows) {
+		return playlist, ErrPlaylistNotFound
+	}
+	return playlist, err
+}
+
+func (r *PlaylistRepository) GetPlaylistEntries(id int) ([]models.PlaylistEntry, error) {
+	query := `
+        SELECT e.id, s.id, s."group", s.song, s.release_date, s.text, s.link, s.artist_id
+        FROM playlist_entries e
+        JOIN songs s ON s.id = e.song_id
+        WHERE e.playlist_id = $1 AND s.deleted_at IS NULL
+        ORDER BY e.position
+    `
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	rows, err := r.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error closing rows")
+		}
+	}(rows)
+
+	var entries []models.PlaylistEntry
+	for rows.Next() {
+		entry := models.PlaylistEntry{Position: len(entries) + 1}
+		if err := rows.Scan(append([]interface{}{&entry.ID}, songFields(&entry.Song)...)...); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func (r *PlaylistRepository) AddPlaylist(playlist *models.Playlist) error {
+	query := `INSERT INTO playlists (name, public, owner) VALUES ($1, $2, $3) RETURNING id`
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	return r.db.QueryRow(query, playlist.Name, playlist.Public, playlist.Owner).Scan(&playlist.ID)
+}
+
+// UpdatePlaylist changes the name and visibility; the owner is kept and
+// copied into playlist.
+func (r *PlaylistRepository) UpdatePlaylist(playlist *models.Playlist) error {
+	query := `UPDATE playlists SET name = $1, public = $2 WHERE id = $3 RETURNING owner`
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	err := r.db.QueryRow(query, playlist.Name, playlist.Public, playlist.ID).Scan(&playlist.Owner)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPlaylistNotFound
+	}
+	return err
+}
+
+func (r *PlaylistRepository) DeletePlaylist(id int) error {
+	query := `DELETE FROM playlists WHERE id = $1`
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	result, err := r.db.Exec(query, id)
+	return affectedOrNotFound(result, err, ErrPlaylistNotFound)
+}
+
+func (r *PlaylistRepository) AddEntry(playlistID, songID int, afterEntryID *int) (int, error) {
+	var entryID int
+	err := r.inPlaylistTx(playlistID, func(tx *sql.Tx) error {
+		if err := lockLiveSong(tx, songID); err != nil {
+			return err
+		}
+		position, err := r.slot(tx, playlistID, afterEntryID, 0)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(`INSERT INTO playlist_entries (playlist_id, song_id, position) VALUES ($1, $2, $3) RETURNING id`,
+			playlistID, songID, position).Scan(&entryID)
+		if isForeignKeyViolation(err) {
+			return ErrSongNotFound
+		}
+		return err
+	})
+	return entryID, err
+}
+
+func (r *PlaylistRepository) RemoveEntry(playlistID, entryID int) error {
+	query := `DELETE FROM playlist_entries WHERE playlist_id = $1 AND id = $2`
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	result, err := r.db.Exec(query, playlistID, entryID)
+	err = affectedOrNotFound(result, err, ErrEntryNotFound)
+	if errors.Is(err, ErrEntryNotFound) {
+		// Tell a missing playlist apart from a missing entry.
+		if _, playlistErr := r.GetPlaylist(playlistID); playlistErr != nil {
+			return playlistErr
+		}
+	}
+	return err
+}
+
+func (r *PlaylistRepository) MoveEntry(playlistID, entryID int, afterEntryID *int) error {
+	return r.inPlaylistTx(playlistID, func(tx *sql.Tx) error {
+		var exists int
+		err := tx.QueryRow(`SELECT 1 FROM playlist_entries WHERE playlist_id = $1 AND id = $2`, playlistID, entryID).Scan(&exists)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrEntryNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if afterEntryID != nil && *afterEntryID == entryID {
+			return nil
+		}
+
+		position, err := r.slot(tx, playlistID, afterEntryID, entryID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`UPDATE playlist_entries SET position = $1 WHERE id = $2`, position, entryID)
+		return err
+	})
+}
+
+// slot finds a free position after the entry afterEntryID, ignoring the
+// entry being moved, and renumbers the playlist when the neighbours are
+// adjacent.
+func (r *PlaylistRepository) slot(tx *sql.Tx, playlistID int, afterEntryID *int, moving int) (int64, error) {
+	for attempt := 0; ; attempt++ {
+		var prev, next sql.NullInt64
+		var err error
+		switch {
+		case afterEntryID == nil:
+			err = tx.QueryRow(`SELECT MAX(position) FROM playlist_entries WHERE playlist_id = $1 AND id <> $2`,
+				playlistID, moving).Scan(&prev)
+		case *afterEntryID == 0:
+			err = tx.QueryRow(`SELECT MIN(position) FROM playlist_entries WHERE playlist_id = $1 AND id <> $2`,
+				playlistID, moving).Scan(&next)
+		default:
+			err = tx.QueryRow(`SELECT position FROM playlist_entries WHERE playlist_id = $1 AND id = $2`,
+				playlistID, *afterEntryID).Scan(&prev)
+			if errors.Is(err, sql.ErrNoRows) {
+				return 0, ErrEntryNotFound
+			}
+			if err == nil {
+				err = tx.QueryRow(`SELECT MIN(position) FROM playlist_entries WHERE playlist_id = $1 AND id <> $2 AND position > $3`,
+					playlistID, moving, prev.Int64).Scan(&next)
+			}
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		position, ok := positionBetween(prev, next)
+		if ok || attempt > 0 {
+			return position, nil
+		}
+
+		r.log.WithFields(logrus.Fields{
+			"playlist_id": playlistID,
+		}).Debug("Renumbering playlist entries")
+
+		_, err = tx.Exec(`UPDATE playlist_entries e SET position = o.n * $2
+FROM (SELECT id, row_number() OVER (ORDER BY position) AS n FROM playlist_entries WHERE playlist_id = $1) o
+WHERE e.id = o.id`, playlistID, positionGap)
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// positionBetween picks a position strictly between prev and next, either of
+// which may be missing. It reports false when the two are adjacent.
+func positionBetween(prev, next sql.NullInt64) (int64, bool) {
+	switch {
+	case !prev.Valid && !next.Valid:
+		return positionGap, true
+	case !prev.Valid:
+		return next.Int64 - positionGap, true
+	case !next.Valid:
+		return prev.Int64 + positionGap, true
+	case next.Int64-prev.Int64 < 2:
+		return 0, false
+	default:
+		return prev.Int64 + (next.Int64-prev.Int64)/2, true
+	}
+}
+
+// inPlaylistTx runs fn in a transaction holding a lock on the playlist row,
+// so concurrent edits of one playlist are applied one after another.
+func (r *PlaylistRepository) inPlaylistTx(playlistID int, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error rolling back transaction")
+		}
+	}(tx)
+
+	var exists int
+	err = tx.QueryRow(`SELECT 1 FROM playlists WHERE id = $1 FOR UPDATE`, playlistID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPlaylistNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -3,11 +3,28 @@ package repositories
 import (
 	"case/models"
 	"database/sql"
+	"errors"
+	"time"
+
 	"github.com/sirupsen/logrus"
-	"strconv"
-	"strings"
 )
 
+const songColumns = `id, "group", song, release_date, text, link, artist_id`
+
+// songFields returns scan destinations matching songColumns.
+func songFields(song *models.Song) []interface{} {
+	return []interface{}{&song.ID, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link, &song.ArtistID}
+}
+
+// storedSongColumns adds the columns only the songs table has, which the
+// memory store fills in as well.
+const storedSongColumns = songColumns + `, version, updated_at`
+
+// storedSongFields returns scan destinations matching storedSongColumns.
+func storedSongFields(song *models.Song) []interface{} {
+	return append(songFields(song), &song.Version, &song.UpdatedAt)
+}
+
 type SongRepository struct {
 	db  *sql.DB
 	log *logrus.Logger
@@ -17,34 +34,32 @@ func NewSongRepository(db *sql.DB, log *logrus.Logger) *SongRepository {
 	return &SongRepository{db: db, log: log}
 }
 
-func (r *SongRepository) GetSongs(filter map[string]string, page, limit int) ([]models.Song, error) {
-	query := `SELECT id, "group", song, release_date, text, link FROM songs`
-	var args []interface{}
-
-	if group, ok := filter["group"]; ok {
-		query += " AND \"group\" = $" + strconv.Itoa(len(args)+1)
-		args = append(args, group)
+// GetSongs returns the requested page together with the total number of
+// songs matching filter, counted in the same query via a window function.
+func (r *SongRepository) GetSongs(filter models.SongFilter, sort models.SongSort, page, limit int) ([]models.Song, int, error) {
+	var b queryBuilder
+	if err := b.applySongFilter(filter); err != nil {
+		return nil, 0, err
 	}
-
-	if song, ok := filter["song"]; ok {
-		query += " AND song = $" + strconv.Itoa(len(args)+1)
-		args = append(args, song)
+	order, err := orderBy(sort)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	query += " LIMIT $" + strconv.Itoa(len(args)+1)
-	args = append(args, limit)
-	query += " OFFSET $" + strconv.Itoa(len(args)+1)
-	args = append(args, (page-1)*limit)
+	where := b.whereClause()
+	filterArgs := b.args
+	query := `SELECT ` + storedSongColumns + `, COUNT(*) OVER() FROM songs` + where + order
+	query += " LIMIT " + b.arg(limit) + " OFFSET " + b.arg((page-1)*limit)
+	args := b.args
 
 	r.log.WithFields(logrus.Fields{
 		"query": query,
-		"group": filter["group"],
-		"song":  filter["song"],
+		"args":  args,
 	}).Debug("Executing SQL query")
 
 	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer func(rows *sql.Rows) {
 		err := rows.Close()
@@ -56,78 +71,316 @@ func (r *SongRepository) GetSongs(filter map[string]string, page, limit int) ([]
 	}(rows)
 
 	var songs []models.Song
+	total := 0
 	for rows.Next() {
 		var song models.Song
-		if err := rows.Scan(&song.ID, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link); err != nil {
-			return nil, err
+		if err := rows.Scan(append(storedSongFields(&song), &total)...); err != nil {
+			return nil, 0, err
 		}
 		songs = append(songs, song)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	// A page past the end yields no rows to carry the window count.
+	if len(songs) == 0 && page > 1 {
+		countQuery := `SELECT COUNT(*) FROM songs` + where
+		r.log.WithFields(logrus.Fields{
+			"query": countQuery,
+		}).Debug("Executing SQL query")
+
+		if err := r.db.QueryRow(countQuery, filterArgs...).Scan(&total); err != nil {
+			return nil, 0, err
+		}
 	}
 
-	return songs, nil
+	return songs, total, nil
 }
 
-func (r *SongRepository) GetSongLyrics(id, page, limit int) (string, error) {
+func (r *SongRepository) GetSongLyrics(id, page, limit int) (models.Lyrics, int, error) {
 	var text string
+	var lyrics models.Lyrics
 
-	query := "SELECT text FROM songs WHERE id=$1"
+	query := "SELECT text, updated_at FROM songs WHERE id=$1 AND deleted_at IS NULL"
 
 	r.log.WithFields(logrus.Fields{
 		"query": query,
 	}).Debug("Executing SQL query")
 
-	err := r.db.QueryRow(query, id).Scan(&text)
-	if err != nil {
-		return "", err
-	}
-
-	verses := strings.Split(text, "\n\n")
-	start := (page - 1) * limit
-	end := start + limit
-	if start >= len(verses) {
-		return "", nil
+	err := r.db.QueryRow(query, id).Scan(&text, &lyrics.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return lyrics, 0, ErrSongNotFound
 	}
-	if end > len(verses) {
-		end = len(verses)
+	if err != nil {
+		return lyrics, 0, err
 	}
 
-	return strings.Join(verses[start:end], "\n\n"), nil
+	var total int
+	lyrics.Stanzas, total = paginateStanzas(text, page, limit)
+	return lyrics, total, nil
 }
 
-func (r *SongRepository) DeleteSong(id int) error {
-	query := "DELETE FROM songs WHERE id = $1"
+// DeleteSong moves a song to the trash; PurgeSongs deletes it for good.
+func (r *SongRepository) DeleteSong(id, version int) error {
+	query := `UPDATE songs SET deleted_at = now(), version = version + 1, updated_at = now()
+WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)`
 	r.log.WithFields(logrus.Fields{
 		"query": query,
 	}).Debug("Executing SQL query")
-	_, err := r.db.Exec(query, id)
-	return err
+
+	result, err := r.db.Exec(query, id, version)
+	if err := affectedOrNotFound(result, err, ErrSongNotFound); !errors.Is(err, ErrSongNotFound) {
+		return err
+	}
+	return r.versionConflict(r.db, id)
 }
 
 func (r *SongRepository) UpdateSong(song *models.Song) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error rolling back transaction")
+		}
+	}(tx)
+
 	query := `UPDATE songs
-SET "group" = $1, song = $2, release_date = $3, text = $4, link = $5
-WHERE id = $6
+SET "group" = $1, song = $2, release_date = $3, text = $4, link = $5, artist_id = $6, version = version + 1,
+    updated_at = now()
+WHERE id = $7 AND deleted_at IS NULL AND ($8 = 0 OR version = $8)
+RETURNING version, updated_at
 `
 	r.log.WithFields(logrus.Fields{
 		"query": query,
 	}).Debug("Executing SQL query")
-	_, err := r.db.Exec(query, song.Group, song.Song, song.ReleaseDate, song.Text, song.Link, song.ID)
-	return err
+	err = tx.QueryRow(query, song.Group, song.Song, song.ReleaseDate, song.Text, song.Link, song.ArtistID, song.ID,
+		song.Version).Scan(&song.Version, &song.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return r.versionConflict(tx, song.ID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := r.addRevision(tx, song.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// versionConflict explains why a conditional write matched no row: the song
+// is gone or it is at another version.
+func (r *SongRepository) versionConflict(q queryRower, id int) error {
+	var version int
+	err := q.QueryRow(`SELECT version FROM songs WHERE id = $1 AND deleted_at IS NULL`, id).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrSongNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return ErrVersionConflict
+}
+
+// queryRower is satisfied by both *sql.DB and *sql.Tx.
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
 }
 
 func (r *SongRepository) AddSong(song *models.Song) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error rolling back transaction")
+		}
+	}(tx)
+
+	query := `
+        INSERT INTO songs ("group", song, release_date, text, link, artist_id)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, version, updated_at
+    `
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	err = tx.QueryRow(query, song.Group, song.Song, song.ReleaseDate, song.Text, song.Link, song.ArtistID).Scan(&song.ID, &song.Version, &song.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := r.addRevision(tx, song.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// SearchSongs ranks with the tsvector and picks the best verse in SQL, but
+// highlights the verse in Go like the in-memory store, as ts_headline would
+// return the lyrics unescaped.
+func (r *SongRepository) SearchSongs(search models.SearchQuery, page, limit int) ([]models.SongSearchResult, error) {
 	query := `
-        INSERT INTO songs ("group", song, release_date, text, link)
-        VALUES ($1, $2, $3, $4, $5)
-        RETURNING id
+        SELECT s.id, s."group", s.song, s.release_date, s.link,
+               ts_rank(s.search_vector, q) AS rank,
+               v.idx,
+               COALESCE(v.verse, split_part(s.text, E'\n\n', 1))
+        FROM songs s
+        CROSS JOIN to_tsquery('simple', $1) AS q
+        LEFT JOIN LATERAL (
+            SELECT t.idx, t.verse
+            FROM unnest(string_to_array(s.text, E'\n\n')) WITH ORDINALITY AS t(verse, idx)
+            WHERE to_tsvector('simple', t.verse) @@ q
+            ORDER BY ts_rank(to_tsvector('simple', t.verse), q) DESC, t.idx
+            LIMIT 1
+        ) v ON true
+        WHERE s.search_vector @@ q AND s.deleted_at IS NULL
+        ORDER BY rank DESC, s.id
+        LIMIT $2 OFFSET $3
     `
+	tsQuery := toTSQuery(search)
+
+	r.log.WithFields(logrus.Fields{
+		"query":   query,
+		"tsquery": tsQuery,
+	}).Debug("Executing SQL query")
+
+	rows, err := r.db.Query(query, tsQuery, limit, (page-1)*limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error closing rows")
+		}
+	}(rows)
+
+	var results []models.SongSearchResult
+	for rows.Next() {
+		var result models.SongSearchResult
+		var verse sql.NullInt64
+		var verseText string
+		if err := rows.Scan(&result.ID, &result.Group, &result.Song, &result.ReleaseDate, &result.Link,
+			&result.Rank, &verse, &verseText); err != nil {
+			return nil, err
+		}
+		result.Snippet = highlight(verseText, search)
+		if verse.Valid {
+			idx := int(verse.Int64)
+			result.Verse = &idx
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// GetSongsAfter returns up to limit songs following cursor (or from the start
+// when cursor is nil) using keyset pagination, and the cursor of the next
+// page, which is nil once the last song has been returned.
+func (r *SongRepository) GetSongsAfter(filter models.SongFilter, sort models.SongSort, cursor *models.SongCursor, limit int) ([]models.Song, *models.SongCursor, error) {
+	var b queryBuilder
+	if err := b.applySongFilter(filter); err != nil {
+		return nil, nil, err
+	}
+	if cursor != nil {
+		sort = cursor.SongSort()
+		if err := b.applyCursor(*cursor); err != nil {
+			return nil, nil, err
+		}
+	}
+	order, err := orderBy(sort)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query := `SELECT ` + storedSongColumns + ` FROM songs` + b.whereClause() + order
+	query += " LIMIT " + b.arg(limit+1)
+	args := b.args
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+		"args":  args,
+	}).Debug("Executing SQL query")
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error closing rows")
+		}
+	}(rows)
+
+	var songs []models.Song
+	for rows.Next() {
+		var song models.Song
+		if err := rows.Scan(storedSongFields(&song)...); err != nil {
+			return nil, nil, err
+		}
+		songs = append(songs, song)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	songs, next := keysetPage(songs, sort, limit)
+	return songs, next, nil
+}
+
+func (r *SongRepository) GetSongsLastModified() (time.Time, error) {
+	var lastModified sql.NullTime
+
+	query := `SELECT MAX(updated_at) FROM songs`
+
 	r.log.WithFields(logrus.Fields{
 		"query": query,
 	}).Debug("Executing SQL query")
 
-	return r.db.QueryRow(query, song.Group, song.Song, song.ReleaseDate, song.Text, song.Link).Scan(&song.ID)
+	if err := r.db.QueryRow(query).Scan(&lastModified); err != nil {
+		return time.Time{}, err
+	}
+	return lastModified.Time, nil
+}
+
+func (r *SongRepository) GetSong(id int) (models.Song, error) {
+	var song models.Song
+
+	query := `SELECT ` + storedSongColumns + ` FROM songs WHERE id = $1 AND deleted_at IS NULL`
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	err := r.db.QueryRow(query, id).Scan(storedSongFields(&song)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return song, ErrSongNotFound
+	}
+	return song, err
 }
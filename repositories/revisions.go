@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"case/models"
+	"database/sql"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+const revisionColumns = `revision, created_at, song_id, "group", song, release_date, text, link, artist_id`
+
+func revisionFields(revision *models.SongRevision) []interface{} {
+	return append([]interface{}{&revision.Revision, &revision.CreatedAt}, songFields(&revision.Song)...)
+}
+
+// addRevision snapshots the current state of a song. It runs in the
+// transaction of the write, after the song row was written and locked, so
+// concurrent writers number their revisions one after the other.
+func (r *SongRepository) addRevision(tx *sql.Tx, songID int) error {
+	query := `
+        INSERT INTO song_revisions (song_id, revision, "group", song, release_date, text, link, artist_id)
+        SELECT id, COALESCE((SELECT MAX(revision) FROM song_revisions WHERE song_id = $1), 0) + 1,
+               "group", song, release_date, text, link, artist_id
+        FROM songs WHERE id = $1
+    `
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	_, err := tx.Exec(query, songID)
+	return err
+}
+
+func (r *SongRepository) GetSongRevisions(songID, page, limit int) ([]models.SongRevision, int, error) {
+	if err := r.songExists(songID); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+        SELECT ` + revisionColumns + `, COUNT(*) OVER() FROM song_revisions
+        WHERE song_id = $1
+        ORDER BY revision DESC
+        LIMIT $2 OFFSET $3
+    `
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	rows, err := r.db.Query(query, songID, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error closing rows")
+		}
+	}(rows)
+
+	var revisions []models.SongRevision
+	total := 0
+	for rows.Next() {
+		var revision models.SongRevision
+		if err := rows.Scan(append(revisionFields(&revision), &total)...); err != nil {
+			return nil, 0, err
+		}
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if len(revisions) == 0 && page > 1 {
+		if err := r.db.QueryRow(`SELECT COUNT(*) FROM song_revisions WHERE song_id = $1`, songID).Scan(&total); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return revisions, total, nil
+}
+
+func (r *SongRepository) GetSongRevision(songID, revision int) (models.SongRevision, error) {
+	if err := r.songExists(songID); err != nil {
+		return models.SongRevision{}, err
+	}
+
+	var result models.SongRevision
+	query := `SELECT ` + revisionColumns + ` FROM song_revisions WHERE song_id = $1 AND revision = $2`
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	err := r.db.QueryRow(query, songID, revision).Scan(revisionFields(&result)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return result, ErrRevisionNotFound
+	}
+	return result, err
+}
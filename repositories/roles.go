@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrRoleNotFound = errors.New("role not found")
+
+// RoleStore is the storage contract used by services.AuthzService. Subjects
+// are the "sub" claims of authenticated tokens.
+type RoleStore interface {
+	GetSubjectRoles(subject string) ([]string, error)
+	// GetRolePermissions returns the union of the permissions of roles.
+	GetRolePermissions(roles []string) ([]string, error)
+	GrantRole(subject, role string) error
+	RevokeRole(subject, role string) error
+}
+
+type RoleRepository struct {
+	db  *sql.DB
+	log *logrus.Logger
+}
+
+func NewRoleRepository(db *sql.DB, log *logrus.Logger) *RoleRepository {
+	return &RoleRepository{db: db, log: log}
+}
+
+func (r *RoleRepository) GetSubjectRoles(subject string) ([]string, error) {
+	query := `SELECT role FROM user_roles WHERE subject = $1 ORDER BY role`
+	return r.queryNames(query, subject)
+}
+
+func (r *RoleRepository) GetRolePermissions(roles []string) ([]string, error) {
+	query := `SELECT DISTINCT permission FROM role_permissions WHERE role = ANY($1) ORDER BY permission`
+	return r.queryNames(query, pq.Array(roles))
+}
+
+func (r *RoleRepository) GrantRole(subject, role string) error {
+	query := `INSERT INTO user_roles (subject, role) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	_, err := r.db.Exec(query, subject, role)
+	if isForeignKeyViolation(err) {
+		return ErrRoleNotFound
+	}
+	return err
+}
+
+func (r *RoleRepository) RevokeRole(subject, role string) error {
+	query := `DELETE FROM user_roles WHERE subject = $1 AND role = $2`
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	result, err := r.db.Exec(query, subject, role)
+	return affectedOrNotFound(result, err, ErrRoleNotFound)
+}
+
+func (r *RoleRepository) queryNames(query string, args ...interface{}) ([]string, error) {
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error closing rows")
+		}
+	}(rows)
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
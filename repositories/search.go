@@ -0,0 +1,169 @@
+package repositories
+
+import (
+	"case/models"
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	highlightStart = "<mark>"
+	highlightStop  = "</mark>"
+)
+
+// toTSQuery renders a parsed query as a to_tsquery('simple', ...) string.
+func toTSQuery(query models.SearchQuery) string {
+	terms := make([]string, 0, len(query.Terms))
+	for _, term := range query.Terms {
+		words := make([]string, len(term.Words))
+		for i, word := range term.Words {
+			words[i] = "'" + word + "'"
+		}
+		if term.Prefix {
+			words[len(words)-1] += ":*"
+		}
+		terms = append(terms, strings.Join(words, " <-> "))
+	}
+	return strings.Join(terms, " & ")
+}
+
+// termMatchesAt reports whether term matches tokens starting at position i.
+func termMatchesAt(term models.SearchTerm, tokens []string, i int) bool {
+	if i+len(term.Words) > len(tokens) {
+		return false
+	}
+	for j, word := range term.Words {
+		token := tokens[i+j]
+		if term.Prefix && j == len(term.Words)-1 {
+			if !strings.HasPrefix(token, word) {
+				return false
+			}
+		} else if token != word {
+			return false
+		}
+	}
+	return true
+}
+
+// countMatches returns how many times each term occurs in tokens and
+// whether every term occurs at least once.
+func countMatches(query models.SearchQuery, tokens []string) (int, bool) {
+	total := 0
+	all := true
+	for _, term := range query.Terms {
+		n := 0
+		for i := range tokens {
+			if termMatchesAt(term, tokens, i) {
+				n++
+			}
+		}
+		if n == 0 {
+			all = false
+		}
+		total += n
+	}
+	return total, all
+}
+
+// searchSong is the naive counterpart of the tsvector search: group and song
+// title weigh more than the lyrics, and the best matching verse is highlighted.
+func searchSong(song models.Song, query models.SearchQuery) (models.SongSearchResult, bool) {
+	titleHits, _ := countMatches(query, models.Tokenize(song.Group+" "+song.Song))
+	textHits, _ := countMatches(query, models.Tokenize(song.Text))
+	_, all := countMatches(query, models.Tokenize(song.Group+" "+song.Song+" "+song.Text))
+	if !all {
+		return models.SongSearchResult{}, false
+	}
+
+	result := models.SongSearchResult{
+		ID:          song.ID,
+		Group:       song.Group,
+		Song:        song.Song,
+		ReleaseDate: song.ReleaseDate,
+		Link:        song.Link,
+		Rank:        float64(titleHits) + 0.4*float64(textHits),
+	}
+
+	verses := strings.Split(song.Text, "\n\n")
+	best, bestHits := -1, 0
+	for i, verse := range verses {
+		hits, all := countMatches(query, models.Tokenize(verse))
+		if all && hits > bestHits {
+			best, bestHits = i, hits
+		}
+	}
+
+	if best >= 0 {
+		verse := best + 1
+		result.Verse = &verse
+		result.Snippet = highlight(verses[best], query)
+	} else if len(verses) > 0 {
+		result.Snippet = highlight(verses[0], query)
+	}
+
+	return result, true
+}
+
+// highlight wraps every word of text that belongs to a matching term. The
+// snippet is HTML, so the text itself is escaped.
+func highlight(text string, query models.SearchQuery) string {
+	type span struct{ start, end int }
+
+	var spans []span
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWord && start < 0 {
+			start = i
+		} else if !isWord && start >= 0 {
+			spans = append(spans, span{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, span{start, len(text)})
+	}
+
+	tokens := make([]string, len(spans))
+	for i, s := range spans {
+		tokens[i] = strings.ToLower(text[s.start:s.end])
+	}
+
+	marked := make([]bool, len(tokens))
+	for _, term := range query.Terms {
+		for i := range tokens {
+			if termMatchesAt(term, tokens, i) {
+				for j := range term.Words {
+					marked[i+j] = true
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+	last := 0
+	for i, s := range spans {
+		if !marked[i] {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[last:s.start]))
+		b.WriteString(highlightStart)
+		b.WriteString(html.EscapeString(text[s.start:s.end]))
+		b.WriteString(highlightStop)
+		last = s.end
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+
+	return b.String()
+}
+
+func sortSearchResults(results []models.SongSearchResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Rank != results[j].Rank {
+			return results[i].Rank > results[j].Rank
+		}
+		return results[i].ID < results[j].ID
+	})
+}
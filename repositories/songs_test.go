@@ -0,0 +1,404 @@
+package repositories
+
+import (
+	"case/migrations"
+	"case/models"
+	"database/sql"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+// songStores are the stores a song fixture is written through.
+type songStores struct {
+	songs   SongStore
+	artists ArtistStore
+	tags    TagStore
+}
+
+// songFixture holds the ids the seeded rows got, by name.
+type songFixture struct {
+	artists map[string]int
+	tags    map[string]int
+	// songs maps titles to ids; the table below refers to songs by title so
+	// that it does not depend on how a store numbers them.
+	songs map[string]int
+}
+
+// seedSongs writes the same catalogue to any store. Names are single
+// capitalised ASCII words so that every collation orders them alike.
+func seedSongs(t *testing.T, stores songStores) songFixture {
+	t.Helper()
+
+	fixture := songFixture{artists: map[string]int{}, tags: map[string]int{}, songs: map[string]int{}}
+
+	for _, name := range []string{"Muse", "Queen", "Radiohead"} {
+		artist := models.Artist{Name: name, Aliases: []string{}}
+		if err := stores.artists.AddArtist(&artist); err != nil {
+			t.Fatalf("AddArtist(%s): %v", name, err)
+		}
+		fixture.artists[name] = artist.ID
+	}
+	for _, name := range []string{"rock", "alt", "classic"} {
+		tag := models.Tag{Name: name, Kind: models.TagGenre}
+		if err := stores.tags.AddTag(&tag); err != nil {
+			t.Fatalf("AddTag(%s): %v", name, err)
+		}
+		fixture.tags[name] = tag.ID
+	}
+
+	songs := []struct {
+		group, song, date, text string
+		tags                    []string
+	}{
+		{"Muse", "Uprising", "07.09.2009", "Paranoia is in bloom", []string{"rock", "alt"}},
+		{"Muse", "Resistance", "14.09.2009", "Is our secret safe tonight", []string{"rock"}},
+		{"Queen", "Bohemian", "31.10.1975", "Galileo 1000 times", []string{"rock", "classic"}},
+		{"Queen", "Innuendo", "", "While the sun hangs in the sky", []string{"classic"}},
+		{"Radiohead", "Creep", "21.09.1992", "When you were here before", []string{"alt"}},
+		{"Radiohead", "Airbag", "", "In the next world war", nil},
+		{"Muse", "Madness", "20.08.2012", "I am 100%_sure", nil},
+		{"Muse", "Zealot", "01.01.2020", "Deleted before the tests run", []string{"rock"}},
+	}
+	for _, s := range songs {
+		song := models.Song{Group: s.group, Song: s.song, Text: s.text, ArtistID: fixture.artists[s.group]}
+		if s.date != "" {
+			date, err := models.ParseDate(s.date)
+			if err != nil {
+				t.Fatalf("ParseDate(%s): %v", s.date, err)
+			}
+			song.ReleaseDate = date
+		}
+		if err := stores.songs.AddSong(&song); err != nil {
+			t.Fatalf("AddSong(%s): %v", s.song, err)
+		}
+		fixture.songs[s.song] = song.ID
+
+		var tagIDs []int
+		for _, name := range s.tags {
+			tagIDs = append(tagIDs, fixture.tags[name])
+		}
+		if err := stores.tags.SetSongTags(song.ID, tagIDs); err != nil {
+			t.Fatalf("SetSongTags(%s): %v", s.song, err)
+		}
+	}
+
+	if err := stores.songs.DeleteSong(fixture.songs["Zealot"], 0); err != nil {
+		t.Fatalf("DeleteSong: %v", err)
+	}
+	return fixture
+}
+
+type songQueryCase struct {
+	name   string
+	filter func(f songFixture) models.SongFilter
+	sort   models.SongSort
+	// want lists song titles in the expected order.
+	want []string
+}
+
+func date(t *testing.T, value string) *models.Date {
+	t.Helper()
+
+	d, err := models.ParseDate(value)
+	if err != nil {
+		t.Fatalf("ParseDate(%s): %v", value, err)
+	}
+	return &d
+}
+
+// songQueryCases covers every filter and sort the list endpoints accept.
+// Both stores must return exactly these songs in exactly this order.
+func songQueryCases(t *testing.T) []songQueryCase {
+	none := func(songFixture) models.SongFilter { return models.SongFilter{} }
+	byID := models.SongSort{Field: "id"}
+	match := func(field string, m models.MatchType, value string) func(songFixture) models.SongFilter {
+		return func(songFixture) models.SongFilter {
+			return models.SongFilter{Matches: []models.FieldMatch{{Field: field, Match: m, Value: value}}}
+		}
+	}
+	tagged := func(mode models.TagMode, names ...string) func(songFixture) models.SongFilter {
+		return func(f songFixture) models.SongFilter {
+			filter := models.SongFilter{TagMode: mode}
+			for _, name := range names {
+				// Unknown names resolve to 0, as in TagService.
+				filter.TagIDs = append(filter.TagIDs, f.tags[name])
+			}
+			return filter
+		}
+	}
+
+	return []songQueryCase{
+		{"all by id", none, byID,
+			[]string{"Uprising", "Resistance", "Bohemian", "Innuendo", "Creep", "Airbag", "Madness"}},
+		{"all by id desc", none, models.SongSort{Field: "id", Desc: true},
+			[]string{"Madness", "Airbag", "Creep", "Innuendo", "Bohemian", "Resistance", "Uprising"}},
+		{"by group with id tie-break", none, models.SongSort{Field: "group"},
+			[]string{"Uprising", "Resistance", "Madness", "Bohemian", "Innuendo", "Creep", "Airbag"}},
+		{"by group desc", none, models.SongSort{Field: "group", Desc: true},
+			[]string{"Airbag", "Creep", "Innuendo", "Bohemian", "Madness", "Resistance", "Uprising"}},
+		{"by song", none, models.SongSort{Field: "song"},
+			[]string{"Airbag", "Bohemian", "Creep", "Innuendo", "Madness", "Resistance", "Uprising"}},
+		{"by release date, unknown dates last", none, models.SongSort{Field: "release_date"},
+			[]string{"Bohemian", "Creep", "Uprising", "Resistance", "Madness", "Innuendo", "Airbag"}},
+		{"by release date desc, unknown dates still last", none, models.SongSort{Field: "release_date", Desc: true},
+			[]string{"Madness", "Resistance", "Uprising", "Creep", "Bohemian", "Airbag", "Innuendo"}},
+		{"artist", func(f songFixture) models.SongFilter {
+			id := f.artists["Queen"]
+			return models.SongFilter{ArtistID: &id}
+		}, byID, []string{"Bohemian", "Innuendo"}},
+		{"exact group", match("group", models.MatchExact, "Muse"), byID,
+			[]string{"Uprising", "Resistance", "Madness"}},
+		{"exact is case-sensitive", match("group", models.MatchExact, "muse"), byID, nil},
+		{"contains ignores case", match("song", models.MatchContains, "ES"), byID,
+			[]string{"Resistance", "Madness"}},
+		{"prefix ignores case", match("group", models.MatchPrefix, "qu"), byID,
+			[]string{"Bohemian", "Innuendo"}},
+		{"prefix is anchored", match("song", models.MatchPrefix, "sing"), byID, nil},
+		{"LIKE wildcards are literal", match("text", models.MatchContains, "0%_"), byID,
+			[]string{"Madness"}},
+		{"several matches all hold", func(songFixture) models.SongFilter {
+			return models.SongFilter{Matches: []models.FieldMatch{
+				{Field: "group", Match: models.MatchExact, Value: "Muse"},
+				{Field: "text", Match: models.MatchContains, Value: "is"},
+			}}
+		}, byID, []string{"Uprising", "Resistance"}},
+		{"released after, inclusive", func(songFixture) models.SongFilter {
+			return models.SongFilter{ReleasedAfter: date(t, "14.09.2009")}
+		}, byID, []string{"Resistance", "Madness"}},
+		{"released between, inclusive", func(songFixture) models.SongFilter {
+			return models.SongFilter{ReleasedAfter: date(t, "21.09.1992"), ReleasedBefore: date(t, "07.09.2009")}
+		}, byID, []string{"Uprising", "Creep"}},
+		{"all tags", tagged(models.TagMatchAll, "rock", "classic"), byID, []string{"Bohemian"}},
+		{"all tags with a repeat", tagged(models.TagMatchAll, "rock", "rock"), byID,
+			[]string{"Uprising", "Resistance", "Bohemian"}},
+		{"any tag", tagged(models.TagMatchAny, "alt", "classic"), byID,
+			[]string{"Uprising", "Bohemian", "Innuendo", "Creep"}},
+		{"all tags with an unknown one", tagged(models.TagMatchAll, "rock", "unknown"), byID, nil},
+		{"any tag with an unknown one", tagged(models.TagMatchAny, "rock", "unknown"), byID,
+			[]string{"Uprising", "Resistance", "Bohemian"}},
+		{"combined filter and sort", func(f songFixture) models.SongFilter {
+			filter := tagged(models.TagMatchAll, "rock")(f)
+			filter.Matches = []models.FieldMatch{{Field: "group", Match: models.MatchExact, Value: "Muse"}}
+			return filter
+		}, models.SongSort{Field: "release_date", Desc: true}, []string{"Resistance", "Uprising"}},
+	}
+}
+
+// testSongQueries runs songQueryCases against a store seeded with seedSongs,
+// reading every case whole, in offset pages and by walking keyset cursors.
+func testSongQueries(t *testing.T, stores songStores) {
+	fixture := seedSongs(t, stores)
+	titles := make(map[int]string, len(fixture.songs))
+	for title, id := range fixture.songs {
+		titles[id] = title
+	}
+	// names also checks that every store reads a song's version and last
+	// write along with it.
+	names := func(songs []models.Song) []string {
+		var got []string
+		for _, song := range songs {
+			if song.Version == 0 || song.UpdatedAt.IsZero() {
+				t.Errorf("song %d has version %d and updated_at %v; want both set", song.ID, song.Version, song.UpdatedAt)
+			}
+			got = append(got, titles[song.ID])
+		}
+		return got
+	}
+
+	for _, tt := range songQueryCases(t) {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := tt.filter(fixture)
+
+			songs, total, err := stores.songs.GetSongs(filter, tt.sort, 1, 100)
+			if err != nil {
+				t.Fatalf("GetSongs: %v", err)
+			}
+			if got := names(songs); !reflect.DeepEqual(got, tt.want) || total != len(tt.want) {
+				t.Fatalf("GetSongs = %v (total %d); want %v", got, total, tt.want)
+			}
+
+			const limit = 2
+			var paged []models.Song
+			for page := 1; (page-1)*limit < len(tt.want); page++ {
+				songs, total, err := stores.songs.GetSongs(filter, tt.sort, page, limit)
+				if err != nil {
+					t.Fatalf("GetSongs page %d: %v", page, err)
+				}
+				if total != len(tt.want) {
+					t.Errorf("GetSongs page %d total = %d; want %d", page, total, len(tt.want))
+				}
+				paged = append(paged, songs...)
+			}
+			if got := names(paged); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetSongs in pages of %d = %v; want %v", limit, got, tt.want)
+			}
+
+			var walked []models.Song
+			var cursor *models.SongCursor
+			for i := 0; i <= len(tt.want); i++ {
+				songs, next, err := stores.songs.GetSongsAfter(filter, tt.sort, cursor, limit)
+				if err != nil {
+					t.Fatalf("GetSongsAfter: %v", err)
+				}
+				walked = append(walked, songs...)
+				if next == nil {
+					break
+				}
+				// Round-trip the cursor as a client would.
+				decoded, err := models.DecodeSongCursor(next.Encode())
+				if err != nil {
+					t.Fatalf("DecodeSongCursor: %v", err)
+				}
+				cursor = &decoded
+			}
+			if got := names(walked); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetSongsAfter walk = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemorySongQueries(t *testing.T) {
+	log := newTestLogger()
+	songs := NewMemorySongRepository(log)
+	testSongQueries(t, songStores{
+		songs:   songs,
+		artists: NewMemoryArtistRepository(songs, log),
+		tags:    NewMemoryTagRepository(songs, log),
+	})
+}
+
+// TestPostgresSongQueries runs the same table against PostgreSQL. It needs
+// TEST_DATABASE_URL pointing at a database it may migrate and empty.
+func TestPostgresSongQueries(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	log := newTestLogger()
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.NewMigrator(db, log)
+	if err != nil {
+		t.Fatalf("NewMigrator: %v", err)
+	}
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if _, err := db.Exec("TRUNCATE songs, artists, tags RESTART IDENTITY CASCADE"); err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+
+	testSongQueries(t, songStores{
+		songs:   NewSongRepository(db, log),
+		artists: NewArtistRepository(db, log),
+		tags:    NewTagRepository(db, log),
+	})
+}
+
+func TestApplySongFilter(t *testing.T) {
+	artistID := 3
+	after := models.Date{}
+
+	tests := []struct {
+		name      string
+		filter    models.SongFilter
+		wantWhere string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "empty filter still skips the trash",
+			wantWhere: " WHERE deleted_at IS NULL",
+		},
+		{
+			name: "matches",
+			filter: models.SongFilter{ArtistID: &artistID, Matches: []models.FieldMatch{
+				{Field: "group", Match: models.MatchExact, Value: "Muse"},
+				{Field: "song", Match: models.MatchContains, Value: `50%_off\`},
+				{Field: "text", Match: models.MatchPrefix, Value: "a_b"},
+			}},
+			wantWhere: ` WHERE deleted_at IS NULL AND artist_id = $1 AND "group" = $2 AND song ILIKE $3 AND text ILIKE $4`,
+			wantArgs:  []interface{}{3, "Muse", `%50\%\_off\\%`, `a\_b%`},
+		},
+		{
+			name:      "date range",
+			filter:    models.SongFilter{ReleasedAfter: &after, ReleasedBefore: &after},
+			wantWhere: " WHERE deleted_at IS NULL AND release_date >= $1 AND release_date <= $2",
+			wantArgs:  []interface{}{after, after},
+		},
+		{
+			name:      "all tags counts distinct ids",
+			filter:    models.SongFilter{TagIDs: []int{4, 2, 4}, TagMode: models.TagMatchAll},
+			wantWhere: " WHERE deleted_at IS NULL AND id IN (SELECT song_id FROM song_tags WHERE tag_id = ANY($1) GROUP BY song_id HAVING COUNT(*) = $2)",
+			wantArgs:  []interface{}{pq.Array([]int64{4, 2}), 2},
+		},
+		{
+			name:      "any tag",
+			filter:    models.SongFilter{TagIDs: []int{4}, TagMode: models.TagMatchAny},
+			wantWhere: " WHERE deleted_at IS NULL AND id IN (SELECT song_id FROM song_tags WHERE tag_id = ANY($1))",
+			wantArgs:  []interface{}{pq.Array([]int64{4})},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b queryBuilder
+			if err := b.applySongFilter(tt.filter); err != nil {
+				t.Fatalf("applySongFilter: %v", err)
+			}
+			if got := b.whereClause(); got != tt.wantWhere {
+				t.Errorf("whereClause() = %q; want %q", got, tt.wantWhere)
+			}
+			if !reflect.DeepEqual(b.args, tt.wantArgs) {
+				t.Errorf("args = %#v; want %#v", b.args, tt.wantArgs)
+			}
+		})
+	}
+
+	for _, filter := range []models.SongFilter{
+		{Matches: []models.FieldMatch{{Field: "id", Match: models.MatchExact, Value: "1"}}},
+		{Matches: []models.FieldMatch{{Field: "song", Match: "regex", Value: "."}}},
+	} {
+		var b queryBuilder
+		if err := b.applySongFilter(filter); err == nil {
+			t.Errorf("applySongFilter(%+v) succeeded; want an error", filter)
+		}
+		if _, err := matchSong(models.Song{}, nil, filter); err == nil {
+			t.Errorf("matchSong(%+v) succeeded; want an error", filter)
+		}
+	}
+}
+
+func TestOrderBy(t *testing.T) {
+	tests := []struct {
+		sort models.SongSort
+		want string
+	}{
+		{models.SongSort{Field: "id"}, " ORDER BY id ASC"},
+		{models.SongSort{Field: "id", Desc: true}, " ORDER BY id DESC"},
+		{models.SongSort{Field: "group"}, ` ORDER BY "group" ASC NULLS LAST, id ASC`},
+		{models.SongSort{Field: "release_date", Desc: true}, " ORDER BY release_date DESC NULLS LAST, id DESC"},
+	}
+
+	for _, tt := range tests {
+		got, err := orderBy(tt.sort)
+		if err != nil {
+			t.Fatalf("orderBy(%+v): %v", tt.sort, err)
+		}
+		if got != tt.want {
+			t.Errorf("orderBy(%+v) = %q; want %q", tt.sort, got, tt.want)
+		}
+	}
+
+	if _, err := orderBy(models.SongSort{Field: "text"}); err == nil {
+		t.Error("orderBy(text) succeeded; want an error")
+	}
+}
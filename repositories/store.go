@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"case/models"
+	"errors"
+	"time"
+)
+
+var (
+	ErrSongNotFound = errors.New("song not found")
+	// ErrVersionConflict is returned by conditional writes when the song has
+	// been changed since the expected version.
+	ErrVersionConflict = errors.New("song version conflict")
+)
+
+// SongStore is the storage contract used by services.SongService.
+type SongStore interface {
+	GetSong(id int) (models.Song, error)
+	// GetSongs returns a page of songs and the total number matching filter.
+	GetSongs(filter models.SongFilter, sort models.SongSort, page, limit int) ([]models.Song, int, error)
+	// GetSongsAfter returns the songs following cursor in keyset order and
+	// the cursor of the next page, nil at the end.
+	GetSongsAfter(filter models.SongFilter, sort models.SongSort, cursor *models.SongCursor, limit int) ([]models.Song, *models.SongCursor, error)
+	// ExportSongs calls fn with every song matching filter in sort order,
+	// without holding them all in memory, and stops at the first error fn
+	// returns.
+	ExportSongs(filter models.SongFilter, sort models.SongSort, fn func(models.Song) error) error
+	// GetSongLyrics returns a page of stanzas with the song's last write time
+	// and the total number of stanzas.
+	GetSongLyrics(id, page, limit int) (models.Lyrics, int, error)
+	SearchSongs(query models.SearchQuery, page, limit int) ([]models.SongSearchResult, error)
+	// GetSyncedLyrics returns the time-stamped lines of a song in playback order.
+	GetSyncedLyrics(songID int) ([]models.SyncedLine, error)
+	// SetSyncedLyrics replaces the time-stamped lines of a song if it is still
+	// at version (0 skips the check) and moves the song to a new version.
+	SetSyncedLyrics(songID, version int, lines []models.SyncedLine) error
+	// GetSyncedLineAt returns the line active at offsetMs or ErrNoActiveLine.
+	GetSyncedLineAt(songID, offsetMs int) (models.SyncedLine, error)
+	// GetSongsLastModified returns the time of the latest write to any song,
+	// trashed songs included, or the zero time if there are none.
+	GetSongsLastModified() (time.Time, error)
+	// AddSong and UpdateSong also record the new state as a revision and set
+	// song.Version and song.UpdatedAt to the stored values.
+	AddSong(song *models.Song) error
+	// UpdateSong only writes if the song is still at song.Version, unless
+	// that is 0, and returns ErrVersionConflict otherwise.
+	UpdateSong(song *models.Song) error
+	// AddSongs adds songs in one transaction, recording the first revision
+	// of each, and sets their IDs, versions and UpdatedAt.
+	AddSongs(songs []models.Song) error
+	// GetExistingSongKeys returns those of keys that match a song outside
+	// the trash.
+	GetExistingSongKeys(keys []models.SongKey) (map[models.SongKey]bool, error)
+	// DeleteSong moves a song to the trash, where every other read ignores
+	// it until it is restored or purged. Like UpdateSong it checks version
+	// unless that is 0.
+	DeleteSong(id, version int) error
+	// GetTrashedSongs returns a page of trashed songs, most recently deleted
+	// first, and the total number of trashed songs.
+	GetTrashedSongs(page, limit int) ([]models.TrashedSong, int, error)
+	RestoreSong(id int) error
+	// PurgeSongs permanently deletes the songs trashed before cutoff and
+	// returns how many there were.
+	PurgeSongs(cutoff time.Time) (int, error)
+	// GetSongRevisions returns a page of revisions, newest first, and the
+	// total number of revisions of the song.
+	GetSongRevisions(songID, page, limit int) ([]models.SongRevision, int, error)
+	GetSongRevision(songID, revision int) (models.SongRevision, error)
+}
+
+// paginateStanzas parses text into stanzas and returns the requested page of
+// them along with the total number of stanzas.
+func paginateStanzas(text string, page, limit int) ([]models.Stanza, int) {
+	return pageStanzas(models.ParseLyrics(text), page, limit)
+}
+
+func pageStanzas(stanzas []models.Stanza, page, limit int) ([]models.Stanza, int) {
+	start := (page - 1) * limit
+	end := start + limit
+	if start >= len(stanzas) {
+		return []models.Stanza{}, len(stanzas)
+	}
+	if end > len(stanzas) {
+		end = len(stanzas)
+	}
+
+	return stanzas[start:end], len(stanzas)
+}
+
+// keysetPage trims a result fetched with limit+1 rows to limit and returns
+// the cursor of its last song if more rows follow.
+func keysetPage(songs []models.Song, sort models.SongSort, limit int) ([]models.Song, *models.SongCursor) {
+	if len(songs) <= limit {
+		return songs, nil
+	}
+
+	songs = songs[:limit]
+	next := models.NewSongCursor(songs[len(songs)-1], sort)
+	return songs, &next
+}
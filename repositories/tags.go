@@ -0,0 +1,286 @@
+package repositories
+
+import (
+	"case/models"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrTagNotFound = errors.New("tag not found")
+	ErrTagExists   = errors.New("tag with this name already exists")
+)
+
+// TagStore is the storage contract used by services.TagService. Tag names
+// are expected to be normalized with models.NormalizeTagName.
+type TagStore interface {
+	GetTags(kind models.TagKind, page, limit int) ([]models.Tag, int, error)
+	GetTag(id int) (models.Tag, error)
+	// FindTags returns the tags with the given names; unknown names are
+	// skipped.
+	FindTags(names []string) ([]models.Tag, error)
+	AddTag(tag *models.Tag) error
+	UpdateTag(tag *models.Tag) error
+	// DeleteTag also removes the tag from every song.
+	DeleteTag(id int) error
+	GetSongTags(songID int) ([]models.Tag, error)
+	// SetSongTags replaces the tags of a song.
+	SetSongTags(songID int, tagIDs []int) error
+	// GetTagFacets counts the songs matching filter per tag, most used first.
+	GetTagFacets(filter models.SongFilter) ([]models.TagFacet, error)
+}
+
+type TagRepository struct {
+	db  *sql.DB
+	log *logrus.Logger
+}
+
+func NewTagRepository(db *sql.DB, log *logrus.Logger) *TagRepository {
+	return &TagRepository{db: db, log: log}
+}
+
+func (r *TagRepository) GetTags(kind models.TagKind, page, limit int) ([]models.Tag, int, error) {
+	var b queryBuilder
+	if kind != "" {
+		b.where("kind = " + b.arg(kind))
+	}
+	where := b.whereClause()
+	filterArgs := b.args
+
+	query := `SELECT id, name, kind, COUNT(*) OVER() FROM tags` + where + ` ORDER BY name`
+	query += " LIMIT " + b.arg(limit) + " OFFSET " + b.arg((page-1)*limit)
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	rows, err := r.db.Query(query, b.args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error closing rows")
+		}
+	}(rows)
+
+	var tags []models.Tag
+	total := 0
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Kind, &total); err != nil {
+			return nil, 0, err
+		}
+		tags = append(tags, tag)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if len(tags) == 0 && page > 1 {
+		if err := r.db.QueryRow(`SELECT COUNT(*) FROM tags`+where, filterArgs...).Scan(&total); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return tags, total, nil
+}
+
+func (r *TagRepository) GetTag(id int) (models.Tag, error) {
+	var tag models.Tag
+
+	query := `SELECT id, name, kind FROM tags WHERE id = $1`
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	err := r.db.QueryRow(query, id).Scan(&tag.ID, &tag.Name, &tag.Kind)
+	if errors.Is(err, sql.ErrNoRows) {
+		return tag, ErrTagNotFound
+	}
+	return tag, err
+}
+
+func (r *TagRepository) FindTags(names []string) ([]models.Tag, error) {
+	query := `SELECT id, name, kind FROM tags WHERE name = ANY($1) ORDER BY name`
+	return r.queryTags(query, pq.Array(names))
+}
+
+func (r *TagRepository) AddTag(tag *models.Tag) error {
+	query := `INSERT INTO tags (name, kind) VALUES ($1, $2) RETURNING id`
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	err := r.db.QueryRow(query, tag.Name, tag.Kind).Scan(&tag.ID)
+	if isUniqueViolation(err) {
+		return ErrTagExists
+	}
+	return err
+}
+
+func (r *TagRepository) UpdateTag(tag *models.Tag) error {
+	query := `UPDATE tags SET name = $1, kind = $2 WHERE id = $3`
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	result, err := r.db.Exec(query, tag.Name, tag.Kind, tag.ID)
+	if isUniqueViolation(err) {
+		return ErrTagExists
+	}
+	return affectedOrNotFound(result, err, ErrTagNotFound)
+}
+
+func (r *TagRepository) DeleteTag(id int) error {
+	query := `DELETE FROM tags WHERE id = $1`
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	result, err := r.db.Exec(query, id)
+	return affectedOrNotFound(result, err, ErrTagNotFound)
+}
+
+func (r *TagRepository) GetSongTags(songID int) ([]models.Tag, error) {
+	var exists int
+	err := r.db.QueryRow(`SELECT 1 FROM songs WHERE id = $1 AND deleted_at IS NULL`, songID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSongNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+        SELECT t.id, t.name, t.kind
+        FROM song_tags st
+        JOIN tags t ON t.id = st.tag_id
+        WHERE st.song_id = $1
+        ORDER BY t.name
+    `
+	return r.queryTags(query, songID)
+}
+
+func (r *TagRepository) SetSongTags(songID int, tagIDs []int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func(tx *sql.Tx) {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error rolling back transaction")
+		}
+	}(tx)
+
+	var exists int
+	err = tx.QueryRow(`UPDATE songs SET updated_at = now() WHERE id = $1 AND deleted_at IS NULL RETURNING 1`, songID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrSongNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM song_tags WHERE song_id = $1`, songID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO song_tags (song_id, tag_id) SELECT $1, unnest($2::int[])`,
+		songID, pq.Array(uniqueIDs(tagIDs)))
+	if isForeignKeyViolation(err) {
+		return ErrTagNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *TagRepository) GetTagFacets(filter models.SongFilter) ([]models.TagFacet, error) {
+	var b queryBuilder
+	if err := b.applySongFilter(filter); err != nil {
+		return nil, err
+	}
+
+	query := `
+        SELECT t.id, t.name, t.kind, COUNT(*)
+        FROM song_tags st
+        JOIN tags t ON t.id = st.tag_id
+        WHERE st.song_id IN (SELECT id FROM songs` + b.whereClause() + `)
+        GROUP BY t.id
+        ORDER BY COUNT(*) DESC, t.name
+    `
+
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	rows, err := r.db.Query(query, b.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error closing rows")
+		}
+	}(rows)
+
+	facets := []models.TagFacet{}
+	for rows.Next() {
+		var facet models.TagFacet
+		if err := rows.Scan(&facet.ID, &facet.Name, &facet.Kind, &facet.Count); err != nil {
+			return nil, err
+		}
+		facets = append(facets, facet)
+	}
+
+	return facets, rows.Err()
+}
+
+func (r *TagRepository) queryTags(query string, args ...interface{}) ([]models.Tag, error) {
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error closing rows")
+		}
+	}(rows)
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.Name, &tag.Kind); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
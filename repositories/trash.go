@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"case/models"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func (r *SongRepository) GetTrashedSongs(page, limit int) ([]models.TrashedSong, int, error) {
+	query := `
+        SELECT ` + songColumns + `, deleted_at, COUNT(*) OVER() FROM songs
+        WHERE deleted_at IS NOT NULL
+        ORDER BY deleted_at DESC, id DESC
+        LIMIT $1 OFFSET $2
+    `
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	rows, err := r.db.Query(query, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func(rows *sql.Rows) {
+		err := rows.Close()
+		if err != nil {
+			r.log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Error closing rows")
+		}
+	}(rows)
+
+	var songs []models.TrashedSong
+	total := 0
+	for rows.Next() {
+		var song models.TrashedSong
+		if err := rows.Scan(append(songFields(&song.Song), &song.DeletedAt, &total)...); err != nil {
+			return nil, 0, err
+		}
+		songs = append(songs, song)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if len(songs) == 0 && page > 1 {
+		if err := r.db.QueryRow(`SELECT COUNT(*) FROM songs WHERE deleted_at IS NOT NULL`).Scan(&total); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return songs, total, nil
+}
+
+func (r *SongRepository) RestoreSong(id int) error {
+	query := "UPDATE songs SET deleted_at = NULL, version = version + 1, updated_at = now() WHERE id = $1 AND deleted_at IS NOT NULL"
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	result, err := r.db.Exec(query, id)
+	return affectedOrNotFound(result, err, ErrSongNotFound)
+}
+
+// PurgeSongs deletes the trashed songs; lyrics lines, tags, revisions,
+// album tracks and playlist entries go with them through ON DELETE CASCADE.
+func (r *SongRepository) PurgeSongs(cutoff time.Time) (int, error) {
+	query := "DELETE FROM songs WHERE deleted_at < $1"
+	r.log.WithFields(logrus.Fields{
+		"query": query,
+	}).Debug("Executing SQL query")
+
+	result, err := r.db.Exec(query, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	purged, err := result.RowsAffected()
+	return int(purged), err
+}
+
+// lockLiveSong returns ErrSongNotFound unless the song exists outside the
+// trash. The row stays locked until the transaction ends, so the song
+// cannot be trashed meanwhile.
+func lockLiveSong(q queryRower, songID int) error {
+	var exists int
+	err := q.QueryRow(`SELECT 1 FROM songs WHERE id = $1 AND deleted_at IS NULL FOR SHARE`, songID).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrSongNotFound
+	}
+	return err
+}
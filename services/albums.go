@@ -0,0 +1,80 @@
+package services
+
+import (
+	"case/models"
+	"case/repositories"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidAlbum = errors.New("album title must not be empty")
+
+type AlbumService struct {
+	repo    repositories.AlbumStore
+	artists repositories.ArtistStore
+}
+
+func NewAlbumService(repo repositories.AlbumStore, artists repositories.ArtistStore) *AlbumService {
+	return &AlbumService{repo: repo, artists: artists}
+}
+
+func (s *AlbumService) GetAlbums(artistID *int, page, limit int) ([]models.Album, int, error) {
+	return s.repo.GetAlbums(artistID, page, limit)
+}
+
+// GetAlbum returns the album together with its tracks ordered by disc and
+// track number.
+func (s *AlbumService) GetAlbum(id int) (models.Album, []models.AlbumTrack, error) {
+	album, err := s.repo.GetAlbum(id)
+	if err != nil {
+		return album, nil, err
+	}
+
+	tracks, err := s.repo.GetAlbumTracks(id)
+	return album, tracks, err
+}
+
+func (s *AlbumService) AddAlbum(album *models.Album) error {
+	if err := s.checkAlbum(album); err != nil {
+		return err
+	}
+	return s.repo.AddAlbum(album)
+}
+
+func (s *AlbumService) UpdateAlbum(album *models.Album) error {
+	if err := s.checkAlbum(album); err != nil {
+		return err
+	}
+	return s.repo.UpdateAlbum(album)
+}
+
+func (s *AlbumService) DeleteAlbum(id int) error {
+	return s.repo.DeleteAlbum(id)
+}
+
+func (s *AlbumService) AddTrack(albumID int, req models.AddTrackRequest) error {
+	if req.DiscNumber == 0 {
+		req.DiscNumber = 1
+	}
+	return s.repo.AddTrack(albumID, req.SongID, req.DiscNumber, req.TrackNumber)
+}
+
+func (s *AlbumService) RemoveTrack(albumID, songID int) error {
+	return s.repo.RemoveTrack(albumID, songID)
+}
+
+func (s *AlbumService) ReorderTracks(albumID int, order []models.TrackPosition) error {
+	return s.repo.ReorderTracks(albumID, order)
+}
+
+// checkAlbum trims the title and makes sure the artist exists, so stores
+// without foreign keys reject the same albums as PostgreSQL.
+func (s *AlbumService) checkAlbum(album *models.Album) error {
+	album.Title = strings.TrimSpace(album.Title)
+	if album.Title == "" {
+		return ErrInvalidAlbum
+	}
+
+	_, err := s.artists.GetArtist(album.ArtistID)
+	return err
+}
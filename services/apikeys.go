@@ -0,0 +1,181 @@
+package services
+
+import (
+	"case/models"
+	"case/repositories"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	ErrAPIKeyRevoked = errors.New("api key revoked")
+	// ErrUnknownAPIKey is returned by Authenticate for keys that do not
+	// exist, do not match or are revoked.
+	ErrUnknownAPIKey = errors.New("unknown api key")
+	ErrAPIKeyExpired = errors.New("api key expired")
+)
+
+// apiKeyPrefix starts every key so that leaked keys are easy to scan for.
+const apiKeyPrefix = "sk_"
+
+// APIKeySubjectPrefix starts the subject of requests authenticated with an
+// API key, followed by the key ID.
+const APIKeySubjectPrefix = "apikey:"
+
+// APIKeyService issues and checks API keys of the form
+// sk_<prefix>_<secret>. The prefix identifies the key; only the SHA-256 of
+// the whole key is stored, so a key cannot be shown again once issued.
+type APIKeyService struct {
+	repo repositories.APIKeyStore
+}
+
+func NewAPIKeyService(repo repositories.APIKeyStore) *APIKeyService {
+	return &APIKeyService{repo: repo}
+}
+
+func (s *APIKeyService) GetAPIKeys() ([]models.APIKey, error) {
+	return s.repo.GetAPIKeys()
+}
+
+// CreateAPIKey stores a new key and returns it with its secret.
+func (s *APIKeyService) CreateAPIKey(request models.CreateAPIKeyRequest) (models.APIKey, string, error) {
+	key := models.APIKey{
+		Name:      strings.TrimSpace(request.Name),
+		Scopes:    request.Scopes,
+		ExpiresAt: request.ExpiresAt,
+	}
+	if key.Name == "" {
+		return key, "", fmt.Errorf("%w: name is required", ErrInvalidAPIKey)
+	}
+	if len(key.Scopes) == 0 {
+		return key, "", fmt.Errorf("%w: at least one scope is required", ErrInvalidAPIKey)
+	}
+	for _, scope := range key.Scopes {
+		if !isPermission(scope) {
+			return key, "", fmt.Errorf("%w: unknown scope %q", ErrInvalidAPIKey, scope)
+		}
+	}
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now()) {
+		return key, "", fmt.Errorf("%w: expires_at must be in the future", ErrInvalidAPIKey)
+	}
+
+	secret, err := newAPIKey()
+	if err != nil {
+		return key, "", err
+	}
+	key.Prefix, key.Hash = secret.prefix, secret.hash
+	if err := s.repo.AddAPIKey(&key); err != nil {
+		return key, "", err
+	}
+	return key, secret.key, nil
+}
+
+// RotateAPIKey replaces the secret of a key. The old secret stops working
+// immediately; name, scopes and expiry are kept.
+func (s *APIKeyService) RotateAPIKey(id int) (models.APIKey, string, error) {
+	key, err := s.repo.GetAPIKey(id)
+	if err != nil {
+		return key, "", err
+	}
+	if key.RevokedAt != nil {
+		return key, "", ErrAPIKeyRevoked
+	}
+
+	secret, err := newAPIKey()
+	if err != nil {
+		return key, "", err
+	}
+	if err := s.repo.RotateAPIKey(id, secret.prefix, secret.hash); err != nil {
+		return key, "", err
+	}
+	key.Prefix, key.Hash = secret.prefix, secret.hash
+	return key, secret.key, nil
+}
+
+func (s *APIKeyService) RevokeAPIKey(id int) (models.APIKey, error) {
+	if err := s.repo.RevokeAPIKey(id, time.Now().UTC()); err != nil {
+		return models.APIKey{}, err
+	}
+	return s.repo.GetAPIKey(id)
+}
+
+// Authenticate checks a key presented by a client and returns the claims to
+// authorize the request with.
+func (s *APIKeyService) Authenticate(presented string) (*Claims, error) {
+	rest, ok := strings.CutPrefix(presented, apiKeyPrefix)
+	if !ok {
+		return nil, ErrUnknownAPIKey
+	}
+	prefix, _, ok := strings.Cut(rest, "_")
+	if !ok || prefix == "" {
+		return nil, ErrUnknownAPIKey
+	}
+
+	key, err := s.repo.FindAPIKeyByPrefix(prefix)
+	if errors.Is(err, repositories.ErrAPIKeyNotFound) {
+		return nil, ErrUnknownAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(presented)), []byte(key.Hash)) != 1 || key.RevokedAt != nil {
+		return nil, ErrUnknownAPIKey
+	}
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now()) {
+		return nil, ErrAPIKeyExpired
+	}
+
+	claims := &Claims{
+		Subject:  APIKeySubjectPrefix + strconv.Itoa(key.ID),
+		APIKeyID: key.ID,
+		Scopes:   key.Scopes,
+	}
+	if key.ExpiresAt != nil {
+		claims.ExpiresAt = key.ExpiresAt.Unix()
+	}
+	return claims, nil
+}
+
+// MarkUsed records that the key authenticated a request.
+func (s *APIKeyService) MarkUsed(id int) error {
+	return s.repo.TouchAPIKey(id, time.Now().UTC())
+}
+
+type issuedAPIKey struct {
+	key, prefix, hash string
+}
+
+func newAPIKey() (issuedAPIKey, error) {
+	raw := make([]byte, 6+32)
+	if _, err := rand.Read(raw); err != nil {
+		return issuedAPIKey{}, err
+	}
+	prefix := hex.EncodeToString(raw[:6])
+	key := apiKeyPrefix + prefix + "_" + base64.RawURLEncoding.EncodeToString(raw[6:])
+	return issuedAPIKey{key: key, prefix: prefix, hash: hashAPIKey(key)}, nil
+}
+
+// hashAPIKey is a plain SHA-256: keys carry 256 random bits, so a slow
+// password hash would add latency to every request without adding safety.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func isPermission(name string) bool {
+	for _, permission := range models.Permissions {
+		if permission == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"case/models"
+	"case/repositories"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newTestAPIKeyService() (*APIKeyService, *repositories.MemoryAPIKeyRepository) {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	repo := repositories.NewMemoryAPIKeyRepository(log)
+	return NewAPIKeyService(repo), repo
+}
+
+func TestAPIKeyServiceAuthenticate(t *testing.T) {
+	s, repo := newTestAPIKeyService()
+
+	key, secret, err := s.CreateAPIKey(models.CreateAPIKeyRequest{Name: "nightly-import", Scopes: []string{models.PermSongsWrite}})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+
+	revoked, revokedSecret, err := s.CreateAPIKey(models.CreateAPIKeyRequest{Name: "old", Scopes: []string{models.PermSongsWrite}})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	if _, err := s.RevokeAPIKey(revoked.ID); err != nil {
+		t.Fatalf("RevokeAPIKey: %v", err)
+	}
+
+	// CreateAPIKey refuses past expiry dates, so store an expired key directly.
+	issued, err := newAPIKey()
+	if err != nil {
+		t.Fatalf("newAPIKey: %v", err)
+	}
+	past := time.Now().Add(-time.Minute)
+	expired := models.APIKey{Name: "expired", Prefix: issued.prefix, Hash: issued.hash, Scopes: []string{models.PermSongsWrite}, ExpiresAt: &past}
+	if err := repo.AddAPIKey(&expired); err != nil {
+		t.Fatalf("AddAPIKey: %v", err)
+	}
+
+	prefix, _, _ := strings.Cut(strings.TrimPrefix(secret, apiKeyPrefix), "_")
+
+	tests := []struct {
+		name    string
+		key     string
+		wantErr error
+	}{
+		{"valid", secret, nil},
+		{"wrong secret for a known prefix", apiKeyPrefix + prefix + "_guessed", ErrUnknownAPIKey},
+		{"unknown prefix", apiKeyPrefix + "000000000000_secret", ErrUnknownAPIKey},
+		{"missing sk_ prefix", strings.TrimPrefix(secret, apiKeyPrefix), ErrUnknownAPIKey},
+		{"no separator", apiKeyPrefix + prefix, ErrUnknownAPIKey},
+		{"empty", "", ErrUnknownAPIKey},
+		{"revoked", revokedSecret, ErrUnknownAPIKey},
+		{"expired", issued.key, ErrAPIKeyExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := s.Authenticate(tt.key)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Authenticate error = %v; want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate: %v", err)
+			}
+			if claims.Subject != APIKeySubjectPrefix+strconv.Itoa(key.ID) || claims.APIKeyID != key.ID {
+				t.Errorf("Authenticate subject = %q, key id %d; want key %d", claims.Subject, claims.APIKeyID, key.ID)
+			}
+			if len(claims.Scopes) != 1 || claims.Scopes[0] != models.PermSongsWrite {
+				t.Errorf("Authenticate scopes = %v; want [%s]", claims.Scopes, models.PermSongsWrite)
+			}
+		})
+	}
+}
+
+func TestAPIKeyServiceRotate(t *testing.T) {
+	s, _ := newTestAPIKeyService()
+
+	key, old, err := s.CreateAPIKey(models.CreateAPIKeyRequest{Name: "ci", Scopes: []string{models.PermSongsWrite}})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	_, rotated, err := s.RotateAPIKey(key.ID)
+	if err != nil {
+		t.Fatalf("RotateAPIKey: %v", err)
+	}
+
+	if _, err := s.Authenticate(old); !errors.Is(err, ErrUnknownAPIKey) {
+		t.Errorf("Authenticate with the old secret: %v; want ErrUnknownAPIKey", err)
+	}
+	if _, err := s.Authenticate(rotated); err != nil {
+		t.Errorf("Authenticate with the rotated secret: %v", err)
+	}
+}
+
+func TestAPIKeyServiceCreateValidates(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		request models.CreateAPIKeyRequest
+	}{
+		{"blank name", models.CreateAPIKeyRequest{Name: "  ", Scopes: []string{models.PermSongsWrite}}},
+		{"no scopes", models.CreateAPIKeyRequest{Name: "ci"}},
+		{"unknown scope", models.CreateAPIKeyRequest{Name: "ci", Scopes: []string{"songs:everything"}}},
+		{"past expiry", models.CreateAPIKeyRequest{Name: "ci", Scopes: []string{models.PermSongsWrite}, ExpiresAt: &past}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestAPIKeyService()
+			if _, _, err := s.CreateAPIKey(tt.request); !errors.Is(err, ErrInvalidAPIKey) {
+				t.Errorf("CreateAPIKey error = %v; want ErrInvalidAPIKey", err)
+			}
+		})
+	}
+}
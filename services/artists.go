@@ -0,0 +1,85 @@
+package services
+
+import (
+	"case/models"
+	"case/repositories"
+	"errors"
+	"strings"
+)
+
+var ErrInvalidArtist = errors.New("artist name must not be empty")
+
+type ArtistService struct {
+	repo repositories.ArtistStore
+}
+
+func NewArtistService(repo repositories.ArtistStore) *ArtistService {
+	return &ArtistService{repo: repo}
+}
+
+func (s *ArtistService) GetArtists(nameContains string, page, limit int) ([]models.Artist, int, error) {
+	return s.repo.GetArtists(nameContains, page, limit)
+}
+
+func (s *ArtistService) GetArtist(id int) (models.Artist, error) {
+	return s.repo.GetArtist(id)
+}
+
+func (s *ArtistService) AddArtist(artist *models.Artist) error {
+	if err := cleanArtist(artist); err != nil {
+		return err
+	}
+	return s.repo.AddArtist(artist)
+}
+
+func (s *ArtistService) UpdateArtist(artist *models.Artist) error {
+	if err := cleanArtist(artist); err != nil {
+		return err
+	}
+	return s.repo.UpdateArtist(artist)
+}
+
+func (s *ArtistService) DeleteArtist(id int) error {
+	return s.repo.DeleteArtist(id)
+}
+
+// cleanArtist trims the name and aliases and drops aliases that normalize to
+// the name itself or repeat each other.
+func cleanArtist(artist *models.Artist) error {
+	artist.Name = strings.TrimSpace(artist.Name)
+	if artist.Name == "" {
+		return ErrInvalidArtist
+	}
+
+	seen := map[string]bool{models.NormalizeArtistName(artist.Name): true}
+	aliases := []string{}
+	for _, alias := range artist.Aliases {
+		alias = strings.TrimSpace(alias)
+		key := models.NormalizeArtistName(alias)
+		if alias == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		aliases = append(aliases, alias)
+	}
+	artist.Aliases = aliases
+
+	return nil
+}
+
+// resolveArtist finds the artist a song is credited to by name or alias,
+// creating it on first use.
+func resolveArtist(repo repositories.ArtistStore, name string) (models.Artist, error) {
+	artist, err := repo.FindArtistByName(name)
+	if !errors.Is(err, repositories.ErrArtistNotFound) {
+		return artist, err
+	}
+
+	artist = models.Artist{Name: strings.TrimSpace(name)}
+	err = repo.AddArtist(&artist)
+	if errors.Is(err, repositories.ErrArtistExists) {
+		// Created concurrently by another request.
+		return repo.FindArtistByName(name)
+	}
+	return artist, err
+}
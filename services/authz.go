@@ -0,0 +1,71 @@
+package services
+
+import (
+	"case/repositories"
+)
+
+// AuthzService answers which roles and permissions a token subject has.
+// Subjects without roles of their own get the default role.
+type AuthzService struct {
+	repo        repositories.RoleStore
+	defaultRole string
+}
+
+func NewAuthzService(repo repositories.RoleStore, defaultRole string) *AuthzService {
+	return &AuthzService{repo: repo, defaultRole: defaultRole}
+}
+
+func (s *AuthzService) Roles(subject string) ([]string, error) {
+	roles, err := s.repo.GetSubjectRoles(subject)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) == 0 && s.defaultRole != "" {
+		roles = []string{s.defaultRole}
+	}
+	return roles, nil
+}
+
+func (s *AuthzService) Permissions(subject string) ([]string, error) {
+	roles, err := s.Roles(subject)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetRolePermissions(roles)
+}
+
+func (s *AuthzService) HasPermission(subject, permission string) (bool, error) {
+	permissions, err := s.Permissions(subject)
+	if err != nil {
+		return false, err
+	}
+	for _, granted := range permissions {
+		if granted == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Authorize reports whether the authenticated caller holds permission. API
+// keys are limited to their scopes; token subjects get their roles'
+// permissions.
+func (s *AuthzService) Authorize(claims *Claims, permission string) (bool, error) {
+	if claims.APIKeyID != 0 {
+		for _, scope := range claims.Scopes {
+			if scope == permission {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return s.HasPermission(claims.Subject, permission)
+}
+
+func (s *AuthzService) GrantRole(subject, role string) error {
+	return s.repo.GrantRole(subject, role)
+}
+
+func (s *AuthzService) RevokeRole(subject, role string) error {
+	return s.repo.RevokeRole(subject, role)
+}
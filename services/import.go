@@ -0,0 +1,198 @@
+package services
+
+import (
+	"case/models"
+	"case/repositories"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrImportAborted wraps the store error that stopped an import.
+	ErrImportAborted = errors.New("import aborted")
+	// ErrImportInput wraps the error that made the rest of the input
+	// unreadable, such as a line too long.
+	ErrImportInput = errors.New("unreadable import input")
+)
+
+// ImportSongs reads songs from reader and adds the valid ones in batches of
+// batchSize, one transaction each. Songs are linked to their artists like in
+// AddSong, but the song info service is not asked for missing fields.
+// Unless allowDuplicates is set, songs with the group and title of an
+// existing song or of an earlier row are skipped. Rows that are not valid
+// songs fail without affecting the others; a store error stops the import
+// and is returned together with the report so far.
+func (s *SongService) ImportSongs(reader models.SongReader, batchSize int, allowDuplicates bool) (models.ImportReport, error) {
+	imp := songImport{
+		service:         s,
+		report:          models.ImportReport{Rows: []models.ImportRow{}},
+		artists:         make(artistCache),
+		seen:            make(map[models.SongKey]bool),
+		allowDuplicates: allowDuplicates,
+	}
+
+	for {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return imp.finish(fmt.Errorf("%w: %v", ErrImportInput, err))
+		}
+
+		if err := imp.add(record); err != nil {
+			return imp.finish(err)
+		}
+		if len(imp.batch) >= batchSize {
+			if err := imp.flush(); err != nil {
+				return imp.finish(err)
+			}
+		}
+	}
+
+	return imp.finish(imp.flush())
+}
+
+// songImport is the state of one ImportSongs call.
+type songImport struct {
+	service *SongService
+	report  models.ImportReport
+	artists artistCache
+	// seen holds the keys of the rows accepted so far.
+	seen            map[models.SongKey]bool
+	allowDuplicates bool
+	// batch holds the songs waiting to be saved and rows the index of
+	// their report rows.
+	batch []models.Song
+	rows  []int
+}
+
+// add validates a record and queues it for the next batch. It only returns
+// store errors; invalid rows are reported as failed.
+func (imp *songImport) add(record models.ImportRecord) error {
+	row := models.ImportRow{Line: record.Line, Song: record.Song.Song}
+
+	song := record.Song
+	err := record.Err
+	if err == nil && (song.Song == "" || (song.Group == "" && song.ArtistID == 0)) {
+		err = ErrInvalidSong
+	}
+	if err == nil {
+		// Artists of new names are only created by flush, for the songs
+		// that are saved.
+		var artist models.Artist
+		artist, err = imp.service.findArtist(song, imp.artists)
+		switch {
+		case err == nil:
+			err = setArtist(&song, artist)
+		case !errors.Is(err, repositories.ErrArtistNotFound):
+			return fmt.Errorf("%w: %v", ErrImportAborted, err)
+		case song.ArtistID != 0:
+			err = fmt.Errorf("unknown artist_id %d", song.ArtistID)
+		default:
+			err = nil
+		}
+	}
+	if err != nil {
+		row.Status, row.Error = models.ImportFailed, err.Error()
+		imp.report.Rows = append(imp.report.Rows, row)
+		return nil
+	}
+
+	if !imp.allowDuplicates {
+		key := song.Key()
+		if imp.seen[key] {
+			row.Status, row.Error = models.ImportSkipped, "duplicate of an earlier row"
+			imp.report.Rows = append(imp.report.Rows, row)
+			return nil
+		}
+		imp.seen[key] = true
+	}
+
+	imp.rows = append(imp.rows, len(imp.report.Rows))
+	imp.report.Rows = append(imp.report.Rows, row)
+	imp.batch = append(imp.batch, song)
+	return nil
+}
+
+// flush saves the queued songs that do not exist yet, creating the artists
+// of new group names first.
+func (imp *songImport) flush() error {
+	if len(imp.batch) == 0 {
+		return nil
+	}
+	batch, rows := imp.batch, imp.rows
+	imp.batch, imp.rows = nil, nil
+
+	if !imp.allowDuplicates {
+		keys := make([]models.SongKey, len(batch))
+		for i, song := range batch {
+			keys[i] = song.Key()
+		}
+		existing, err := imp.service.repo.GetExistingSongKeys(keys)
+		if err != nil {
+			imp.fail(rows, "not saved, the import was aborted")
+			return fmt.Errorf("%w: %v", ErrImportAborted, err)
+		}
+
+		kept, keptRows := batch[:0], rows[:0]
+		for i, song := range batch {
+			if existing[song.Key()] {
+				imp.report.Rows[rows[i]].Status = models.ImportSkipped
+				imp.report.Rows[rows[i]].Error = "song already exists"
+				continue
+			}
+			kept, keptRows = append(kept, song), append(keptRows, rows[i])
+		}
+		batch, rows = kept, keptRows
+	}
+
+	for i := range batch {
+		if batch[i].ArtistID != 0 {
+			continue
+		}
+		if err := imp.service.linkArtist(&batch[i], imp.artists); err != nil {
+			imp.fail(rows, "not saved, the import was aborted")
+			return fmt.Errorf("%w: %v", ErrImportAborted, err)
+		}
+	}
+
+	if err := imp.service.repo.AddSongs(batch); err != nil {
+		imp.fail(rows, "not saved, the import was aborted")
+		return fmt.Errorf("%w: %v", ErrImportAborted, err)
+	}
+	for i, song := range batch {
+		imp.report.Rows[rows[i]].Status = models.ImportCreated
+		imp.report.Rows[rows[i]].SongID = song.ID
+	}
+	return nil
+}
+
+// fail marks the report rows at indexes as failed.
+func (imp *songImport) fail(indexes []int, message string) {
+	for _, i := range indexes {
+		imp.report.Rows[i].Status, imp.report.Rows[i].Error = models.ImportFailed, message
+	}
+}
+
+// finish counts the outcomes and returns the report with err. Rows still
+// waiting for a batch when the import stops are reported as failed.
+func (imp *songImport) finish(err error) (models.ImportReport, error) {
+	if err != nil {
+		imp.fail(imp.rows, "not saved, the import was aborted")
+		imp.report.Error = err.Error()
+	}
+
+	for _, row := range imp.report.Rows {
+		switch row.Status {
+		case models.ImportCreated:
+			imp.report.Created++
+		case models.ImportSkipped:
+			imp.report.Skipped++
+		case models.ImportFailed:
+			imp.report.Failed++
+		}
+	}
+	return imp.report, err
+}
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"case/models"
+	"case/repositories"
+	"errors"
+	"io"
+	"testing"
+)
+
+// recordReader returns its records and then err, or io.EOF when err is nil.
+type recordReader struct {
+	records []models.ImportRecord
+	err     error
+}
+
+func (r *recordReader) Next() (models.ImportRecord, error) {
+	if len(r.records) == 0 {
+		if r.err != nil {
+			return models.ImportRecord{}, r.err
+		}
+		return models.ImportRecord{}, io.EOF
+	}
+	record := r.records[0]
+	r.records = r.records[1:]
+	return record, nil
+}
+
+func TestImportSongsCreatesArtistsOnlyForSavedSongs(t *testing.T) {
+	s, artists := newTestSongService("")
+	reader := &recordReader{
+		records: []models.ImportRecord{
+			{Line: 2, Song: models.Song{Group: "Muse", Song: "Uprising"}},
+			{Line: 3, Song: models.Song{Group: "muse", Song: "Resistance"}},
+			{Line: 4, Song: models.Song{Group: "Queen", Song: "Bohemian Rhapsody"}},
+		},
+		err: errors.New("line too long"),
+	}
+
+	// The first batch is saved; the input breaks before the second is.
+	report, err := s.ImportSongs(reader, 2, false)
+	if !errors.Is(err, ErrImportInput) {
+		t.Fatalf("ImportSongs error = %v; want ErrImportInput", err)
+	}
+	if report.Created != 2 || report.Failed != 1 {
+		t.Errorf("report created %d and failed %d rows; want 2 and 1", report.Created, report.Failed)
+	}
+
+	muse, err := artists.FindArtistByName("Muse")
+	if err != nil {
+		t.Fatalf("FindArtistByName(Muse): %v", err)
+	}
+	if list, total, _ := artists.GetArtists("", 1, 10); total != 1 || list[0].ID != muse.ID {
+		t.Errorf("artists = %+v; want only Muse", list)
+	}
+	if _, err := artists.FindArtistByName("Queen"); !errors.Is(err, repositories.ErrArtistNotFound) {
+		t.Errorf("FindArtistByName(Queen) = %v; want ErrArtistNotFound", err)
+	}
+}
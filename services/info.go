@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrUpstreamUnavailable = errors.New("song info service is unavailable")
+	ErrUpstreamTimeout     = errors.New("song info service timed out")
+)
+
+// SongDetail is the payload returned by the external song info service.
+type SongDetail struct {
+	ReleaseDate string `json:"releaseDate"`
+	Text        string `json:"text"`
+	Link        string `json:"link"`
+}
+
+// InfoClient queries GET {API_URL}?group=..&song=.. for song details.
+type InfoClient struct {
+	baseURL string
+	client  *http.Client
+	retries int
+	backoff time.Duration
+	log     *logrus.Logger
+}
+
+func NewInfoClient(baseURL string, timeout time.Duration, retries int, log *logrus.Logger) *InfoClient {
+	if retries < 0 {
+		retries = 0
+	}
+	return &InfoClient{
+		baseURL: baseURL,
+		client:  &http.Client{Timeout: timeout},
+		retries: retries,
+		backoff: 200 * time.Millisecond,
+		log:     log,
+	}
+}
+
+func (c *InfoClient) BaseURL() string {
+	return c.baseURL
+}
+
+func (c *InfoClient) Enabled() bool {
+	return c.baseURL != ""
+}
+
+// GetSongDetail asks for the details of a song, retrying failures that may
+// pass until ctx is done. A song the service does not know has no details.
+func (c *InfoClient) GetSongDetail(ctx context.Context, group, song string) (*SongDetail, error) {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid API_URL: %v", ErrUpstreamUnavailable, err)
+	}
+	q := u.Query()
+	q.Set("group", group)
+	q.Set("song", song)
+	u.RawQuery = q.Encode()
+
+	var lastErr error
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, fmt.Errorf("%w: %v", ErrUpstreamUnavailable, ctx.Err())
+			case <-time.After(c.backoff * time.Duration(attempt)):
+			}
+		}
+
+		detail, retry, err := c.fetch(ctx, u.String())
+		if err == nil {
+			return detail, nil
+		}
+		lastErr = err
+
+		c.log.WithFields(logrus.Fields{
+			"url":     u.String(),
+			"attempt": attempt + 1,
+			"error":   err,
+		}).Warn("Song info request failed")
+
+		if !retry || ctx.Err() != nil {
+			break
+		}
+	}
+
+	return nil, lastErr
+}
+
+func (c *InfoClient) fetch(ctx context.Context, rawURL string) (*SongDetail, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrUpstreamUnavailable, err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return nil, true, fmt.Errorf("%w: %v", ErrUpstreamTimeout, err)
+		}
+		return nil, true, fmt.Errorf("%w: %v", ErrUpstreamUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return &SongDetail{}, false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return nil, retry, fmt.Errorf("%w: unexpected status %d", ErrUpstreamUnavailable, resp.StatusCode)
+	}
+
+	var detail SongDetail
+	if err := json.NewDecoder(resp.Body).Decode(&detail); err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return nil, true, fmt.Errorf("%w: %v", ErrUpstreamTimeout, err)
+		}
+		return nil, false, fmt.Errorf("%w: invalid response: %v", ErrUpstreamUnavailable, err)
+	}
+
+	return &detail, false, nil
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"case/models"
+	"case/repositories"
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidPlaylist  = errors.New("playlist name must not be empty")
+	ErrPrivatePlaylist  = errors.New("playlist is private")
+	ErrNotPlaylistOwner = errors.New("playlist belongs to another subject")
+)
+
+type PlaylistService struct {
+	repo repositories.PlaylistStore
+}
+
+func NewPlaylistService(repo repositories.PlaylistStore) *PlaylistService {
+	return &PlaylistService{repo: repo}
+}
+
+// GetPlaylists lists the public playlists and those of viewer, the subject
+// of the request or "" for anonymous requests.
+func (s *PlaylistService) GetPlaylists(public *bool, viewer string, page, limit int) ([]models.Playlist, int, error) {
+	return s.repo.GetPlaylists(public, viewer, page, limit)
+}
+
+// GetPlaylist returns the playlist together with its entries in order. A
+// private playlist of another subject yields ErrPrivatePlaylist.
+func (s *PlaylistService) GetPlaylist(id int, viewer string) (models.Playlist, []models.PlaylistEntry, error) {
+	playlist, err := s.repo.GetPlaylist(id)
+	if err != nil {
+		return playlist, nil, err
+	}
+	if !playlist.CanRead(viewer) {
+		return models.Playlist{}, nil, ErrPrivatePlaylist
+	}
+
+	entries, err := s.repo.GetPlaylistEntries(id)
+	return playlist, entries, err
+}
+
+func (s *PlaylistService) AddPlaylist(playlist *models.Playlist) error {
+	if err := cleanPlaylist(playlist); err != nil {
+		return err
+	}
+	return s.repo.AddPlaylist(playlist)
+}
+
+// UpdatePlaylist and the other edits below are allowed only to the owner of
+// the playlist, the subject of the request.
+func (s *PlaylistService) UpdatePlaylist(playlist *models.Playlist, subject string) error {
+	if err := cleanPlaylist(playlist); err != nil {
+		return err
+	}
+	if err := s.checkOwner(playlist.ID, subject); err != nil {
+		return err
+	}
+	return s.repo.UpdatePlaylist(playlist)
+}
+
+func (s *PlaylistService) DeletePlaylist(id int, subject string) error {
+	if err := s.checkOwner(id, subject); err != nil {
+		return err
+	}
+	return s.repo.DeletePlaylist(id)
+}
+
+func (s *PlaylistService) AddEntry(playlistID int, subject string, req models.AddPlaylistEntryRequest) (int, error) {
+	if err := s.checkOwner(playlistID, subject); err != nil {
+		return 0, err
+	}
+	return s.repo.AddEntry(playlistID, req.SongID, req.AfterEntryID)
+}
+
+func (s *PlaylistService) RemoveEntry(playlistID, entryID int, subject string) error {
+	if err := s.checkOwner(playlistID, subject); err != nil {
+		return err
+	}
+	return s.repo.RemoveEntry(playlistID, entryID)
+}
+
+func (s *PlaylistService) MoveEntry(playlistID, entryID int, subject string, req models.MovePlaylistEntryRequest) error {
+	if err := s.checkOwner(playlistID, subject); err != nil {
+		return err
+	}
+	return s.repo.MoveEntry(playlistID, entryID, req.AfterEntryID)
+}
+
+// checkOwner returns ErrNotPlaylistOwner unless subject owns the playlist.
+// The owner never changes, so the check cannot go stale before the edit.
+func (s *PlaylistService) checkOwner(id int, subject string) error {
+	playlist, err := s.repo.GetPlaylist(id)
+	if err != nil {
+		return err
+	}
+	if playlist.Owner != subject {
+		return ErrNotPlaylistOwner
+	}
+	return nil
+}
+
+func cleanPlaylist(playlist *models.Playlist) error {
+	playlist.Name = strings.TrimSpace(playlist.Name)
+	if playlist.Name == "" {
+		return ErrInvalidPlaylist
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"case/models"
+)
+
+func (s *SongService) GetSongRevisions(songID, page, limit int) ([]models.SongRevision, int, error) {
+	return s.repo.GetSongRevisions(songID, page, limit)
+}
+
+func (s *SongService) GetSongRevision(songID, revision int) (models.SongRevision, error) {
+	return s.repo.GetSongRevision(songID, revision)
+}
+
+// DiffSongRevisions compares the text of two revisions of a song. A to of 0
+// stands for the latest revision.
+func (s *SongService) DiffSongRevisions(songID, from, to int) ([]models.DiffLine, int, error) {
+	if to == 0 {
+		latest, _, err := s.repo.GetSongRevisions(songID, 1, 1)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(latest) > 0 {
+			to = latest[0].Revision
+		}
+	}
+
+	older, err := s.repo.GetSongRevision(songID, from)
+	if err != nil {
+		return nil, 0, err
+	}
+	newer, err := s.repo.GetSongRevision(songID, to)
+	if err != nil {
+		return nil, 0, err
+	}
+	lines, err := models.DiffLines(older.Song.Text, newer.Song.Text)
+	if err != nil {
+		return nil, 0, err
+	}
+	return lines, to, nil
+}
+
+// RestoreSongRevision writes an earlier state of a song back as a new
+// revision, so the restore itself can be undone. The song keeps the
+// revision's artist under the artist's current name. Like UpdateSong it
+// applies only if the song is still at version; 0 skips the check.
+func (s *SongService) RestoreSongRevision(songID, revision, version int) (models.Song, error) {
+	stored, err := s.repo.GetSongRevision(songID, revision)
+	if err != nil {
+		return models.Song{}, err
+	}
+
+	song := stored.Song
+	song.ID = songID
+	// The artist may have been renamed since; linkArtist sets the new name.
+	song.Group = ""
+	song.Version = version
+	if err := s.UpdateSong(&song); err != nil {
+		return models.Song{}, err
+	}
+	return song, nil
+}
@@ -3,37 +3,304 @@ package services
 import (
 	"case/models"
 	"case/repositories"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// ErrInvalidSong is returned for songs without a title or without both a
+// group and an artist_id.
+var ErrInvalidSong = errors.New("song needs a title and a group or artist_id")
+
+// ErrArtistMismatch is returned when a song names both an artist_id and a
+// group that is neither the name nor an alias of that artist.
+var ErrArtistMismatch = errors.New("group does not match the artist of artist_id")
+
 type SongService struct {
-	repo   *repositories.SongRepository
-	apiURL string
+	repo    repositories.SongStore
+	artists repositories.ArtistStore
+	tags    repositories.TagStore
+	info    *InfoClient
+}
+
+func NewSongService(repo repositories.SongStore, artists repositories.ArtistStore, tags repositories.TagStore, info *InfoClient) *SongService {
+	return &SongService{repo: repo, artists: artists, tags: tags, info: info}
+}
+
+func (s *SongService) GetSong(id int) (models.Song, error) {
+	return s.repo.GetSong(id)
+}
+
+// GetSongsLastModified returns the time of the latest write to any song.
+func (s *SongService) GetSongsLastModified() (time.Time, error) {
+	return s.repo.GetSongsLastModified()
+}
+
+func (s *SongService) GetSongs(filter models.SongFilter, sort models.SongSort, page, limit int) ([]models.Song, int, error) {
+	filter, err := resolveTagFilter(s.tags, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.repo.GetSongs(filter, sort, page, limit)
+}
+
+func (s *SongService) GetSongsAfter(filter models.SongFilter, sort models.SongSort, cursor *models.SongCursor, limit int) ([]models.Song, *models.SongCursor, error) {
+	filter, err := resolveTagFilter(s.tags, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.repo.GetSongsAfter(filter, sort, cursor, limit)
 }
 
-func NewSongService(repo *repositories.SongRepository, apiURL string) *SongService {
-	return &SongService{repo: repo, apiURL: apiURL}
+// ExportSongs calls fn with every song matching filter in sort order.
+func (s *SongService) ExportSongs(filter models.SongFilter, sort models.SongSort, fn func(models.Song) error) error {
+	filter, err := resolveTagFilter(s.tags, filter)
+	if err != nil {
+		return err
+	}
+	return s.repo.ExportSongs(filter, sort, fn)
 }
 
-func (s *SongService) GetSongs(filter map[string]string, page, limit int) ([]models.Song, error) {
-	return s.repo.GetSongs(filter, page, limit)
+// GetTagFacets counts the songs matching filter per tag.
+func (s *SongService) GetTagFacets(filter models.SongFilter) ([]models.TagFacet, error) {
+	filter, err := resolveTagFilter(s.tags, filter)
+	if err != nil {
+		return nil, err
+	}
+	return s.tags.GetTagFacets(filter)
 }
 
-func (s *SongService) GetSongLyrics(id, page, limit int) (string, error) {
+func (s *SongService) GetSongLyrics(id, page, limit int) (models.Lyrics, int, error) {
 	return s.repo.GetSongLyrics(id, page, limit)
 }
 
-func (s *SongService) DeleteSong(id int) error {
-	return s.repo.DeleteSong(id)
+func (s *SongService) GetSyncedLyrics(songID int) ([]models.SyncedLine, error) {
+	return s.repo.GetSyncedLyrics(songID)
+}
+
+// ImportLRC parses an .lrc file and replaces the song's time-stamped lines.
+func (s *SongService) ImportLRC(songID, version int, data string) ([]models.SyncedLine, error) {
+	lines, err := models.ParseLRC(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetSyncedLyrics(songID, version, lines); err != nil {
+		return nil, err
+	}
+	return lines, nil
 }
 
+func (s *SongService) GetSyncedLineAt(songID, offsetMs int) (models.SyncedLine, error) {
+	return s.repo.GetSyncedLineAt(songID, offsetMs)
+}
+
+func (s *SongService) SearchSongs(query models.SearchQuery, page, limit int) ([]models.SongSearchResult, error) {
+	return s.repo.SearchSongs(query, page, limit)
+}
+
+// DeleteSong trashes a song if it is still at version; 0 skips the check.
+func (s *SongService) DeleteSong(id, version int) error {
+	return s.repo.DeleteSong(id, version)
+}
+
+// UpdateSong replaces every field of an existing song if it is still at
+// song.Version; 0 skips the check.
 func (s *SongService) UpdateSong(song *models.Song) error {
+	// Check first so that a missing or stale song does not create its artist.
+	current, err := s.repo.GetSong(song.ID)
+	if err != nil {
+		return err
+	}
+	if song.Version != 0 && song.Version != current.Version {
+		return repositories.ErrVersionConflict
+	}
+	if err := s.linkArtist(song, nil); err != nil {
+		return err
+	}
 	return s.repo.UpdateSong(song)
 }
 
-func (s *SongService) AddSong(song *models.Song) error {
+// PatchSong applies patch to the JSON representation of a song with apply
+// and stores the result. Changing the group without artist_id links the
+// song to the artist of the new name. The song must still be at version
+// unless that is 0, and is only written if nobody changed it meanwhile.
+func (s *SongService) PatchSong(id int, patch []byte, apply models.PatchFunc, version int) (models.Song, error) {
+	current, err := s.repo.GetSong(id)
+	if err != nil {
+		return models.Song{}, err
+	}
+	if version != 0 && version != current.Version {
+		return models.Song{}, repositories.ErrVersionConflict
+	}
+
+	doc, err := json.Marshal(current)
+	if err != nil {
+		return models.Song{}, err
+	}
+	patched, err := apply(doc, patch)
+	if err != nil {
+		return models.Song{}, err
+	}
+
+	var song models.Song
+	if err := json.Unmarshal(patched, &song); err != nil {
+		if errors.Is(err, models.ErrInvalidDate) {
+			return models.Song{}, err
+		}
+		return models.Song{}, fmt.Errorf("%w: %v", models.ErrInvalidPatch, err)
+	}
+	if song.ID != id {
+		return models.Song{}, fmt.Errorf("%w: id cannot be changed", models.ErrInvalidPatch)
+	}
+	if (song.Group == "" && song.ArtistID == 0) || song.Song == "" {
+		return models.Song{}, ErrInvalidSong
+	}
+	if song.ArtistID == current.ArtistID && song.Group != "" && song.Group != current.Group {
+		song.ArtistID = 0
+	}
+	if song.ArtistID != current.ArtistID && song.Group == current.Group {
+		// Only artist_id was patched; the group follows the new artist.
+		song.Group = ""
+	}
+
+	if err := s.linkArtist(&song, nil); err != nil {
+		return models.Song{}, err
+	}
+	song.Version = current.Version
+	if err := s.repo.UpdateSong(&song); err != nil {
+		return models.Song{}, err
+	}
+	return song, nil
+}
+
+// AddSong links the song to its artist and fills missing release date, text
+// and link from the song info service before persisting the song. An artist
+// the song introduces is only created once the info service has answered,
+// so that a failed lookup leaves no artist without songs behind. ctx bounds
+// the calls to the info service.
+func (s *SongService) AddSong(ctx context.Context, song *models.Song) error {
+	artist, err := s.findArtist(*song, nil)
+	switch {
+	case err == nil:
+		if err := setArtist(song, artist); err != nil {
+			return err
+		}
+	case song.ArtistID != 0 || !errors.Is(err, repositories.ErrArtistNotFound):
+		return err
+	}
+
+	if err := s.enrich(ctx, song); err != nil {
+		return err
+	}
+
+	if song.ArtistID == 0 {
+		if err := s.linkArtist(song, nil); err != nil {
+			return err
+		}
+	}
+
 	return s.repo.AddSong(song)
 }
 
+// artistCache holds the artists looked up for songs by "#id" and by
+// lower-case group name, so that an import asks the store once per artist.
+type artistCache map[string]models.Artist
+
+func artistKey(song models.Song) string {
+	if song.ArtistID != 0 {
+		return "#" + strconv.Itoa(song.ArtistID)
+	}
+	return strings.ToLower(strings.TrimSpace(song.Group))
+}
+
+// findArtist returns the artist of the song's artist_id, or of its group
+// name when no id is given, without creating one. A nil cache is not used.
+func (s *SongService) findArtist(song models.Song, cache artistCache) (models.Artist, error) {
+	key := artistKey(song)
+	if artist, ok := cache[key]; ok {
+		return artist, nil
+	}
+
+	var artist models.Artist
+	var err error
+	if song.ArtistID != 0 {
+		artist, err = s.artists.GetArtist(song.ArtistID)
+	} else {
+		artist, err = s.artists.FindArtistByName(song.Group)
+	}
+	if err == nil && cache != nil {
+		cache[key] = artist
+	}
+	return artist, err
+}
+
+// linkArtist sets the song's artist from artist_id, or from its group name
+// when no id is given, creating an artist for a new name. A nil cache is
+// not used.
+func (s *SongService) linkArtist(song *models.Song, cache artistCache) error {
+	artist, err := s.findArtist(*song, cache)
+	if song.ArtistID == 0 && errors.Is(err, repositories.ErrArtistNotFound) {
+		artist, err = resolveArtist(s.artists, song.Group)
+		if err == nil && cache != nil {
+			cache[artistKey(*song)] = artist
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return setArtist(song, artist)
+}
+
+// setArtist links the song to artist and replaces the group with the
+// artist's name. A group given along with artist_id must be a name of that
+// artist.
+func setArtist(song *models.Song, artist models.Artist) error {
+	if song.Group != "" && !artist.HasName(song.Group) {
+		return fmt.Errorf("%w: %q is not a name of artist %d", ErrArtistMismatch, song.Group, artist.ID)
+	}
+
+	song.ArtistID = artist.ID
+	song.Group = artist.Name
+	return nil
+}
+
+func (s *SongService) enrich(ctx context.Context, song *models.Song) error {
+	if s.info == nil || !s.info.Enabled() {
+		return nil
+	}
+	if !song.ReleaseDate.IsZero() && song.Text != "" && song.Link != "" {
+		return nil
+	}
+
+	detail, err := s.info.GetSongDetail(ctx, song.Group, song.Song)
+	if err != nil {
+		return err
+	}
+
+	if song.ReleaseDate.IsZero() && detail.ReleaseDate != "" {
+		date, err := models.ParseDate(detail.ReleaseDate)
+		if err != nil {
+			return fmt.Errorf("%w: invalid releaseDate: %v", ErrUpstreamUnavailable, err)
+		}
+		song.ReleaseDate = date
+	}
+	if song.Text == "" {
+		song.Text = detail.Text
+	}
+	if song.Link == "" {
+		song.Link = detail.Link
+	}
+
+	return nil
+}
+
 func (s *SongService) GetApiURL() string {
-	return s.apiURL
+	if s.info == nil {
+		return ""
+	}
+	return s.info.BaseURL()
 }
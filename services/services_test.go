@@ -0,0 +1,97 @@
+package services
+
+import (
+	"case/models"
+	"case/repositories"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// newTestSongService serves songs from memory and asks the song info
+// service at infoURL for missing details.
+func newTestSongService(infoURL string) (*SongService, *repositories.MemoryArtistRepository) {
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+
+	songs := repositories.NewMemorySongRepository(log)
+	artists := repositories.NewMemoryArtistRepository(songs, log)
+	tags := repositories.NewMemoryTagRepository(songs, log)
+	return NewSongService(songs, artists, tags, NewInfoClient(infoURL, time.Second, 0, log)), artists
+}
+
+func TestAddSongLeavesNoArtistWhenEnrichmentFails(t *testing.T) {
+	info := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer info.Close()
+	s, artists := newTestSongService(info.URL)
+
+	song := models.Song{Group: "Muse", Song: "Uprising"}
+	if err := s.AddSong(context.Background(), &song); !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Fatalf("AddSong error = %v; want ErrUpstreamUnavailable", err)
+	}
+	if artist, err := artists.FindArtistByName("Muse"); !errors.Is(err, repositories.ErrArtistNotFound) {
+		t.Errorf("FindArtistByName = %+v, %v; want ErrArtistNotFound", artist, err)
+	}
+}
+
+func TestAddSongKeepsAnUnknownReleaseDate(t *testing.T) {
+	s, _ := newTestSongService("")
+
+	song := models.Song{Group: "Muse", Song: "Uprising"}
+	if err := s.AddSong(context.Background(), &song); err != nil {
+		t.Fatalf("AddSong: %v", err)
+	}
+	stored, err := s.GetSong(song.ID)
+	if err != nil {
+		t.Fatalf("GetSong: %v", err)
+	}
+	if !stored.ReleaseDate.IsZero() {
+		t.Errorf("release date = %v; want none", stored.ReleaseDate)
+	}
+}
+
+func TestAddSongWithoutDetailsFromTheInfoService(t *testing.T) {
+	info := httptest.NewServer(http.NotFoundHandler())
+	defer info.Close()
+	s, _ := newTestSongService(info.URL)
+
+	song := models.Song{Group: "Muse", Song: "Uprising", Text: "Paranoia is in bloom"}
+	if err := s.AddSong(context.Background(), &song); err != nil {
+		t.Fatalf("AddSong: %v", err)
+	}
+	if song.ID == 0 || song.Text != "Paranoia is in bloom" || song.Link != "" {
+		t.Errorf("AddSong stored %+v; want the song as given", song)
+	}
+}
+
+func TestInfoClientStopsRetryingWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	requests := 0
+	info := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer info.Close()
+
+	log := logrus.New()
+	log.SetOutput(io.Discard)
+	client := NewInfoClient(info.URL, time.Second, 3, log)
+
+	if _, err := client.GetSongDetail(ctx, "Muse", "Uprising"); !errors.Is(err, ErrUpstreamUnavailable) {
+		t.Errorf("GetSongDetail error = %v; want ErrUpstreamUnavailable", err)
+	}
+	if requests != 1 {
+		t.Errorf("info service got %d requests; want 1", requests)
+	}
+}
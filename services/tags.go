@@ -0,0 +1,140 @@
+package services
+
+import (
+	"case/models"
+	"case/repositories"
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidTag = errors.New("invalid tag")
+
+type TagService struct {
+	repo repositories.TagStore
+}
+
+func NewTagService(repo repositories.TagStore) *TagService {
+	return &TagService{repo: repo}
+}
+
+func (s *TagService) GetTags(kind models.TagKind, page, limit int) ([]models.Tag, int, error) {
+	return s.repo.GetTags(kind, page, limit)
+}
+
+func (s *TagService) GetTag(id int) (models.Tag, error) {
+	return s.repo.GetTag(id)
+}
+
+func (s *TagService) AddTag(tag *models.Tag) error {
+	if err := cleanTag(tag); err != nil {
+		return err
+	}
+	return s.repo.AddTag(tag)
+}
+
+func (s *TagService) UpdateTag(tag *models.Tag) error {
+	if err := cleanTag(tag); err != nil {
+		return err
+	}
+	return s.repo.UpdateTag(tag)
+}
+
+func (s *TagService) DeleteTag(id int) error {
+	return s.repo.DeleteTag(id)
+}
+
+func (s *TagService) GetSongTags(songID int) ([]models.Tag, error) {
+	return s.repo.GetSongTags(songID)
+}
+
+// SetSongTags replaces the tags of a song by name. Names that are not tags
+// yet are created with kind "tag".
+func (s *TagService) SetSongTags(songID int, names []string) ([]models.Tag, error) {
+	var ids []int
+	for _, name := range names {
+		tag, err := s.resolveTag(name)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, tag.ID)
+	}
+
+	if err := s.repo.SetSongTags(songID, ids); err != nil {
+		return nil, err
+	}
+	return s.repo.GetSongTags(songID)
+}
+
+func (s *TagService) resolveTag(name string) (models.Tag, error) {
+	tag := models.Tag{Name: name, Kind: models.TagOther}
+	if err := cleanTag(&tag); err != nil {
+		return tag, err
+	}
+
+	found, err := s.repo.FindTags([]string{tag.Name})
+	if err != nil {
+		return tag, err
+	}
+	if len(found) > 0 {
+		return found[0], nil
+	}
+
+	err = s.repo.AddTag(&tag)
+	if errors.Is(err, repositories.ErrTagExists) {
+		// Created concurrently by another request.
+		found, err = s.repo.FindTags([]string{tag.Name})
+		if err == nil && len(found) == 0 {
+			err = repositories.ErrTagNotFound
+		}
+		if err != nil {
+			return tag, err
+		}
+		return found[0], nil
+	}
+	return tag, err
+}
+
+// cleanTag normalizes the name and defaults the kind to "tag".
+func cleanTag(tag *models.Tag) error {
+	tag.Name = models.NormalizeTagName(tag.Name)
+	if tag.Name == "" {
+		return fmt.Errorf("%w: name must not be empty", ErrInvalidTag)
+	}
+
+	if tag.Kind == "" {
+		tag.Kind = models.TagOther
+	}
+	if !tag.Kind.Valid() {
+		return fmt.Errorf("%w: kind must be genre, mood or tag. Got %q", ErrInvalidTag, tag.Kind)
+	}
+
+	return nil
+}
+
+// resolveTagFilter fills filter.TagIDs from the tag names in filter.Tags.
+// Unknown names become id 0, which no song carries, so they never match.
+func resolveTagFilter(repo repositories.TagStore, filter models.SongFilter) (models.SongFilter, error) {
+	if len(filter.Tags) == 0 {
+		return filter, nil
+	}
+
+	names := make([]string, len(filter.Tags))
+	for i, name := range filter.Tags {
+		names[i] = models.NormalizeTagName(name)
+	}
+
+	tags, err := repo.FindTags(names)
+	if err != nil {
+		return filter, err
+	}
+	ids := make(map[string]int, len(tags))
+	for _, tag := range tags {
+		ids[tag.Name] = tag.ID
+	}
+
+	filter.TagIDs = make([]int, len(names))
+	for i, name := range names {
+		filter.TagIDs[i] = ids[name]
+	}
+	return filter, nil
+}
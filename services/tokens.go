@@ -0,0 +1,291 @@
+package services
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+	ErrCannotIssue  = errors.New("no signing key configured")
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+)
+
+// placeholderSecrets are HS256 secrets that appeared in the repository or
+// its documentation; anyone could sign tokens with them.
+var placeholderSecrets = []string{
+	"dev-only-secret-change-me-in-production",
+	"your-256-bit-secret",
+}
+
+// Claims are the registered JWT claims the API issues and checks. Requests
+// authenticated with an API key get claims with APIKeyID and Scopes set,
+// which are never part of a token.
+type Claims struct {
+	Subject   string `json:"sub"`
+	Issuer    string `json:"iss,omitempty"`
+	IssuedAt  int64  `json:"iat"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	ExpiresAt int64  `json:"exp"`
+
+	APIKeyID int      `json:"-"`
+	Scopes   []string `json:"-"`
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+// TokenService issues and verifies compact JWTs signed with HS256 or RS256.
+// An RS256 service built from a public key only can verify but not issue.
+type TokenService struct {
+	alg        string
+	secret     []byte
+	privateKey *rsa.PrivateKey
+	publicKey  *rsa.PublicKey
+	issuer     string
+	ttl        time.Duration
+	now        func() time.Time
+}
+
+func NewHS256TokenService(secret []byte, issuer string, ttl time.Duration) (*TokenService, error) {
+	if len(secret) < 32 {
+		return nil, errors.New("HS256 secret must be at least 32 bytes")
+	}
+	if slices.Contains(placeholderSecrets, string(secret)) {
+		return nil, errors.New("HS256 secret is a published placeholder, generate a random one")
+	}
+	return &TokenService{alg: AlgHS256, secret: secret, issuer: issuer, ttl: ttl, now: time.Now}, nil
+}
+
+// NewRS256TokenService loads a PEM private key (PKCS#1 or PKCS#8) for
+// issuing and verifying, or only a PEM public key for verifying when
+// privateKeyFile is empty.
+func NewRS256TokenService(privateKeyFile, publicKeyFile, issuer string, ttl time.Duration) (*TokenService, error) {
+	s := &TokenService{alg: AlgRS256, issuer: issuer, ttl: ttl, now: time.Now}
+
+	if privateKeyFile != "" {
+		key, err := readRSAPrivateKey(privateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		s.privateKey = key
+		s.publicKey = &key.PublicKey
+	}
+	if publicKeyFile != "" {
+		key, err := readRSAPublicKey(publicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		s.publicKey = key
+	}
+	if s.publicKey == nil {
+		return nil, errors.New("RS256 needs a private or public key file")
+	}
+
+	return s, nil
+}
+
+func (s *TokenService) Algorithm() string {
+	return s.alg
+}
+
+// Issue signs a token for subject that expires after the configured TTL.
+func (s *TokenService) Issue(subject string) (string, time.Time, error) {
+	now := s.now()
+	expires := now.Add(s.ttl)
+	claims := Claims{
+		Subject:   subject,
+		Issuer:    s.issuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expires.Unix(),
+	}
+
+	header, err := encodeSegment(tokenHeader{Alg: s.alg, Typ: "JWT"})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	payload, err := encodeSegment(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	signingInput := header + "." + payload
+	signature, err := s.sign(signingInput)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), expires, nil
+}
+
+// Verify checks the signature, algorithm, issuer and time claims of token.
+func (s *TokenService) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	// Never let the token choose the algorithm.
+	if header.Alg != s.alg {
+		return nil, fmt.Errorf("%w: unexpected algorithm %q", ErrInvalidToken, header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+	if err := s.verify(parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+
+	now := s.now().Unix()
+	switch {
+	case claims.Subject == "":
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	case s.issuer != "" && claims.Issuer != s.issuer:
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, claims.Issuer)
+	case claims.ExpiresAt == 0:
+		return nil, fmt.Errorf("%w: missing expiry", ErrInvalidToken)
+	case now >= claims.ExpiresAt:
+		return nil, ErrTokenExpired
+	case claims.NotBefore != 0 && now < claims.NotBefore:
+		return nil, fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	}
+
+	return &claims, nil
+}
+
+func (s *TokenService) sign(signingInput string) ([]byte, error) {
+	switch s.alg {
+	case AlgHS256:
+		mac := hmac.New(sha256.New, s.secret)
+		mac.Write([]byte(signingInput))
+		return mac.Sum(nil), nil
+	case AlgRS256:
+		if s.privateKey == nil {
+			return nil, ErrCannotIssue
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		return rsa.SignPKCS1v15(rand.Reader, s.privateKey, crypto.SHA256, digest[:])
+	}
+	return nil, ErrCannotIssue
+}
+
+func (s *TokenService) verify(signingInput string, signature []byte) error {
+	switch s.alg {
+	case AlgHS256:
+		mac := hmac.New(sha256.New, s.secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		return nil
+	case AlgRS256:
+		digest := sha256.Sum256([]byte(signingInput))
+		if err := rsa.VerifyPKCS1v15(s.publicKey, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unsupported algorithm", ErrInvalidToken)
+}
+
+func encodeSegment(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeSegment(segment string, value interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: malformed segment", ErrInvalidToken)
+	}
+	if err := json.Unmarshal(data, value); err != nil {
+		return fmt.Errorf("%w: malformed segment", ErrInvalidToken)
+	}
+	return nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", path)
+	}
+	return block, nil
+}
+
+func readRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an RSA private key", path)
+	}
+	return key, nil
+}
+
+func readRSAPublicKey(path string) (*rsa.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s: not an RSA public key", path)
+	}
+	return key, nil
+}
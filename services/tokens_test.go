@@ -0,0 +1,207 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+var testNow = time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+
+func newTestHS256(t *testing.T, issuer string) *TokenService {
+	t.Helper()
+
+	s, err := NewHS256TokenService([]byte(testSecret), issuer, time.Hour)
+	if err != nil {
+		t.Fatalf("NewHS256TokenService: %v", err)
+	}
+	s.now = func() time.Time { return testNow }
+	return s
+}
+
+// writePEM writes a PEM block to a file in the test's temporary directory
+// and returns its path and contents.
+func writePEM(t *testing.T, name, blockType string, der []byte) (string, []byte) {
+	t.Helper()
+
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path, data
+}
+
+// forge builds a token with the given header and claims, signing it with
+// HMAC-SHA256 under secret, or leaving the signature empty when secret is nil.
+func forge(t *testing.T, header tokenHeader, claims Claims, secret []byte) string {
+	t.Helper()
+
+	h, err := encodeSegment(header)
+	if err != nil {
+		t.Fatalf("encode header: %v", err)
+	}
+	p, err := encodeSegment(claims)
+	if err != nil {
+		t.Fatalf("encode claims: %v", err)
+	}
+	signingInput := h + "." + p
+	if secret == nil {
+		return signingInput + "."
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestNewHS256TokenServiceRejectsWeakSecrets(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{"empty", ""},
+		{"short", "too-short"},
+		{"placeholder", "dev-only-secret-change-me-in-production"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewHS256TokenService([]byte(tt.secret), "", time.Hour); err == nil {
+				t.Errorf("NewHS256TokenService(%q) succeeded; want an error", tt.secret)
+			}
+		})
+	}
+}
+
+func TestTokenServiceVerify(t *testing.T) {
+	s := newTestHS256(t, "case")
+	valid := Claims{Subject: "alice", Issuer: "case", IssuedAt: testNow.Unix(), ExpiresAt: testNow.Add(time.Hour).Unix()}
+	hs256 := tokenHeader{Alg: AlgHS256, Typ: "JWT"}
+
+	with := func(change func(*Claims)) Claims {
+		claims := valid
+		change(&claims)
+		return claims
+	}
+
+	issued, _, err := s.Issue("alice")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	// Swap the payload of a genuine token for one naming another subject.
+	parts := strings.Split(issued, ".")
+	parts[1] = strings.Split(forge(t, hs256, with(func(c *Claims) { c.Subject = "admin" }), nil), ".")[1]
+	tampered := strings.Join(parts, ".")
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"issued token", issued, nil},
+		{"forged with the secret", forge(t, hs256, valid, []byte(testSecret)), nil},
+		{"alg none", forge(t, tokenHeader{Alg: "none", Typ: "JWT"}, valid, nil), ErrInvalidToken},
+		{"alg none with HS256 header and no signature", forge(t, hs256, valid, nil), ErrInvalidToken},
+		{"RS256 header", forge(t, tokenHeader{Alg: AlgRS256, Typ: "JWT"}, valid, []byte(testSecret)), ErrInvalidToken},
+		{"wrong secret", forge(t, hs256, valid, []byte("another-secret-of-at-least-32-bytes!")), ErrInvalidToken},
+		{"tampered claims", tampered, ErrInvalidToken},
+		{"malformed", "not-a-token", ErrInvalidToken},
+		{"expired", forge(t, hs256, with(func(c *Claims) { c.ExpiresAt = testNow.Unix() }), []byte(testSecret)), ErrTokenExpired},
+		{"missing expiry", forge(t, hs256, with(func(c *Claims) { c.ExpiresAt = 0 }), []byte(testSecret)), ErrInvalidToken},
+		{"not valid yet", forge(t, hs256, with(func(c *Claims) { c.NotBefore = testNow.Add(time.Minute).Unix() }), []byte(testSecret)), ErrInvalidToken},
+		{"wrong issuer", forge(t, hs256, with(func(c *Claims) { c.Issuer = "elsewhere" }), []byte(testSecret)), ErrInvalidToken},
+		{"missing issuer", forge(t, hs256, with(func(c *Claims) { c.Issuer = "" }), []byte(testSecret)), ErrInvalidToken},
+		{"missing subject", forge(t, hs256, with(func(c *Claims) { c.Subject = "" }), []byte(testSecret)), ErrInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := s.Verify(tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Verify error = %v; want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if claims.Subject != "alice" {
+				t.Errorf("Verify subject = %q; want alice", claims.Subject)
+			}
+		})
+	}
+}
+
+func TestTokenServiceVerifyExpiresAfterTTL(t *testing.T) {
+	s := newTestHS256(t, "")
+	token, expires, err := s.Issue("alice")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if !expires.Equal(testNow.Add(time.Hour)) {
+		t.Errorf("Issue expiry = %v; want %v", expires, testNow.Add(time.Hour))
+	}
+
+	s.now = func() time.Time { return expires.Add(-time.Second) }
+	if _, err := s.Verify(token); err != nil {
+		t.Errorf("Verify before expiry: %v", err)
+	}
+	s.now = func() time.Time { return expires }
+	if _, err := s.Verify(token); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("Verify at expiry: %v; want ErrTokenExpired", err)
+	}
+}
+
+func TestRS256TokenService(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	privateFile, _ := writePEM(t, "private.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key))
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+	publicFile, publicPEM := writePEM(t, "public.pem", "PUBLIC KEY", publicDER)
+
+	issuer, err := NewRS256TokenService(privateFile, "", "case", time.Hour)
+	if err != nil {
+		t.Fatalf("NewRS256TokenService(private): %v", err)
+	}
+	verifier, err := NewRS256TokenService("", publicFile, "case", time.Hour)
+	if err != nil {
+		t.Fatalf("NewRS256TokenService(public): %v", err)
+	}
+
+	token, _, err := issuer.Issue("alice")
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if claims, err := verifier.Verify(token); err != nil || claims.Subject != "alice" {
+		t.Errorf("Verify = %+v, %v; want alice", claims, err)
+	}
+	if _, _, err := verifier.Issue("alice"); !errors.Is(err, ErrCannotIssue) {
+		t.Errorf("Issue with only a public key: %v; want ErrCannotIssue", err)
+	}
+
+	// The classic confusion attack: an HS256 token keyed with the public
+	// key, which an attacker can read, must not pass an RS256 verifier.
+	claims := Claims{Subject: "admin", Issuer: "case", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+	forged := forge(t, tokenHeader{Alg: AlgHS256, Typ: "JWT"}, claims, publicPEM)
+	if _, err := verifier.Verify(forged); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify of an HS256 token keyed with the public key: %v; want ErrInvalidToken", err)
+	}
+}
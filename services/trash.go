@@ -0,0 +1,52 @@
+package services
+
+import (
+	"case/models"
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func (s *SongService) GetTrashedSongs(page, limit int) ([]models.TrashedSong, int, error) {
+	return s.repo.GetTrashedSongs(page, limit)
+}
+
+func (s *SongService) RestoreSong(id int) (models.Song, error) {
+	if err := s.repo.RestoreSong(id); err != nil {
+		return models.Song{}, err
+	}
+	return s.repo.GetSong(id)
+}
+
+// PurgeTrash permanently deletes the songs that have been in the trash for
+// longer than retention.
+func (s *SongService) PurgeTrash(retention time.Duration) (int, error) {
+	return s.repo.PurgeSongs(time.Now().Add(-retention))
+}
+
+// RunTrashPurge calls PurgeTrash every interval until ctx is done. Purging
+// is idempotent, so several replicas may run it at once.
+func (s *SongService) RunTrashPurge(ctx context.Context, retention, interval time.Duration, log *logrus.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := s.PurgeTrash(retention)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"error": err,
+			}).Error("Failed to purge trash")
+		} else if purged > 0 {
+			log.WithFields(logrus.Fields{
+				"purged": purged,
+			}).Info("Purged songs from trash")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}